@@ -22,14 +22,19 @@ if [ ! -f "$CONFIG_PATH" ]; then
     # Use defaults for optional vars
     WEBHOOK_PORT="${WEBHOOK_PORT:-8080}"
     WEBHOOK_TOKEN="${WEBHOOK_TOKEN:-}"
+    WEBHOOK_QUERY_TOKEN="${WEBHOOK_QUERY_TOKEN:-false}"
     SYNC_DAYS="${SYNC_DAYS:-1}"
     DEFAULT_DURATION="${DEFAULT_DURATION:-60}"
     CHECK_INTERVAL="${CHECK_INTERVAL:-60}"
+    SMART_POLLING="${SMART_POLLING:-true}"
     QUIET_HOURS_START="${QUIET_HOURS_START:-0}"
     QUIET_HOURS_END="${QUIET_HOURS_END:-6}"
     ENABLE_SCHEDULER="${ENABLE_SCHEDULER:-true}"
     DRY_RUN="${DRY_RUN:-false}"
+    MQTT_BROKER="${MQTT_BROKER:-}"
+    LOG_LEVEL="${LOG_LEVEL:-info}"
     DATA_DIR="${DATA_DIR:-/data}"
+    WEBHOOK_INGRESS_PORT="${WEBHOOK_INGRESS_PORT:-}"
 else
     # Read configuration from Home Assistant add-on options
     echo "Loading configuration from options.json..."
@@ -37,34 +42,51 @@ else
     STRAVA_CLIENT_ID=$(jq -r '.strava_client_id' $CONFIG_PATH)
     STRAVA_CLIENT_SECRET=$(jq -r '.strava_client_secret' $CONFIG_PATH)
     STRAVA_REFRESH_TOKEN=$(jq -r '.strava_refresh_token' $CONFIG_PATH)
+    STRAVA_REDIRECT_URI=$(jq -r '.strava_redirect_uri // empty' $CONFIG_PATH)
     AIMHARDER_EMAIL=$(jq -r '.aimharder_email' $CONFIG_PATH)
     AIMHARDER_PASSWORD=$(jq -r '.aimharder_password' $CONFIG_PATH)
     AIMHARDER_BOX_ID=$(jq -r '.aimharder_box_id' $CONFIG_PATH)
     AIMHARDER_USER_ID=$(jq -r '.aimharder_user_id' $CONFIG_PATH)
     WEBHOOK_TOKEN=$(jq -r '.webhook_token' $CONFIG_PATH)
     WEBHOOK_PORT=$(jq -r '.webhook_port' $CONFIG_PATH)
+    WEBHOOK_QUERY_TOKEN=$(jq -r '.webhook_query_token // false' $CONFIG_PATH)
     SYNC_DAYS=$(jq -r '.sync_days' $CONFIG_PATH)
     DEFAULT_DURATION=$(jq -r '.default_duration // 60' $CONFIG_PATH)
     CHECK_INTERVAL=$(jq -r '.check_interval' $CONFIG_PATH)
+    SMART_POLLING=$(jq -r '.smart_polling // true' $CONFIG_PATH)
     QUIET_HOURS_START=$(jq -r '.quiet_hours_start' $CONFIG_PATH)
     QUIET_HOURS_END=$(jq -r '.quiet_hours_end' $CONFIG_PATH)
     ENABLE_SCHEDULER=$(jq -r '.enable_scheduler' $CONFIG_PATH)
     DRY_RUN=$(jq -r '.dry_run' $CONFIG_PATH)
+    MQTT_BROKER=$(jq -r '.mqtt_broker // empty' $CONFIG_PATH)
+    MQTT_USERNAME=$(jq -r '.mqtt_username // empty' $CONFIG_PATH)
+    MQTT_PASSWORD=$(jq -r '.mqtt_password // empty' $CONFIG_PATH)
+    LOG_LEVEL=$(jq -r '.log_level // "info"' $CONFIG_PATH)
     
     # Use /share for persistent storage on HAOS
     DATA_DIR="/share/aimharder-sync"
+
+    # Dashboard in the Home Assistant sidebar (ingress_port in config.yaml)
+    WEBHOOK_INGRESS_PORT=8099
 fi
 
 # Export environment variables for the Go binary
 export STRAVA_CLIENT_ID
 export STRAVA_CLIENT_SECRET
 export STRAVA_REFRESH_TOKEN
+export STRAVA_REDIRECT_URI
 export AIMHARDER_EMAIL
 export AIMHARDER_PASSWORD
 export AIMHARDER_BOX_ID
 export AIMHARDER_USER_ID
 export AIMHARDER_DEFAULT_DURATION="${DEFAULT_DURATION}m"
 export DATA_DIR
+export WEBHOOK_QUERY_TOKEN
+export WEBHOOK_INGRESS_PORT
+export MQTT_BROKER
+export MQTT_USERNAME
+export MQTT_PASSWORD
+export AIMHARDER_LOG_LEVEL="$LOG_LEVEL"
 
 # Create data directory
 mkdir -p "$DATA_DIR"
@@ -96,8 +118,11 @@ echo "  - Sync Days: $SYNC_DAYS"
 echo "  - Default Duration: ${DEFAULT_DURATION}m"
 echo "  - Scheduler Enabled: $ENABLE_SCHEDULER"
 echo "  - Check Interval: ${CHECK_INTERVAL}s"
+echo "  - Smart Polling: $SMART_POLLING"
 echo "  - Quiet Hours: $QUIET_HOURS_START:00 - $QUIET_HOURS_END:00"
 echo "  - Dry Run: $DRY_RUN"
+echo "  - MQTT Broker: ${MQTT_BROKER:-disabled}"
+echo "  - Log Level: $LOG_LEVEL"
 echo "  - Data Dir: $DATA_DIR"
 
 # Build dry-run flag
@@ -106,75 +131,25 @@ if [ "$DRY_RUN" = "true" ]; then
     DRY_RUN_FLAG="--dry-run"
 fi
 
-# Function to run sync
-run_sync() {
-    echo "[$(date '+%Y-%m-%d %H:%M:%S')] Running sync..."
-    /app/aimharder-sync sync --days "$SYNC_DAYS" $DRY_RUN_FLAG 2>&1 || true
-}
-
-# Function to check if in quiet hours
-in_quiet_hours() {
-    if [ "$QUIET_HOURS_START" = "$QUIET_HOURS_END" ]; then
-        return 1  # No quiet hours
-    fi
-    
-    current_hour=$(date +%H | sed 's/^0//')
-    
-    if [ "$QUIET_HOURS_START" -lt "$QUIET_HOURS_END" ]; then
-        # Simple range (e.g., 2-6)
-        [ "$current_hour" -ge "$QUIET_HOURS_START" ] && [ "$current_hour" -lt "$QUIET_HOURS_END" ]
-    else
-        # Wrapping range (e.g., 22-6)
-        [ "$current_hour" -ge "$QUIET_HOURS_START" ] || [ "$current_hour" -lt "$QUIET_HOURS_END" ]
-    fi
-}
-
-# Start webhook server in background
-echo "Starting webhook server on port $WEBHOOK_PORT..."
-/app/aimharder-sync webhook --port "$WEBHOOK_PORT" --token "$WEBHOOK_TOKEN" &
-WEBHOOK_PID=$!
-
-# Give webhook time to start
-sleep 2
-
-# Check if webhook started successfully
-if ! kill -0 $WEBHOOK_PID 2>/dev/null; then
-    echo "ERROR: Webhook server failed to start"
-    exit 1
+# Schedule for the daemon's built-in scheduler: poll around booked classes,
+# or every check_interval seconds
+if [ "$SMART_POLLING" = "true" ]; then
+    export AIMHARDER_SCHEDULE_MODE=classes
+    SCHEDULE_DESC="around booked classes"
+else
+    export AIMHARDER_SCHEDULE_MODE=cron
+    SCHEDULE_DESC="every ${CHECK_INTERVAL}s"
+fi
+export AIMHARDER_SCHEDULE="@every ${CHECK_INTERVAL}s"
+export SYNC_DAYS
+if [ "$QUIET_HOURS_START" != "$QUIET_HOURS_END" ]; then
+    export AIMHARDER_QUIET_HOURS="${QUIET_HOURS_START}:00-${QUIET_HOURS_END}:00"
 fi
 
-echo "Webhook server started (PID: $WEBHOOK_PID)"
-
-# Run scheduler if enabled
 if [ "$ENABLE_SCHEDULER" = "true" ]; then
-    echo "Scheduler enabled - checking every ${CHECK_INTERVAL}s"
-    
-    # Initial sync on startup
-    echo "Running initial sync..."
-    run_sync
-    
-    # Scheduler loop
-    while true; do
-        sleep "$CHECK_INTERVAL"
-        
-        # Check if webhook is still running
-        if ! kill -0 $WEBHOOK_PID 2>/dev/null; then
-            echo "ERROR: Webhook server died, restarting..."
-            /app/aimharder-sync webhook --port "$WEBHOOK_PORT" --token "$WEBHOOK_TOKEN" &
-            WEBHOOK_PID=$!
-            sleep 2
-        fi
-        
-        # Skip during quiet hours
-        if in_quiet_hours; then
-            echo "[$(date '+%Y-%m-%d %H:%M:%S')] Quiet hours - skipping sync"
-            continue
-        fi
-        
-        run_sync
-    done
+    echo "Starting daemon (webhook server + scheduler ${SCHEDULE_DESC}) on port $WEBHOOK_PORT..."
+    exec /app/aimharder-sync daemon --port "$WEBHOOK_PORT" --token "$WEBHOOK_TOKEN" $DRY_RUN_FLAG
 else
     echo "Scheduler disabled - webhook only mode"
-    # Wait for webhook to exit
-    wait $WEBHOOK_PID
+    exec /app/aimharder-sync webhook --port "$WEBHOOK_PORT" --token "$WEBHOOK_TOKEN" $DRY_RUN_FLAG
 fi
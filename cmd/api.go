@@ -0,0 +1,535 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/apisec"
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/jobs"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
+	"github.com/aimharder-sync/internal/runlog"
+)
+
+// maxRequestDays caps ?days= on webhook requests; longer ranges need
+// start and end dates
+const maxRequestDays = 365
+
+func invalidParam(field, message string) *api.Error {
+	return &api.Error{Status: http.StatusBadRequest, Code: "invalid_request", Message: message, Field: field}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, e *api.Error) {
+	writeJSON(w, e.Status, e)
+}
+
+var errJobNotFound = &api.Error{Status: http.StatusNotFound, Code: "not_found", Message: "job not found"}
+
+// handleOpenAPI describes the API. It needs no token, like /health.
+func (s *WebhookServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.OpenAPI())
+}
+
+// syncParams are the options of POST /sync and the workout endpoints, from
+// query parameters or a JSON body
+type syncParams api.SyncRequest
+
+// parseSyncParams reads the query string, then a JSON body on top of it
+func parseSyncParams(r *http.Request) (*syncParams, *api.Error) {
+	p := &syncParams{}
+	q := r.URL.Query()
+
+	if v := q.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, invalidParam("days", fmt.Sprintf("days must be a whole number, got %q", v))
+		}
+		p.Days = &n
+	}
+	p.Start, p.End = q.Get("start"), q.Get("end")
+	if v := q.Get("platforms"); v != "" {
+		p.Platforms = []string{v}
+	}
+	flags := []struct {
+		name string
+		dst  **bool
+	}{{"dry_run", &p.DryRun}, {"force", &p.Force}, {"wait", &p.Wait}}
+	for _, flag := range flags {
+		if v := q.Get(flag.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, invalidParam(flag.name, fmt.Sprintf("%s must be true or false, got %q", flag.name, v))
+			}
+			*flag.dst = &b
+		}
+	}
+
+	if r.Body != nil && r.ContentLength != 0 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		dec := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+			return nil, invalidParam("", fmt.Sprintf("invalid JSON body: %v", err))
+		}
+	}
+	return p, nil
+}
+
+// wait reports whether the client asked to block until the job finishes
+func (p *syncParams) wait() bool {
+	return p.Wait != nil && *p.Wait
+}
+
+// request validates the parameters and turns them into a job request
+func (p *syncParams) request(c *config.Config, defaultDays int) (jobs.Request, *api.Error) {
+	req := jobs.Request{Days: defaultDays, Start: p.Start, End: p.End}
+	if p.Days != nil {
+		if *p.Days < 1 || *p.Days > maxRequestDays {
+			return req, invalidParam("days", fmt.Sprintf("days must be between 1 and %d", maxRequestDays))
+		}
+		req.Days = *p.Days
+	}
+	for _, date := range []struct{ field, value string }{{"start", p.Start}, {"end", p.End}} {
+		if date.value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date.value); err != nil {
+			return req, invalidParam(date.field, fmt.Sprintf("%s must be a date (YYYY-MM-DD), got %q", date.field, date.value))
+		}
+	}
+	if start, end, _ := parseDateRange(req.Days, p.Start, p.End); start.After(end) {
+		return req, invalidParam("start", "start must not be after end")
+	}
+
+	if len(p.Platforms) > 0 {
+		names, err := destination.ParseList(p.Platforms)
+		if err != nil {
+			return req, invalidParam("platforms", err.Error())
+		}
+		req.Destinations = names
+	}
+	if p.DryRun != nil {
+		req.DryRun = *p.DryRun
+	}
+	if p.Force != nil {
+		req.Force = *p.Force
+	}
+	return req, nil
+}
+
+// destinations returns the platforms a request syncs to
+func (s *WebhookServer) destinations(req jobs.Request) ([]string, error) {
+	if len(req.Destinations) > 0 {
+		return req.Destinations, nil
+	}
+	return destination.ParseList(s.cfg.Sync.Destinations)
+}
+
+// buildPreviews describes the activities a sync would create on each
+// destination, the data `--dry-run` prints. files[i] is the activity file of
+// workouts[i]; when they don't line up, previews are built without files
+// rather than from another workout's.
+func buildPreviews(c *config.Config, destinations []string, workouts []models.Workout, files []string, history map[string][]models.SyncStatus, force bool) []api.SyncPreview {
+	previews := []api.SyncPreview{}
+	for _, name := range destinations {
+		dest, err := destination.New(name, c)
+		if err != nil {
+			dest = nil // generic preview for unconfigured destinations
+		}
+		for i := range workouts {
+			w := &workouts[i]
+			file := ""
+			if len(files) == len(workouts) {
+				file = files[i]
+			}
+			action := "upload"
+			if !force && destination.LastSuccess(history, w.ID, name) != nil {
+				action = "skip"
+			}
+			preview, elapsed := previewActivity(dest, w, file)
+			previews = append(previews, api.SyncPreview{
+				Platform:    name,
+				WorkoutID:   w.ID,
+				Action:      action,
+				Activity:    preview,
+				ElapsedTime: elapsed,
+			})
+		}
+	}
+	return previews
+}
+
+// enqueueSync queues a sync job and writes the response: 202 with the job
+// ID, or the job's result when the client waits
+func (s *WebhookServer) enqueueSync(w http.ResponseWriter, r *http.Request, req jobs.Request, wait bool) {
+	job, err := s.jobs.Enqueue("webhook", req)
+	if err != nil {
+		writeError(w, &api.Error{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "queue_full",
+			Message: "Too many syncs are waiting. Please try again later.",
+		})
+		return
+	}
+	s.log.Info("Queued job", logging.Icon("📋"), "job", job.ID, "request", req.Describe())
+
+	if wait {
+		s.waitForJob(w, r, job.ID)
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, api.JobAccepted{
+		JobID:     job.ID,
+		State:     job.State,
+		StatusURL: "/jobs/" + job.ID,
+		EventsURL: "/jobs/" + job.ID + "/events",
+	})
+}
+
+// handleRuns lists recorded sync runs, newest first (optional: ?limit=N,
+// ?trigger=, ?failed=true, ?workout=ID, ?since=YYYY-MM-DD)
+func (s *WebhookServer) handleRuns(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	q := r.URL.Query()
+	query := api.RunsQuery{
+		Limit:   defaultRunsLimit,
+		Trigger: q.Get("trigger"),
+		Workout: q.Get("workout"),
+		Since:   q.Get("since"),
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, invalidParam("limit", fmt.Sprintf("limit must be a whole number of at least 0, got %q", v)))
+			return
+		}
+		query.Limit = n
+	}
+	if v := q.Get("failed"); v != "" {
+		failed, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, invalidParam("failed", fmt.Sprintf("failed must be true or false, got %q", v)))
+			return
+		}
+		query.Failed = failed
+	}
+	filter, err := runFilter(query)
+	if err != nil {
+		writeError(w, invalidParam("since", err.Error()))
+		return
+	}
+	runs, err := runStore(s.cfg).List(filter)
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "run_log_error", Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.RunList{Runs: runs})
+}
+
+// handleRun returns a recorded sync run with its workout decisions
+func (s *WebhookServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	run, err := runStore(s.cfg).Get(r.PathValue("id"))
+	if errors.Is(err, runlog.ErrNotFound) {
+		writeError(w, &api.Error{Status: http.StatusNotFound, Code: "not_found", Message: "run not found; it may have been pruned"})
+		return
+	}
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "run_log_error", Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// platformState summarizes the history of a workout on a platform
+func platformState(history map[string][]models.SyncStatus, workoutID, platform string) api.PlatformState {
+	if last := destination.LastSuccess(history, workoutID, platform); last != nil {
+		syncedAt := last.SyncedAt
+		return api.PlatformState{Status: "synced", ExternalID: last.ExternalID, SyncedAt: &syncedAt}
+	}
+	if last := destination.LastAttempt(history, workoutID, platform); last != nil {
+		return api.PlatformState{Status: "failed", Error: last.ErrorMessage}
+	}
+	return api.PlatformState{Status: "pending"}
+}
+
+// handleWorkouts lists fetched workouts, newest first, with their sync
+// state (optional: ?days=N or ?start=&end=, ?platforms=a,b)
+func (s *WebhookServer) handleWorkouts(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	p, apiErr := parseSyncParams(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	req, apiErr := p.request(s.cfg, s.cfg.Sync.DefaultDays)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	platforms, err := s.destinations(req)
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "config_error", Message: err.Error()})
+		return
+	}
+	start, end, _ := parseDateRange(req.Days, req.Start, req.End)
+
+	history := loadSyncHistory(s.cfg.Storage.HistoryFile)
+	list := []api.WorkoutState{}
+	for _, workout := range loadWorkoutCache(s.cfg.Storage.CacheFile) {
+		if workout.Date.Before(start) || workout.Date.After(end) {
+			continue
+		}
+		state := api.WorkoutState{
+			ID:        workout.ID,
+			Date:      workout.Date,
+			Name:      workout.Name,
+			Type:      workout.Type,
+			ClassTime: workout.ClassTime,
+			Synced:    true,
+			Platforms: make(map[string]api.PlatformState, len(platforms)),
+		}
+		for _, name := range platforms {
+			ps := platformState(history, workout.ID, name)
+			state.Platforms[name] = ps
+			state.Synced = state.Synced && ps.Status == "synced"
+		}
+		list = append(list, state)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Date.After(list[j].Date) })
+
+	writeJSON(w, http.StatusOK, api.WorkoutList{
+		Start:    start.Format("2006-01-02"),
+		End:      end.Format("2006-01-02"),
+		Count:    len(list),
+		Workouts: list,
+	})
+}
+
+// selectWorkouts returns the workouts with the given IDs
+func selectWorkouts(workouts []models.Workout, ids []string) []models.Workout {
+	var selected []models.Workout
+	for _, workout := range workouts {
+		if slices.Contains(ids, workout.ID) {
+			selected = append(selected, workout)
+		}
+	}
+	return selected
+}
+
+// cachedWorkout finds a fetched workout by ID
+func (s *WebhookServer) cachedWorkout(id string) (*models.Workout, *api.Error) {
+	for _, workout := range loadWorkoutCache(s.cfg.Storage.CacheFile) {
+		if workout.ID == id {
+			return &workout, nil
+		}
+	}
+	return nil, &api.Error{
+		Status:  http.StatusNotFound,
+		Code:    "not_found",
+		Message: fmt.Sprintf("workout %s has not been fetched; sync the day it was logged first", id),
+	}
+}
+
+// handleWorkout returns a fetched workout (optional: ?platforms=a,b)
+func (s *WebhookServer) handleWorkout(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	workout, apiErr := s.cachedWorkout(r.PathValue("id"))
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	p, apiErr := parseSyncParams(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	req, apiErr := p.request(s.cfg, 1)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	platforms, err := s.destinations(req)
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "config_error", Message: err.Error()})
+		return
+	}
+
+	renderer, err := newRenderer(s.cfg)
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "config_error", Message: err.Error()})
+		return
+	}
+	html, err := renderer.Render(render.HTML, workout)
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "render_error", Message: err.Error()})
+		return
+	}
+
+	history := loadSyncHistory(s.cfg.Storage.HistoryFile)
+	detail := api.WorkoutDetail{Workout: workout, HTML: html, Platforms: make(map[string]api.PlatformState, len(platforms))}
+	for _, name := range platforms {
+		detail.Platforms[name] = platformState(history, workout.ID, name)
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// handleResync queues a sync of one workout, re-uploading it even if it was
+// synced before (optional: ?platforms=a,b, ?force=false, ?dry_run=true,
+// ?wait=true)
+func (s *WebhookServer) handleResync(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeTrigger) {
+		return
+	}
+	workout, apiErr := s.cachedWorkout(r.PathValue("id"))
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	p, apiErr := parseSyncParams(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	if p.Days != nil || p.Start != "" || p.End != "" {
+		writeError(w, invalidParam("days", "the date range of a resync is the workout's date"))
+		return
+	}
+
+	day := workout.Date.In(time.Local).Format("2006-01-02")
+	p.Start, p.End = day, day
+	if p.Force == nil {
+		force := true
+		p.Force = &force
+	}
+	req, apiErr := p.request(s.cfg, 1)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	req.WorkoutIDs = []string{workout.ID}
+	s.enqueueSync(w, r, req, p.wait())
+}
+
+// handleUnsync removes a workout's sync state so the next sync uploads it
+// again (optional: ?platforms=a,b; ?delete_activity=true also deletes the
+// uploaded activity where the platform allows it)
+func (s *WebhookServer) handleUnsync(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeTrigger) {
+		return
+	}
+	id := r.PathValue("id")
+	q := r.URL.Query()
+
+	deleteActivity := false
+	if v := q.Get("delete_activity"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, invalidParam("delete_activity", fmt.Sprintf("delete_activity must be true or false, got %q", v)))
+			return
+		}
+		deleteActivity = b
+	}
+	var platforms []string
+	if v := q.Get("platforms"); v != "" {
+		names, err := destination.ParseList([]string{v})
+		if err != nil {
+			writeError(w, invalidParam("platforms", err.Error()))
+			return
+		}
+		platforms = names
+	}
+
+	// Syncs load history at the start and save it at the end
+	if !s.historyMu.TryLock() {
+		writeError(w, &api.Error{
+			Status:  http.StatusConflict,
+			Code:    "sync_running",
+			Message: "A sync is running. Please try again when it has finished.",
+		})
+		return
+	}
+	defer s.historyMu.Unlock()
+
+	history := loadSyncHistory(s.cfg.Storage.HistoryFile)
+	if platforms == nil {
+		seen := make(map[string]bool)
+		for _, status := range history[id] {
+			if !seen[status.Platform] {
+				seen[status.Platform] = true
+				platforms = append(platforms, status.Platform)
+			}
+		}
+	}
+
+	var removals []api.PlatformRemoval
+	for _, name := range platforms {
+		if destination.LastAttempt(history, id, name) == nil {
+			continue
+		}
+		removal := api.PlatformRemoval{Platform: name}
+		if last := destination.LastSuccess(history, id, name); last != nil {
+			removal.ExternalID = last.ExternalID
+		}
+
+		if deleteActivity && removal.ExternalID != "" {
+			dest, err := destination.New(name, s.cfg)
+			if err == nil {
+				err = dest.Delete(r.Context(), removal.ExternalID)
+			}
+			if err != nil {
+				// Keep the history so the activity isn't uploaded twice
+				removal.Error = err.Error()
+				removals = append(removals, removal)
+				continue
+			}
+			removal.ActivityDeleted = true
+		}
+
+		destination.Forget(history, id, name)
+		removal.Forgotten = true
+		removals = append(removals, removal)
+	}
+
+	if len(removals) == 0 {
+		writeError(w, &api.Error{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: fmt.Sprintf("workout %s has no sync history", id),
+		})
+		return
+	}
+	if err := saveSyncHistory(s.cfg.Storage.HistoryFile, history); err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "storage_error", Message: err.Error()})
+		return
+	}
+	s.log.Info("Removed sync state", logging.Icon("🗑️ "), "workout", id)
+
+	writeJSON(w, http.StatusOK, api.UnsyncResult{WorkoutID: id, Platforms: removals})
+}
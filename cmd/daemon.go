@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aimharder-sync/internal/aimharder"
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/schedule"
+	"github.com/aimharder-sync/internal/tracing"
+	"github.com/spf13/cobra"
+)
+
+func newDaemonCmd() *cobra.Command {
+	var (
+		port      string
+		authToken string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the webhook server and scheduled syncs",
+		Long: `Run the webhook server together with a scheduler that syncs periodically.
+
+Scheduled syncs go through the same job queue as POST /sync and the Home
+Assistant button, and are skipped while another sync is queued or running.
+By default the daemon reads your booked classes and polls often only in
+the window after each class ends, when results are usually logged, and
+rarely otherwise. The schedule is configured under 'schedule' in
+config.yaml:
+
+  schedule:
+    mode: classes               # or "cron" to poll on a fixed schedule
+    fast_interval: 5m           # while a window is open
+    slow_interval: 1h           # otherwise
+    window: 2h                  # fast polling after each class ends
+    timetable:                  # used when no classes are booked
+      - "mon-fri 07:00-08:00"
+      - "sat 10:00-11:00"
+    cron: "*/15 6-23 * * *"     # cron mode: or "@every 5m", "@hourly", ...
+    days: 1                     # days to look back on each sync
+    quiet_hours: "00:00-06:00"  # no scheduled syncs in this window
+    jitter: 30s                 # random delay added to each sync
+    run_on_start: true
+    backoff_after: 3            # failures in a row before backing off
+    max_backoff: 1h
+
+Examples:
+  # Poll around booked classes and serve webhooks on port 8080
+  aimharder-sync daemon --token mysecrettoken
+
+  # Sync every 15 minutes during the day
+  AIMHARDER_SCHEDULE_MODE=cron AIMHARDER_SCHEDULE="*/15 7-22 * * *" aimharder-sync daemon`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(cfg, port, authToken)
+		},
+	}
+
+	cmd.Flags().StringVar(&port, "port", "8080", "port to listen on")
+	cmd.Flags().StringVar(&authToken, "token", "", "authentication token (optional but recommended)")
+
+	return cmd
+}
+
+// scheduleOptions validates the schedule config. In classes mode it also
+// returns the class-aligned polling that the daemon keeps up to date.
+func scheduleOptions(c *config.Config) (schedule.Options, *classPolling, error) {
+	quiet, err := schedule.ParseQuietHours(c.Schedule.QuietHours)
+	if err != nil {
+		return schedule.Options{}, nil, err
+	}
+	opts := schedule.Options{
+		Quiet:        quiet,
+		Jitter:       c.Schedule.Jitter,
+		RunOnStart:   c.Schedule.RunOnStart,
+		BackoffAfter: c.Schedule.BackoffAfter,
+		MaxBackoff:   c.Schedule.MaxBackoff,
+		Logger:       logging.Component("scheduler"),
+	}
+
+	switch c.Schedule.Mode {
+	case "cron":
+		if opts.Schedule, err = schedule.Parse(c.Schedule.Cron); err != nil {
+			return schedule.Options{}, nil, err
+		}
+		return opts, nil, nil
+	case "classes", "":
+		polling, err := newClassPolling(c)
+		if err != nil {
+			return schedule.Options{}, nil, err
+		}
+		opts.Schedule = polling.schedule
+		return opts, polling, nil
+	default:
+		return schedule.Options{}, nil, fmt.Errorf("unknown schedule mode %q (expected classes or cron)", c.Schedule.Mode)
+	}
+}
+
+// classPolling keeps the windows of a class-aligned schedule up to date
+// from the athlete's booked classes, or the configured timetable
+type classPolling struct {
+	cfg       *config.Config
+	schedule  *schedule.Classes
+	timetable schedule.Timetable
+	log       *slog.Logger
+}
+
+func newClassPolling(c *config.Config) (*classPolling, error) {
+	if c.Schedule.FastInterval < time.Second || c.Schedule.SlowInterval < time.Second {
+		return nil, fmt.Errorf("schedule fast_interval and slow_interval must be at least 1s")
+	}
+	timetable, err := schedule.ParseTimetable(c.Schedule.Timetable)
+	if err != nil {
+		return nil, err
+	}
+	return &classPolling{
+		cfg:       c,
+		schedule:  schedule.NewClasses(c.Schedule.FastInterval, c.Schedule.SlowInterval),
+		timetable: timetable,
+		log:       logging.Component("scheduler"),
+	}, nil
+}
+
+// stale reports whether the booked classes should be read again
+func (p *classPolling) stale() bool {
+	return time.Since(p.schedule.State().UpdatedAt) >= p.cfg.Schedule.Refresh
+}
+
+// refresh reads the booked classes and replaces the polling windows. When
+// Aimharder can't be reached the current windows are kept until the next
+// attempt.
+func (p *classPolling) refresh(ctx context.Context) {
+	now := time.Now()
+	bookings, err := p.bookings(ctx)
+	if err != nil {
+		p.log.Warn("Could not read booked classes", "error", err)
+		if len(p.schedule.State().Windows) > 0 {
+			return
+		}
+	}
+
+	var classes []schedule.Window
+	for _, b := range bookings {
+		start, end, err := b.TimeRange(time.Local)
+		if err != nil {
+			continue
+		}
+		classes = append(classes, schedule.Window{Start: start, End: end, Class: b.ClassName})
+	}
+	source := "bookings"
+	if len(classes) == 0 {
+		// Start yesterday for late classes whose window runs past midnight
+		classes = p.timetable.Classes(now.AddDate(0, 0, -1), 3)
+		source = "timetable"
+	}
+	if len(classes) == 0 {
+		source = "none"
+	}
+
+	var windows []schedule.Window
+	for _, w := range schedule.WindowsAfter(classes, p.cfg.Schedule.Window) {
+		if w.End.After(now) {
+			windows = append(windows, w)
+		}
+	}
+	p.schedule.SetWindows(windows, source)
+
+	if len(windows) > 0 {
+		p.log.Info("Polling windows updated", logging.Icon("📅"), "windows", len(windows), "source", source, "next", windows[0].String())
+	} else {
+		p.log.Info("No classes booked", logging.Icon("📅"), "interval", p.cfg.Schedule.SlowInterval)
+	}
+	if err := schedule.SaveState(p.cfg.Storage.ScheduleFile, p.schedule.State()); err != nil {
+		p.log.Warn("Could not save schedule state", "error", err)
+	}
+}
+
+// bookings returns today's and tomorrow's booked classes
+func (p *classPolling) bookings(ctx context.Context) (bookings []models.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "bookings")
+	defer func() {
+		span.SetAttributes(tracing.Int("bookings", len(bookings)))
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if err := p.cfg.Validate(); err != nil {
+		return nil, err
+	}
+	client, err := aimharder.NewClient(p.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Aimharder client: %w", err)
+	}
+	if err := client.Login(ctx); err != nil {
+		return nil, fmt.Errorf("failed to login to Aimharder: %w", err)
+	}
+	return client.GetUpcomingBookings(ctx, 2)
+}
+
+// describe summarizes the policy, e.g. for the daemon's startup banner
+func (p *classPolling) describe() string {
+	return fmt.Sprintf("every %s for %s after each class, otherwise every %s",
+		p.cfg.Schedule.FastInterval, p.cfg.Schedule.Window, p.cfg.Schedule.SlowInterval)
+}
+
+// printSchedule shows the daemon's polling policy for the status command
+func printSchedule(c *config.Config) {
+	if c.Schedule.Mode == "cron" {
+		fmt.Printf("\n%sSchedule: cron %s\n", emoji("⏰"), c.Schedule.Cron)
+	} else {
+		fmt.Printf("\n%sSchedule: classes (every %s for %s after each class, otherwise every %s)\n", emoji("⏰"),
+			c.Schedule.FastInterval, c.Schedule.Window, c.Schedule.SlowInterval)
+		state, err := schedule.LoadState(c.Storage.ScheduleFile)
+		if err != nil {
+			fmt.Println("   No polling windows yet (start the daemon)")
+		} else {
+			p := state.Policy(time.Now())
+			if p.Current != nil {
+				fmt.Printf("   %sFast polling until %s (%s)\n", emoji("🟢"), p.Until.Format("15:04"), p.Current.Class)
+			} else {
+				fmt.Printf("   %sSlow polling (every %s)\n", emoji("🐢"), p.Interval)
+			}
+			if p.NextWindow != nil {
+				fmt.Printf("   Next window: %s\n", p.NextWindow)
+			}
+			fmt.Printf("   Windows from %s, updated %s\n", p.Source, p.UpdatedAt.Format("2006-01-02 15:04"))
+		}
+	}
+	if c.Schedule.QuietHours != "" {
+		fmt.Printf("   Quiet hours: %s\n", c.Schedule.QuietHours)
+	}
+}
+
+func runDaemon(c *config.Config, port, authToken string) error {
+	opts, polling, err := scheduleOptions(c)
+	if err != nil {
+		return err
+	}
+	days := max(c.Schedule.Days, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle graceful shutdown
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		slog.Warn("Shutting down daemon")
+		cancel()
+	}()
+
+	args := []any{logging.Icon("🚀"), "days", days}
+	if polling != nil {
+		args = append(args, "schedule", polling.describe())
+	} else {
+		args = append(args, "schedule", c.Schedule.Cron)
+	}
+	if opts.Quiet != nil {
+		args = append(args, "quiet_hours", opts.Quiet.String())
+	}
+	if opts.Jitter > 0 {
+		args = append(args, "jitter", opts.Jitter)
+	}
+	if dryRun {
+		args = append(args, "dry_run", true)
+	}
+	slog.Info("AimHarder Sync daemon started", args...)
+
+	server, err := NewWebhookServer(c, port, authToken)
+	if err != nil {
+		return err
+	}
+	server.dryRun = dryRun
+	if polling != nil {
+		server.polling = polling.schedule
+	}
+	go func() {
+		if polling != nil {
+			polling.refresh(ctx)
+		}
+		schedule.Run(ctx, opts, func(ctx context.Context) error {
+			if polling != nil && polling.stale() {
+				polling.refresh(ctx)
+			}
+			return server.scheduledSync(ctx, days)
+		})
+	}()
+
+	if err := server.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
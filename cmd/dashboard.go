@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/apisec"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/strava"
+)
+
+// ingressProxy is the address Home Assistant's ingress proxy connects from
+const ingressProxy = "172.30.32.2"
+
+// ingressToken identifies requests made through Home Assistant ingress,
+// whose users Home Assistant has already authenticated
+var ingressToken = &apisec.Token{Name: "ingress", Scope: apisec.ScopeTrigger}
+
+type ingressKey struct{}
+
+// fromIngress reports whether a request came in through ingressHandler
+func fromIngress(r *http.Request) bool {
+	trusted, _ := r.Context().Value(ingressKey{}).(bool)
+	return trusted
+}
+
+// ingressHandler serves the ingress port. Only the ingress proxy may
+// connect; "/" redirects to the dashboard relative to the ingress path.
+func ingressHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if clientIP(r) != ingressProxy {
+			writeError(w, &api.Error{Status: http.StatusForbidden, Code: "forbidden", Message: "only Home Assistant ingress may use this port"})
+			return
+		}
+		if r.URL.Path == "/" {
+			// http.Redirect would make the location absolute, dropping the
+			// ingress path prefix
+			w.Header().Set("Location", "ui/")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ingressKey{}, true)))
+	})
+}
+
+// serveIngress serves the API and dashboard to Home Assistant ingress until
+// ctx is done
+func (s *WebhookServer) serveIngress(ctx context.Context, handler http.Handler) {
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", s.cfg.Server.IngressPort),
+		Handler:      ingressHandler(handler),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 5 * time.Minute,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		s.log.Warn("Ingress server stopped", "error", err)
+	}
+}
+
+// oauthStateTTL is how long a Strava authorization started from the
+// dashboard may take
+const oauthStateTTL = 10 * time.Minute
+
+// stravaCallbackPath is where the webhook server receives Strava's OAuth
+// redirect: the path of strava.redirect_uri
+func (s *WebhookServer) stravaCallbackPath() string {
+	if u, err := url.Parse(s.cfg.Strava.RedirectURI); err == nil && u.Path != "" && u.Path != "/" {
+		return u.Path
+	}
+	return "/callback"
+}
+
+// handleStravaStatus returns the Strava connection state
+func (s *WebhookServer) handleStravaStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	conn := api.StravaConnection{Configured: s.cfg.Strava.ClientID != "" && s.cfg.Strava.ClientSecret != ""}
+	if conn.Configured {
+		conn.Connection = strava.LoadConnection(s.cfg)
+		conn.RedirectURI = s.cfg.Strava.RedirectURI
+	}
+	writeJSON(w, http.StatusOK, conn)
+}
+
+// handleStravaAuthorize starts a Strava authorization and returns the URL
+// to send the user to. Strava redirects back to strava.redirect_uri, which
+// must reach this server.
+func (s *WebhookServer) handleStravaAuthorize(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeTrigger) {
+		return
+	}
+	if s.cfg.Strava.ClientID == "" || s.cfg.Strava.ClientSecret == "" {
+		writeError(w, &api.Error{
+			Status:  http.StatusConflict,
+			Code:    "not_configured",
+			Message: "Strava is not configured (set STRAVA_CLIENT_ID and STRAVA_CLIENT_SECRET)",
+		})
+		return
+	}
+	client, err := strava.NewClient(s.cfg)
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "config_error", Message: err.Error()})
+		return
+	}
+
+	stateBytes := make([]byte, 16)
+	rand.Read(stateBytes)
+	state := hex.EncodeToString(stateBytes)
+
+	s.oauthMu.Lock()
+	now := time.Now()
+	for st, expires := range s.oauthStates {
+		if now.After(expires) {
+			delete(s.oauthStates, st)
+		}
+	}
+	s.oauthStates[state] = now.Add(oauthStateTTL)
+	s.oauthMu.Unlock()
+
+	s.log.Info("Strava authorization started from the dashboard", logging.Icon("🔐"))
+	writeJSON(w, http.StatusOK, api.StravaAuthorization{
+		AuthorizeURL: client.GetAuthURL(state),
+		RedirectURI:  s.cfg.Strava.RedirectURI,
+		ExpiresAt:    now.Add(oauthStateTTL),
+	})
+}
+
+// handleStravaCallback completes an authorization started with
+// handleStravaAuthorize. Strava can't send a token; the single-use state
+// ties the redirect to the authorized request.
+func (s *WebhookServer) handleStravaCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	state := q.Get("state")
+
+	s.oauthMu.Lock()
+	expires, ok := s.oauthStates[state]
+	delete(s.oauthStates, state)
+	s.oauthMu.Unlock()
+	if state == "" || !ok || time.Now().After(expires) {
+		http.Error(w, "Unknown or expired authorization. Start it again from the dashboard.", http.StatusBadRequest)
+		return
+	}
+	if errParam := q.Get("error"); errParam != "" {
+		s.log.Error("Strava authorization denied", "error", errParam)
+		http.Error(w, "Authorization denied: "+errParam, http.StatusBadRequest)
+		return
+	}
+	code := q.Get("code")
+	if code == "" {
+		http.Error(w, "No authorization code received", http.StatusBadRequest)
+		return
+	}
+
+	client, err := strava.NewClient(s.cfg)
+	if err == nil {
+		err = client.ExchangeCode(r.Context(), code)
+	}
+	if err != nil {
+		s.log.Error("Strava authorization failed", "error", err)
+		http.Error(w, "Failed to exchange the authorization code: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	s.log.Info("Strava authorized from the dashboard", logging.Icon("✅"))
+	http.Redirect(w, r, "/ui/", http.StatusFound)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -14,17 +16,40 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/aimharder-sync/internal/aimharder"
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/bundle"
 	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/export"
+	_ "github.com/aimharder-sync/internal/garmin"    // registers the "garmin" destination
+	_ "github.com/aimharder-sync/internal/intervals" // registers the "intervals" destination
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/metrics"
 	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
+	"github.com/aimharder-sync/internal/runlog"
+	"github.com/aimharder-sync/internal/sheet"
+	"github.com/aimharder-sync/internal/source"
 	"github.com/aimharder-sync/internal/strava"
 	"github.com/aimharder-sync/internal/tcx"
+	"github.com/aimharder-sync/internal/tracing"
+	_ "github.com/aimharder-sync/internal/webhook" // registers the "webhook:<name>" destinations
 )
 
+// version is reported by the version command and in traces
+const version = "1.0.0"
+
+// upcomingBookingDays is how far ahead booked classes are looked up for
+// calendar exports
+const upcomingBookingDays = 14
+
 var (
-	cfgFile string
-	cfg     *config.Config
-	verbose bool
-	dryRun  bool
+	cfgFile   string
+	cfg       *config.Config
+	verbose   bool
+	dryRun    bool
+	logFormat string
+	logLevel  string
 )
 
 func main() {
@@ -49,43 +74,103 @@ Before using, you need to:
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			return nil
+			if err := setupLogging(cmd, cfg); err != nil {
+				return err
+			}
+			if err := setupTracing(cfg); err != nil {
+				return err
+			}
+			return applyRemoteFlags(cmd, cfg)
 		},
 	}
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.aimharder-sync/config.yaml)")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output (same as --log-level debug)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "log format: auto, pretty, text or json (default: log.format)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "log level: debug, info, warn or error (default: log.level)")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would be done without actually doing it")
+	rootCmd.PersistentFlags().StringVar(&remoteURL, "remote", "", "run sync, status and runs on a webhook server, e.g. http://ha:8080 (default: remote.url)")
+	rootCmd.PersistentFlags().StringVar(&remoteToken, "remote-token", "", "API token for --remote (default: remote.token)")
 
 	rootCmd.AddCommand(
 		newSyncCmd(),
 		newAuthCmd(),
 		newFetchCmd(),
 		newExportCmd(),
+		newImportCmd(),
+		newValidateCmd(),
+		newSheetCmd(),
 		newStatusCmd(),
+		newRunsCmd(),
 		newWebhookCmd(),
+		newMQTTCmd(),
+		newNotifyCmd(),
+		newDaemonCmd(),
 		newVersionCmd(),
 	)
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	shutdownTracing()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+// setupLogging configures the default logger from the log config, overridden
+// by --log-format, --log-level and --verbose
+func setupLogging(cmd *cobra.Command, c *config.Config) error {
+	flags := cmd.Flags()
+	if flags.Changed("log-format") {
+		c.Log.Format = logFormat
+	}
+	if flags.Changed("log-level") {
+		c.Log.Level = logLevel
+	} else if verbose {
+		c.Log.Level = "debug"
+	}
+	return logging.Setup(c.Log.Format, c.Log.Level)
+}
+
+// emoji returns icon and a space when stdout is a terminal. Reports written
+// to files or pipes stay plain text.
+func emoji(icon string) string {
+	if !logging.IsTerminal(os.Stdout) {
+		return ""
+	}
+	return icon + " "
+}
+
+// mark is ✅ or ❌ in a terminal, and ok or failed in plain output
+func mark(ok bool) string {
+	switch {
+	case logging.IsTerminal(os.Stdout) && ok:
+		return "✅"
+	case logging.IsTerminal(os.Stdout):
+		return "❌"
+	case ok:
+		return "ok"
+	default:
+		return "failed"
+	}
+}
+
 func newSyncCmd() *cobra.Command {
 	var (
 		days      int
 		startDate string
 		endDate   string
 		force     bool
+		to        []string
+		from      string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Sync workouts from Aimharder to Strava",
-		Long: `Fetch workouts from Aimharder and upload them to Strava.
-By default, syncs the last 30 days of workouts.
+		Long: `Fetch workouts from Aimharder and upload them to Strava or other destinations.
+By default, syncs the last 30 days of workouts to the destinations in
+sync.destinations (default: strava).
 
 Examples:
   # Sync last 30 days to Strava
@@ -98,16 +183,31 @@ Examples:
   aimharder-sync sync --days 7
 
   # Force re-sync already synced workouts
-  aimharder-sync sync --force`,
+  aimharder-sync sync --force
+
+  # Choose destinations (comma-separated)
+  aimharder-sync sync --to strava,intervals
+
+  # Upload history exported from SugarWOD, BTWB or Wodify
+  aimharder-sync sync --from sugarwod:workouts.csv --start 2019-01-01
+
+  # Run the sync on a running daemon and follow its progress
+  aimharder-sync --remote http://homeassistant.local:8080 --remote-token $TOKEN sync --days 7`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSync(days, startDate, endDate, force)
+			if rc := remoteClient(cfg); rc != nil {
+				return runRemoteSync(rc, days, startDate, endDate, force, to, from)
+			}
+			return runSync(days, startDate, endDate, force, to, from)
 		},
 	}
+	supportsRemote(cmd)
 
 	cmd.Flags().IntVar(&days, "days", 30, "number of days to sync (from today)")
 	cmd.Flags().StringVar(&startDate, "start", "", "start date (YYYY-MM-DD)")
 	cmd.Flags().StringVar(&endDate, "end", "", "end date (YYYY-MM-DD)")
 	cmd.Flags().BoolVar(&force, "force", false, "force re-sync of already synced workouts")
+	cmd.Flags().StringSliceVar(&to, "to", nil, "destinations to upload to, comma-separated (default: sync.destinations)")
+	cmd.Flags().StringVar(&from, "from", source.Aimharder, fromUsage)
 
 	return cmd
 }
@@ -135,6 +235,8 @@ func newFetchCmd() *cobra.Command {
 		startDate string
 		endDate   string
 		output    string
+		template  string
+		from      string
 	)
 
 	cmd := &cobra.Command{
@@ -148,9 +250,18 @@ Examples:
   aimharder-sync fetch --days 7
 
   # Fetch and save to JSON file
-  aimharder-sync fetch --days 30 --output workouts.json`,
+  aimharder-sync fetch --days 30 --output workouts.json
+
+  # Print workouts as Markdown
+  aimharder-sync fetch --days 7 --template markdown
+
+  # Preview a Wodify export before syncing it
+  aimharder-sync fetch --from wodify:results.csv --start 2020-01-01
+
+Templates (plain, markdown, html, strava, notes) can be overridden by
+placing <name>.tmpl files in the templates directory (storage.templates_dir).`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runFetch(days, startDate, endDate, output)
+			return runFetch(days, startDate, endDate, output, template, from)
 		},
 	}
 
@@ -158,6 +269,8 @@ Examples:
 	cmd.Flags().StringVar(&startDate, "start", "", "start date (YYYY-MM-DD)")
 	cmd.Flags().StringVar(&endDate, "end", "", "end date (YYYY-MM-DD)")
 	cmd.Flags().StringVarP(&output, "output", "o", "", "output file (JSON)")
+	cmd.Flags().StringVarP(&template, "template", "t", render.Plain, "template used to display workouts")
+	cmd.Flags().StringVar(&from, "from", source.Aimharder, fromUsage)
 
 	return cmd
 }
@@ -168,22 +281,46 @@ func newExportCmd() *cobra.Command {
 		startDate string
 		endDate   string
 		outputDir string
+		format    string
+		bundleOut string
+		from      string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "export",
-		Short: "Export workouts as TCX files",
-		Long: `Export workouts from Aimharder as TCX files that can be
-manually uploaded to any fitness platform.
+		Short: "Export workouts as TCX, CSV, JSONL, GPX, calendar or health app files",
+		Long: `Export workouts from Aimharder as files that can be
+manually uploaded to any fitness platform or loaded into spreadsheets.
+
+Formats:
+  tcx            One TCX file per workout (default)
+  csv            exercises.csv (one row per exercise) and workouts.csv (one row per workout)
+  jsonl          workouts.jsonl with one workout JSON object per line
+  gpx            One GPX file per workout with time and heart rate
+  ics            calendar.ics with workouts and upcoming booked classes
+  healthkit      Apple Health XML (export.xml layout) for the whole date range
+  healthconnect  Android Health Connect records as JSON for the whole date range
 
 Examples:
   # Export last 30 days to TCX files
   aimharder-sync export --days 30
 
   # Export to specific directory
-  aimharder-sync export --days 30 --output ~/tcx-files`,
+  aimharder-sync export --days 30 --output ~/tcx-files
+
+  # Export a spreadsheet of exercises
+  aimharder-sync export --days 90 --format csv --output ~/training-log
+
+  # Back up everything into a single archive
+  aimharder-sync export --start 2020-01-01 --bundle backup.zip
+
+  # Convert a BTWB export to TCX files
+  aimharder-sync export --from btwb:btwb.csv --start 2015-01-01`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runExport(days, startDate, endDate, outputDir)
+			if bundleOut != "" {
+				return runExportBundle(days, startDate, endDate, bundleOut, from)
+			}
+			return runExport(days, startDate, endDate, outputDir, format, from)
 		},
 	}
 
@@ -191,7 +328,93 @@ Examples:
 	cmd.Flags().StringVar(&startDate, "start", "", "start date (YYYY-MM-DD)")
 	cmd.Flags().StringVar(&endDate, "end", "", "end date (YYYY-MM-DD)")
 	cmd.Flags().StringVarP(&outputDir, "output", "o", "", "output directory")
+	cmd.Flags().StringVarP(&format, "format", "f", "tcx", "export format ("+strings.Join(export.Formats(), "|")+")")
+	cmd.Flags().StringVar(&bundleOut, "bundle", "", "write a single .zip archive with activity files, workouts, history and an index")
+	cmd.Flags().StringVar(&from, "from", source.Aimharder, fromUsage)
+
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	var bundlePath string
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Restore workouts, activity files and sync history from a bundle",
+		Long: `Restore a bundle created with 'export --bundle'. Activity files are
+written to the TCX directory, workouts are merged into the local workout
+cache and sync history entries are merged into the history file.
+
+Examples:
+  # Restore a backup on a new device
+  aimharder-sync import --bundle backup.zip`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImportBundle(bundlePath)
+		},
+	}
+
+	cmd.Flags().StringVar(&bundlePath, "bundle", "", "bundle archive to import")
+	cmd.MarkFlagRequired("bundle")
+
+	return cmd
+}
+
+func newValidateCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "validate [files...]",
+		Short: "Validate TCX files against the TCX v2 schema rules",
+		Long: `Parse TCX files and check them against the TrainingCenterDatabase v2
+schema rules (required elements, timestamp ordering, heart rate ranges).
+With no arguments, every .tcx file in the TCX directory is checked.
+
+Examples:
+  # Validate all generated TCX files
+  aimharder-sync validate
+
+  # Validate specific files
+  aimharder-sync validate ~/tcx-files/2024-01-15_1900_fran.tcx
+
+  # Re-import previously exported files as workout JSON
+  aimharder-sync validate ~/tcx-files/*.tcx --output workouts.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(args, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the re-imported workouts to a JSON file")
+
+	return cmd
+}
+
+func newSheetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sheet",
+		Short: "Manage the spreadsheet training log",
+	}
+
+	var output string
+	merge := &cobra.Command{
+		Use:   "merge [workbooks...]",
+		Short: "Merge athletes' training logs into one workbook",
+		Long: `Merge XLSX or ODS training logs written by the "sheet" destination into
+one workbook with one sheet per athlete. Rows are keyed by workout ID, so
+merging the same log twice (or a log with corrected scores) updates rows
+instead of duplicating them.
+
+Examples:
+  # Build a shared box log from each athlete's file
+  aimharder-sync sheet merge --output box.xlsx alice.xlsx bob.ods`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSheetMerge(output, args)
+		},
+	}
+	merge.Flags().StringVarP(&output, "output", "o", "", "workbook to merge into (.xlsx or .ods), created if missing")
+	merge.MarkFlagRequired("output")
 
+	cmd.AddCommand(merge)
 	return cmd
 }
 
@@ -200,9 +423,13 @@ func newStatusCmd() *cobra.Command {
 		Use:   "status",
 		Short: "Show sync status and configuration",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if rc := remoteClient(cfg); rc != nil {
+				return runRemoteStatus(rc)
+			}
 			return runStatus()
 		},
 	}
+	supportsRemote(cmd)
 
 	return cmd
 }
@@ -212,7 +439,7 @@ func newVersionCmd() *cobra.Command {
 		Use:   "version",
 		Short: "Print version information",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("aimharder-sync v1.0.0")
+			fmt.Println("aimharder-sync v" + version)
 		},
 	}
 }
@@ -230,9 +457,39 @@ func newWebhookCmd() *cobra.Command {
 This allows you to trigger syncs from phone widgets, shortcuts, or other automation tools.
 
 Endpoints:
-  POST /sync         - Trigger a sync (optional: ?days=N)
+  POST /sync         - Queue a sync and return its job ID (optional: ?days=N,
+                       ?start=&end=, ?platforms=a,b, ?force=true, ?dry_run=true,
+                       ?wait=true to wait for the result; or the same as JSON)
+  GET  /jobs         - Recent sync jobs
+  GET  /jobs/{id}    - Sync job state and per-workout progress
+  GET  /jobs/{id}/events - Sync job progress as Server-Sent Events
+  GET  /runs         - Past syncs, newest first (optional: ?limit=N, ?trigger=,
+                       ?failed=true, ?workout=ID, ?since=YYYY-MM-DD)
+  GET  /runs/{id}    - A past sync with the decision for every workout
+  GET  /workouts     - Fetched workouts and their sync state per platform
+  GET  /workouts/{id} - One workout, rendered with the html template
+  POST /workouts/{id}/resync - Upload one workout again
+  DELETE /workouts/{id}/sync - Forget a workout was synced (?delete_activity=true)
   GET  /status       - Get last sync result
+  GET  /calendar.ics - Workouts and booked classes as an iCalendar feed (optional: ?days=N)
+  GET  /ui/          - Dashboard: workouts, sync jobs and the Strava connection
+  GET  /auth/strava  - Strava connection state (POST starts a re-authorization)
   GET  /health       - Health check
+  GET  /openapi.json - OpenAPI description of these endpoints
+
+When mqtt.broker (MQTT_BROKER) is set, the server also connects to it and
+runs a sync when the Home Assistant "Sync now" button is pressed.
+
+Authentication:
+  Requests send a token in the X-Auth-Token or "Authorization: Bearer" header.
+  server.tokens adds named tokens with the read or trigger scope, and tokens
+  marked signed only accept HMAC-signed requests (X-Auth-Key, X-Auth-Timestamp,
+  X-Auth-Signature). The ?token= query parameter is only accepted with
+  server.query_token. /health, /metrics and /openapi.json are always open.
+
+  Requests are rate limited per client (server.rate_limit), and the server
+  speaks HTTPS when server.tls_cert and server.tls_key are set; the
+  certificate is reloaded when the files change.
 
 Examples:
   # Start webhook server on default port 8080
@@ -242,7 +499,10 @@ Examples:
   aimharder-sync webhook --port 9090 --token mysecrettoken
 
   # Trigger sync from phone (with curl)
-  curl -X POST http://your-server:8080/sync -H "X-Auth-Token: mysecrettoken"`,
+  curl -X POST http://your-server:8080/sync -H "X-Auth-Token: mysecrettoken"
+
+  # Trigger it from another machine and follow its progress
+  aimharder-sync --remote http://your-server:8080 --remote-token mysecrettoken sync`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return RunWebhookServer(cfg, port, authToken)
 		},
@@ -256,7 +516,7 @@ Examples:
 
 // Command implementations
 
-func runSync(days int, startDate, endDate string, force bool) error {
+func runSync(days int, startDate, endDate string, force bool, to []string, from string) (err error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -265,18 +525,22 @@ func runSync(days int, startDate, endDate string, force bool) error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\n⚠️  Cancelling... (press Ctrl+C again to force)")
+		slog.Warn("Cancelling (press Ctrl+C again to force)")
 		cancel()
 		<-sigCh
-		fmt.Println("\n❌ Forced exit")
+		slog.Error("Forced exit")
 		os.Exit(1)
 	}()
 
-	if err := cfg.Validate(); err != nil {
+	if err := cfg.EnsureDirectories(); err != nil {
 		return err
 	}
 
-	if err := cfg.EnsureDirectories(); err != nil {
+	if len(to) == 0 {
+		to = cfg.Sync.Destinations
+	}
+	destinations, err := destination.ParseList(to)
+	if err != nil {
 		return err
 	}
 
@@ -285,284 +549,224 @@ func runSync(days int, startDate, endDate string, force bool) error {
 		return err
 	}
 
-	fmt.Printf("🔄 Syncing workouts from %s to %s\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	slog.Info("Syncing workouts", logging.Icon("🔄"), "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
+
+	ctx, span := startSyncSpan(ctx, runlog.TriggerCLI, start, end, destinations, dryRun)
+
+	// Report the outcome to Home Assistant and notification channels,
+	// failures included
+	run := runlog.New(runlog.TriggerCLI, runlog.Params{
+		Start:        start.Format("2006-01-02"),
+		End:          end.Format("2006-01-02"),
+		Destinations: destinations,
+		Source:       from,
+		Force:        force,
+		DryRun:       dryRun,
+	})
+	run.TraceID = span.TraceID()
+	result := &SyncResult{SyncResult: api.SyncResult{Success: true, StartedAt: time.Now()}, run: run}
+	defer func() {
+		if err != nil {
+			result.Success = false
+			result.Message = err.Error()
+			result.failures = append(result.failures, syncFailure("sync", err))
+		}
+		endSyncSpan(span, result)
+		result.CompletedAt = time.Now()
+		result.Duration = result.CompletedAt.Sub(result.StartedAt).Round(time.Millisecond).String()
+		recordRun(cfg, result, slog.Default())
+		if dryRun {
+			return
+		}
+		reportSync(cfg, result, slog.Default())
+	}()
 
-	ahClient, err := aimharder.NewClient(cfg)
+	fetchCtx, fetchSpan := tracing.Start(ctx, "fetch", tracing.String("source", from))
+	src, err := openSource(fetchCtx, from)
 	if err != nil {
-		return fmt.Errorf("failed to create Aimharder client: %w", err)
-	}
-
-	fmt.Println("🔐 Logging into Aimharder...")
-	if err := ahClient.Login(); err != nil {
-		return fmt.Errorf("failed to login to Aimharder: %w", err)
+		fetchSpan.RecordError(err)
+		fetchSpan.End()
+		return err
 	}
-	fmt.Println("✅ Logged into Aimharder")
 
-	fmt.Println("📥 Fetching workouts from Aimharder...")
-	workouts, err := ahClient.GetWorkoutHistory(ctx, start, end)
+	slog.Info("Fetching workouts", logging.Icon("📥"), "source", src.Name())
+	workouts, err := src.Workouts(fetchCtx, start, end)
+	fetchSpan.SetAttributes(tracing.Int("workouts", len(workouts)))
+	fetchSpan.RecordError(err)
+	fetchSpan.End()
 	if err != nil {
 		if ctx.Err() != nil {
 			return fmt.Errorf("cancelled")
 		}
 		return fmt.Errorf("failed to fetch workouts: %w", err)
 	}
+	result.authenticated = append(result.authenticated, src.Name())
+	run.Fetched = len(workouts)
+	metrics.WorkoutsFetched.Add(float64(len(workouts)), src.Name())
+
+	if err := updateWorkoutCache(cfg.Storage.CacheFile, workouts); err != nil {
+		slog.Warn("Failed to update workout cache", "error", err)
+	}
 
 	if len(workouts) == 0 {
-		fmt.Println("ℹ️  No workouts found in the specified date range")
+		slog.Info("No workouts found in the specified date range")
+		result.Message = "No workouts found in date range"
 		return nil
 	}
 
-	fmt.Printf("📋 Found %d workouts\n", len(workouts))
+	slog.Info("Found workouts", logging.Icon("📋"), "count", len(workouts))
 
 	toSync := workouts
-	history := make(map[string][]models.SyncStatus)
+	history := loadSyncHistory(cfg.Storage.HistoryFile)
+	synced := syncedIDs(history, destinations)
 
 	// Generate TCX files
-	fmt.Println("📝 Generating TCX files...")
-	tcxGen := tcx.NewGenerator(cfg.Storage.TCXDir, cfg.Sync.DefaultDuration)
-	tcxFiles, err := tcxGen.GenerateAll(toSync)
+	slog.Info("Generating TCX files", logging.Icon("📝"))
+	renderer, err := newRenderer(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to generate TCX files: %w", err)
+		return err
+	}
+
+	tcxGen := tcx.NewGenerator(cfg.Storage.TCXDir, cfg.Sync.DefaultDuration)
+	tcxGen.SetRenderer(renderer)
+	_, genSpan := tracing.Start(ctx, "generate", tracing.Int("workouts", len(toSync)))
+	toSync, tcxFiles, failures := tcxGen.GenerateAll(toSync)
+	for _, f := range failures {
+		run.Add(f.Workout, "", destination.OutcomeFailed, runlog.ReasonGenerateFailed, "", f.Err)
+		slog.Warn("Failed to generate TCX", "workout", f.Workout.ID, "error", f.Err)
+		result.Errors++
 	}
+	genSpan.SetAttributes(tracing.Int("files", len(tcxFiles)))
+	genSpan.End()
 
 	if dryRun {
-		fmt.Println("\n" + strings.Repeat("━", 70))
-		fmt.Println("📋 DRY RUN - Strava Activities that would be created:")
-		fmt.Println(strings.Repeat("━", 70))
+		result.Previews = buildPreviews(cfg, destinations, toSync, tcxFiles, history, force)
+		result.recordPreviews(toSync)
+		result.Message = fmt.Sprintf("Dry run: previewed %d workouts, nothing uploaded", len(toSync))
+		return previewSync(destinations, toSync, tcxFiles, history, force, renderer)
+	}
 
-		var stravaClient *strava.Client
-		if err := cfg.ValidateStrava(); err == nil {
-			stravaClient, _ = strava.NewClient(cfg)
+	for _, name := range destinations {
+		slog.Info("Syncing destination", logging.Icon("🎯"), "destination", name)
+		started := time.Now()
+		summary, err := syncDestination(ctx, cfg, name, toSync, tcxFiles, history, destination.SyncOptions{
+			Force:    force,
+			Delay:    500 * time.Millisecond,
+			Progress: run.Synced(name),
+		})
+		if err != nil {
+			slog.Error("Destination failed", "destination", name, "error", err)
 		}
-
-		for i, w := range toSync {
-			tcxFile := ""
-			if i < len(tcxFiles) {
-				tcxFile = tcxFiles[i]
-			}
-
-			fmt.Printf("\n┌─ Activity %d of %d ─────────────────────────────────────────────────\n", i+1, len(toSync))
-			fmt.Printf("│\n")
-			fmt.Printf("│ 🏃 STRAVA ACTIVITY PREVIEW\n")
-			fmt.Printf("│ %s\n", strings.Repeat("─", 50))
-
-			activityName := w.Name
-			if activityName == "" {
-				activityName = fmt.Sprintf("CrossFit WOD - %s", w.Date.Format("2006-01-02"))
-			}
-
-			activityType := "Crossfit"
-			if stravaClient != nil {
-				preview := stravaClient.PreviewActivity(&w, tcxFile)
-				activityType = preview.Type
-			}
-
-			fmt.Printf("│\n")
-			fmt.Printf("│ 📛 name:           %s\n", activityName)
-			fmt.Printf("│ 🏃 type:           %s\n", activityType)
-			fmt.Printf("│ 📅 start_date:     %s\n", w.Date.Format("2006-01-02T15:04:05Z"))
-			fmt.Printf("│ 🆔 external_id:    %s\n", w.ID)
-			fmt.Printf("│ 📄 data_type:      tcx\n")
-			if tcxFile != "" {
-				fmt.Printf("│ 📁 tcx_file:       %s\n", tcxFile)
-			}
-
-			elapsed := ""
-			if w.Duration > 0 {
-				elapsed = formatDurationForDisplay(w.Duration)
-			} else if w.Result != nil && w.Result.Time != nil {
-				elapsed = formatDurationForDisplay(*w.Result.Time)
-			}
-			if elapsed != "" {
-				fmt.Printf("│ ⏱️  elapsed_time:   %s\n", elapsed)
-			}
-
-			fmt.Printf("│\n")
-			fmt.Printf("│ 📝 description:\n")
-			fmt.Printf("│ %s\n", strings.Repeat("─", 50))
-			if w.Description != "" {
-				for _, line := range strings.Split(w.Description, "\n") {
-					if line != "" {
-						fmt.Printf("│    %s\n", line)
-					}
-				}
-			} else {
-				fmt.Printf("│    (no description)\n")
-			}
-
-			fmt.Printf("│\n")
-			fmt.Printf("│ 📊 WORKOUT DETAILS\n")
-			fmt.Printf("│ %s\n", strings.Repeat("─", 50))
-			fmt.Printf("│ 🏠 Box:            %s\n", w.BoxName)
-			fmt.Printf("│ 🏋️  Workout Type:   %s\n", w.Type)
-
-			if len(w.Sections) > 0 {
-				fmt.Printf("│\n│ 📋 Sections:\n")
-				for _, s := range w.Sections {
-					sectionLine := fmt.Sprintf("│    • %s", s.Name)
-					if s.TimeCap > 0 {
-						sectionLine += fmt.Sprintf(" (%d min)", s.TimeCap)
-					}
-					if s.RoundsCompleted > 0 && s.RepsAchieved > 0 {
-						sectionLine += fmt.Sprintf(" → %dR + %d reps", s.RoundsCompleted, s.RepsAchieved)
-					} else if s.RoundsCompleted > 0 {
-						sectionLine += fmt.Sprintf(" → %d rounds", s.RoundsCompleted)
-					}
-					if s.RX {
-						sectionLine += " ✅RX"
-					}
-					fmt.Println(sectionLine)
-				}
-			}
-
-			if w.Result != nil {
-				fmt.Printf("│\n│ 🎯 Result:\n")
-				if w.Result.Time != nil {
-					fmt.Printf("│    ⏱️  Time: %s\n", formatDurationForDisplay(*w.Result.Time))
-				}
-				if w.Result.Rounds > 0 {
-					if w.Result.Reps > 0 {
-						fmt.Printf("│    🔄 Rounds: %d + %d reps\n", w.Result.Rounds, w.Result.Reps)
-					} else {
-						fmt.Printf("│    🔄 Rounds: %d\n", w.Result.Rounds)
-					}
-				}
-				if w.Result.Weight > 0 {
-					fmt.Printf("│    🏋️  Weight: %.1f kg\n", w.Result.Weight)
-				}
-				if w.Result.RxPlus {
-					fmt.Printf("│    ⭐ Rx+\n")
-				} else if w.Result.Scaled {
-					fmt.Printf("│    📉 Scaled\n")
-				} else {
-					fmt.Printf("│    ✅ Rx\n")
-				}
-			}
-
-			fmt.Printf("└%s\n", strings.Repeat("─", 69))
+		result.recordDestination(name, summary, err, history, toSync, started)
+		if summary != nil {
+			slog.Info("Destination done", logging.Icon("📊"), "destination", name,
+				"uploaded", summary.Uploaded, "updated", summary.Updated, "skipped", summary.Skipped, "errors", summary.Errors)
+		}
+		if ctx.Err() != nil {
+			break
 		}
-
-		fmt.Printf("\n📊 Summary: %d activities would be uploaded to Strava\n", len(toSync))
-		fmt.Println("📁 TCX files generated in:", cfg.Storage.TCXDir)
-		fmt.Println("\n💡 Run without --dry-run to actually sync these workouts.")
-		return nil
-	}
-
-	if err := syncToStrava(ctx, cfg, toSync, tcxFiles, history); err != nil {
-		fmt.Printf("⚠️  Strava sync error: %v\n", err)
 	}
 
 	if err := saveSyncHistory(cfg.Storage.HistoryFile, history); err != nil {
-		fmt.Printf("⚠️  Failed to save sync history: %v\n", err)
+		slog.Warn("Failed to save sync history", "error", err)
 	}
 
-	fmt.Println("\n✅ Sync complete!")
+	result.newWorkouts = newlySynced(toSync, history, destinations, synced)
+	result.Message = fmt.Sprintf("Uploaded %d, updated %d, skipped %d, errors %d", result.Uploaded, result.Updated, result.Skipped, result.Errors)
+	result.Success = result.Errors == 0
+
+	slog.Info("Sync complete", logging.Icon("✅"), "message", result.Message)
 	return nil
 }
 
-func syncToStrava(ctx context.Context, cfg *config.Config, workouts []models.Workout, tcxFiles []string, history map[string][]models.SyncStatus) error {
-	if err := cfg.ValidateStrava(); err != nil {
-		return err
-	}
-
-	stravaClient, err := strava.NewClient(cfg)
+// syncDestination creates the named destination and uploads workouts to it
+func syncDestination(ctx context.Context, c *config.Config, name string, workouts []models.Workout, files []string, history map[string][]models.SyncStatus, opts destination.SyncOptions) (*destination.Summary, error) {
+	dest, err := destination.New(name, c)
 	if err != nil {
-		return fmt.Errorf("failed to create Strava client: %w", err)
+		return nil, err
 	}
+	return destination.Sync(ctx, dest, workouts, files, history, opts)
+}
 
-	if err := stravaClient.EnsureValidToken(ctx); err != nil {
-		return fmt.Errorf("Strava authentication failed: %w (run 'aimharder-sync auth' or set STRAVA_REFRESH_TOKEN)", err)
+// previewActivity describes the activity a destination would create for a
+// workout, falling back to a generic preview when dest is nil
+func previewActivity(dest destination.Destination, w *models.Workout, file string) (*destination.Preview, string) {
+	preview := &destination.Preview{
+		Name:        w.Name,
+		Type:        string(w.Type),
+		StartDate:   w.Date.Format("2006-01-02T15:04:05Z"),
+		Description: w.Description,
+		ExternalID:  w.ID,
+		DataType:    "tcx",
+		File:        file,
 	}
-
-	// Find the date range of workouts we're syncing
-	var minDate, maxDate time.Time
-	for _, w := range workouts {
-		if minDate.IsZero() || w.Date.Before(minDate) {
-			minDate = w.Date
-		}
-		if maxDate.IsZero() || w.Date.After(maxDate) {
-			maxDate = w.Date
-		}
+	if preview.Name == "" {
+		preview.Name = fmt.Sprintf("CrossFit WOD - %s", w.Date.Format("2006-01-02"))
 	}
-
-	// Fetch existing Strava activities in this date range (with some buffer)
-	fmt.Println("🔍 Checking for existing activities in Strava...")
-	startRange := minDate.AddDate(0, 0, -1) // 1 day before
-	endRange := maxDate.AddDate(0, 0, 1)    // 1 day after
-	existingActivities, err := stravaClient.GetActivitiesInRange(ctx, startRange, endRange)
-	if err != nil {
-		fmt.Printf("⚠️  Warning: Could not fetch existing activities: %v\n", err)
-		fmt.Println("   Proceeding anyway (Strava will reject duplicates)...")
-		existingActivities = nil
-	} else {
-		fmt.Printf("   Found %d existing activities in date range\n", len(existingActivities))
+	if dest != nil {
+		preview = dest.Preview(w, file)
 	}
 
-	fmt.Println("📤 Uploading to Strava...")
+	elapsed := ""
+	if w.Duration > 0 {
+		elapsed = render.FormatElapsed(w.Duration)
+	} else if w.Result != nil && w.Result.Time != nil {
+		elapsed = render.FormatElapsed(*w.Result.Time)
+	}
+	return preview, elapsed
+}
 
-	uploadedCount := 0
-	skippedCount := 0
+// previewSync prints the activities a sync would create on each destination
+func previewSync(destinations []string, workouts []models.Workout, files []string, history map[string][]models.SyncStatus, force bool, renderer *render.Renderer) error {
+	for _, name := range destinations {
+		fmt.Println("\n" + strings.Repeat("━", 70))
+		fmt.Printf("%sDRY RUN - %s activities that would be created:\n", emoji("📋"), name)
+		fmt.Println(strings.Repeat("━", 70))
 
-	for i, workout := range workouts {
-		if i >= len(tcxFiles) {
-			break
+		dest, err := destination.New(name, cfg)
+		if err != nil {
+			fmt.Printf("%s%s is not configured: %v\n", emoji("⚠️ "), name, err)
 		}
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+		count := 0
+		for i := range workouts {
+			w := &workouts[i]
+			file := ""
+			if i < len(files) {
+				file = files[i]
+			}
 
-		// Check if activity already exists in Strava
-		if existingActivities != nil {
-			if existing := stravaClient.ActivityExistsForWorkout(existingActivities, &workout); existing != nil {
-				fmt.Printf("  ⏭️  Skipping: %s - %s (already exists as activity %d)\n",
-					workout.Date.Format("2006-01-02"), workout.Name, existing.ID)
-				recordSync(history, workout.ID, fmt.Sprintf("%d", existing.ID), true, "already_exists")
-				skippedCount++
+			if !force && destination.LastSuccess(history, w.ID, name) != nil {
+				fmt.Printf("\n%s%s - %s (already synced to %s)\n", emoji("⏭️ "), w.Date.Format("2006-01-02"), w.Name, name)
 				continue
 			}
-		}
-
-		tcxFile := tcxFiles[i]
-		fmt.Printf("  📤 Uploading: %s - %s...", workout.Date.Format("2006-01-02"), workout.Name)
-
-		uploadResp, err := stravaClient.UploadActivity(ctx, tcxFile, &workout)
-		if err != nil {
-			fmt.Printf(" ❌ Error: %v\n", err)
-			recordSync(history, workout.ID, "", false, err.Error())
-			continue
-		}
-
-		status, err := stravaClient.WaitForUpload(ctx, uploadResp.ID, 2*time.Minute)
-		if err != nil {
-			fmt.Printf(" ❌ Error: %v\n", err)
-			recordSync(history, workout.ID, "", false, err.Error())
-			continue
-		}
 
-		if status.Error != "" {
-			if status.Error == "duplicate" || strings.Contains(status.Error, "duplicate") {
-				fmt.Printf(" ⏭️  Already exists\n")
-				recordSync(history, workout.ID, "", true, "duplicate")
-				skippedCount++
-			} else {
-				fmt.Printf(" ❌ Error: %s\n", status.Error)
-				recordSync(history, workout.ID, "", false, status.Error)
+			preview, elapsed := previewActivity(dest, w, file)
+			out, err := renderer.Render(render.Preview, render.PreviewData{
+				Index:       i + 1,
+				Total:       len(workouts),
+				Workout:     w,
+				Platform:    name,
+				Name:        preview.Name,
+				Type:        preview.Type,
+				StartDate:   preview.StartDate,
+				ExternalID:  preview.ExternalID,
+				DataType:    preview.DataType,
+				File:        preview.File,
+				ElapsedTime: elapsed,
+			})
+			if err != nil {
+				return err
 			}
-			continue
+			fmt.Printf("\n%s\n", out)
+			count++
 		}
 
-		fmt.Printf(" ✅ Activity ID: %d\n", status.ActivityID)
-		recordSync(history, workout.ID, fmt.Sprintf("%d", status.ActivityID), true, "")
-		uploadedCount++
-
-		time.Sleep(500 * time.Millisecond)
+		fmt.Printf("\n%sSummary: %d activities would be uploaded to %s\n", emoji("📊"), count, name)
 	}
 
-	fmt.Printf("\n📊 Summary: %d uploaded, %d skipped (already existed)\n", uploadedCount, skippedCount)
-
+	fmt.Println(emoji("📁")+"TCX files generated in:", cfg.Storage.TCXDir)
+	fmt.Println("\n" + emoji("💡") + "Run without --dry-run to actually sync these workouts.")
 	return nil
 }
 
@@ -582,7 +786,53 @@ func runAuth() error {
 	return stravaClient.StartOAuthFlow(ctx)
 }
 
-func runFetch(days int, startDate, endDate, output string) error {
+// newRenderer loads the built-in templates and any overrides from the
+// configured templates directory
+func newRenderer(c *config.Config) (*render.Renderer, error) {
+	renderer, err := render.New(c.Storage.TemplatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+	return renderer, nil
+}
+
+// fromUsage describes the --from flag shared by sync, fetch and export
+var fromUsage = "where to read workouts from: aimharder, or <format>:<file> to import an export (formats: " + strings.Join(source.Formats(), ", ") + ")"
+
+// openSource returns the workout source selected with --from, logging into
+// Aimharder when that's the source
+func openSource(ctx context.Context, from string) (source.WorkoutSource, error) {
+	if from == "" || strings.EqualFold(from, source.Aimharder) {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+
+		ahClient, err := aimharder.NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Aimharder client: %w", err)
+		}
+
+		slog.Info("Logging into Aimharder", logging.Icon("🔐"))
+		if err := ahClient.Login(ctx); err != nil {
+			return nil, fmt.Errorf("failed to login to Aimharder: %w", err)
+		}
+		return ahClient, nil
+	}
+
+	renderer, err := newRenderer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := source.Open(from, source.Options{Renderer: renderer})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Importing export", logging.Icon("📂"), "source", src.Name())
+	return src, nil
+}
+
+func runFetch(days int, startDate, endDate, output, templateName, from string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -590,34 +840,33 @@ func runFetch(days int, startDate, endDate, output string) error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\n⚠️  Cancelling...")
+		slog.Warn("Cancelling (press Ctrl+C again to force)")
 		cancel()
 		<-sigCh
 		os.Exit(1)
 	}()
 
-	if err := cfg.Validate(); err != nil {
+	start, end, err := parseDateRange(days, startDate, endDate)
+	if err != nil {
 		return err
 	}
 
-	start, end, err := parseDateRange(days, startDate, endDate)
+	renderer, err := newRenderer(cfg)
 	if err != nil {
 		return err
 	}
+	if !renderer.Has(templateName) {
+		return fmt.Errorf("unknown template %q (available: %s)", templateName, strings.Join(renderer.Names(), ", "))
+	}
 
-	fmt.Printf("📥 Fetching workouts from %s to %s\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	slog.Info("Fetching workouts", logging.Icon("📥"), "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
 
-	ahClient, err := aimharder.NewClient(cfg)
+	src, err := openSource(ctx, from)
 	if err != nil {
-		return fmt.Errorf("failed to create Aimharder client: %w", err)
-	}
-
-	fmt.Println("🔐 Logging into Aimharder...")
-	if err := ahClient.Login(); err != nil {
-		return fmt.Errorf("failed to login: %w", err)
+		return err
 	}
 
-	workouts, err := ahClient.GetWorkoutHistory(ctx, start, end)
+	workouts, err := src.Workouts(ctx, start, end)
 	if err != nil {
 		if ctx.Err() != nil {
 			return fmt.Errorf("cancelled")
@@ -625,7 +874,7 @@ func runFetch(days int, startDate, endDate, output string) error {
 		return fmt.Errorf("failed to fetch workouts: %w", err)
 	}
 
-	fmt.Printf("📋 Found %d workouts\n\n", len(workouts))
+	slog.Info("Found workouts", logging.Icon("📋"), "count", len(workouts))
 
 	if output != "" {
 		data, err := json.MarshalIndent(workouts, "", "  ")
@@ -635,187 +884,24 @@ func runFetch(days int, startDate, endDate, output string) error {
 		if err := os.WriteFile(output, data, 0644); err != nil {
 			return fmt.Errorf("failed to write output file: %w", err)
 		}
-		fmt.Printf("💾 Saved to %s\n", output)
+		slog.Info("Saved workouts", logging.Icon("💾"), "file", output)
 	} else {
-		for _, w := range workouts {
-			fmt.Println(strings.Repeat("━", 70))
-			if w.Date.Hour() > 0 || w.Date.Minute() > 0 {
-				fmt.Printf("📅 %s @ %s - %s\n", w.Date.Format("2006-01-02 (Monday)"), w.Date.Format("15:04"), w.Name)
-			} else {
-				fmt.Printf("📅 %s - %s\n", w.Date.Format("2006-01-02 (Monday)"), w.Name)
-			}
-			fmt.Printf("   🏠 %s | 🏋️ %s\n", w.BoxName, w.Type)
-
-			if len(w.Sections) > 0 {
-				fmt.Println("\n   📋 Workout Structure:")
-				for i, s := range w.Sections {
-					line := fmt.Sprintf("      [%d] %s", i+1, s.Name)
-					if s.TimeCap > 0 {
-						line += fmt.Sprintf(" (%d min cap)", s.TimeCap)
-					}
-					fmt.Println(line)
-
-					resultLine := "          → "
-					hasResult := false
-
-					if s.RoundsCompleted > 0 && s.RepsAchieved > 0 {
-						resultLine += fmt.Sprintf("%dR + %d reps", s.RoundsCompleted, s.RepsAchieved)
-						hasResult = true
-					} else if s.RoundsCompleted > 0 {
-						if s.Type == "EMOM" || strings.Contains(strings.ToUpper(s.Name), "EMOM") {
-							resultLine += fmt.Sprintf("%d/%d sets", s.RoundsCompleted, s.RoundsCompleted)
-						} else {
-							resultLine += fmt.Sprintf("%d rounds", s.RoundsCompleted)
-						}
-						hasResult = true
-					} else if s.RepsAchieved > 0 {
-						resultLine += fmt.Sprintf("%d reps", s.RepsAchieved)
-						hasResult = true
-					}
-
-					if s.Time != "" && s.Time != "0" {
-						if hasResult {
-							resultLine += " in "
-						}
-						resultLine += s.Time + " min"
-						hasResult = true
-					}
-					if s.RX {
-						resultLine += " ✅RX"
-						hasResult = true
-					}
-					if s.Rank > 0 {
-						resultLine += fmt.Sprintf(" (rank #%d)", s.Rank)
-						hasResult = true
-					}
-					if hasResult {
-						fmt.Println(resultLine)
-					}
-
-					if s.Notes != "" {
-						notes := strings.ReplaceAll(s.Notes, "&quot;", "\"")
-						notes = strings.ReplaceAll(notes, "&#39;", "'")
-						notes = strings.ReplaceAll(notes, "\u2019", "'")
-						fmt.Printf("          📝 %s\n", notes)
-					}
-
-					for _, ex := range w.Exercises {
-						if ex.SectionIndex == i {
-							exLine := "            • " + ex.Name
-							if ex.RepsPerRound > 0 {
-								exLine += fmt.Sprintf(" (%d/round)", ex.RepsPerRound)
-							} else if ex.Reps > 0 {
-								exLine += fmt.Sprintf(" (%d reps)", ex.Reps)
-							}
-							if ex.Weight > 0 {
-								unit := ex.WeightUnit
-								if unit == "" {
-									unit = "kg"
-								}
-								exLine += fmt.Sprintf(" @ %.0f%s", ex.Weight, unit)
-							}
-							if ex.Distance > 0 {
-								unit := ex.DistanceUnit
-								if unit == "" {
-									unit = "m"
-								}
-								exLine += fmt.Sprintf(" %.0f%s", ex.Distance, unit)
-							}
-							if ex.Calories > 0 {
-								exLine += fmt.Sprintf(" %dcal", ex.Calories)
-							}
-							if ex.PR {
-								exLine += " 🏆PR!"
-							}
-							fmt.Println(exLine)
-						}
-					}
-				}
-			}
-
-			unassigned := false
-			for _, ex := range w.Exercises {
-				if len(w.Sections) <= 1 || ex.SectionIndex >= len(w.Sections) {
-					unassigned = true
-					break
-				}
-			}
-			if len(w.Sections) == 0 || (len(w.Sections) == 1 && len(w.Exercises) > 0) || unassigned {
-				if len(w.Sections) > 0 && !unassigned {
-					// Already shown under section
-				} else {
-					fmt.Println("\n   💪 Exercises:")
-					for _, ex := range w.Exercises {
-						line := "      • " + ex.Name
-						if ex.RepsPerRound > 0 {
-							line += fmt.Sprintf(" (%d/round)", ex.RepsPerRound)
-						} else if ex.Reps > 0 {
-							line += fmt.Sprintf(" (%d reps)", ex.Reps)
-						}
-						if ex.Weight > 0 {
-							unit := ex.WeightUnit
-							if unit == "" {
-								unit = "kg"
-							}
-							line += fmt.Sprintf(" @ %.0f%s", ex.Weight, unit)
-						}
-						if ex.Distance > 0 {
-							unit := ex.DistanceUnit
-							if unit == "" {
-								unit = "m"
-							}
-							line += fmt.Sprintf(" %.0f%s", ex.Distance, unit)
-						}
-						if ex.Calories > 0 {
-							line += fmt.Sprintf(" %dcal", ex.Calories)
-						}
-						if ex.PR {
-							line += " 🏆PR!"
-						}
-						fmt.Println(line)
-					}
-				}
-			}
-
-			if w.Result != nil {
-				fmt.Println("\n   🎯 Result:")
-				if w.Result.Time != nil {
-					fmt.Printf("      ⏱️ Time: %s\n", formatDurationForDisplay(*w.Result.Time))
-				}
-				if w.Result.Rounds > 0 {
-					if w.Result.Reps > 0 {
-						fmt.Printf("      🔄 Rounds: %d + %d reps\n", w.Result.Rounds, w.Result.Reps)
-					} else {
-						fmt.Printf("      🔄 Rounds: %d\n", w.Result.Rounds)
-					}
-				}
-				if w.Result.Weight > 0 {
-					fmt.Printf("      🏋️ Weight: %.1fkg\n", w.Result.Weight)
-				}
-				if w.Result.Score != "" && w.Result.Time == nil && w.Result.Rounds == 0 {
-					fmt.Printf("      📊 Score: %s\n", w.Result.Score)
-				}
-				if w.Result.RxPlus {
-					fmt.Println("      ⭐ Rx+")
-				} else if !w.Result.Scaled {
-					fmt.Println("      ✅ Rx")
-				} else {
-					fmt.Println("      📉 Scaled")
-				}
-				if w.Result.Notes != "" {
-					fmt.Printf("      💬 %s\n", w.Result.Notes)
-				}
+		for i := range workouts {
+			out, err := renderer.Workout(templateName, &workouts[i])
+			if err != nil {
+				return err
 			}
-
-			fmt.Println()
+			fmt.Printf("%s\n\n", out)
+		}
+		if templateName == render.Plain {
+			fmt.Println(strings.Repeat("━", 70))
 		}
-		fmt.Println(strings.Repeat("━", 70))
 	}
 
 	return nil
 }
 
-func runExport(days int, startDate, endDate, outputDir string) error {
+func runExport(days int, startDate, endDate, outputDir, format, from string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -823,13 +909,22 @@ func runExport(days int, startDate, endDate, outputDir string) error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\n⚠️  Cancelling...")
+		slog.Warn("Cancelling (press Ctrl+C again to force)")
 		cancel()
 		<-sigCh
 		os.Exit(1)
 	}()
 
-	if err := cfg.Validate(); err != nil {
+	renderer, err := newRenderer(cfg)
+	if err != nil {
+		return err
+	}
+
+	exporter, err := export.New(format, export.Options{
+		DefaultDuration: cfg.Sync.DefaultDuration,
+		Renderer:        renderer,
+	})
+	if err != nil {
 		return err
 	}
 
@@ -842,19 +937,82 @@ func runExport(days int, startDate, endDate, outputDir string) error {
 		outputDir = cfg.Storage.TCXDir
 	}
 
-	fmt.Printf("📥 Fetching workouts from %s to %s\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	slog.Info("Fetching workouts", logging.Icon("📥"), "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
+
+	src, err := openSource(ctx, from)
+	if err != nil {
+		return err
+	}
 
-	ahClient, err := aimharder.NewClient(cfg)
+	workouts, err := src.Workouts(ctx, start, end)
 	if err != nil {
-		return fmt.Errorf("failed to create Aimharder client: %w", err)
+		if ctx.Err() != nil {
+			return fmt.Errorf("cancelled")
+		}
+		return fmt.Errorf("failed to fetch workouts: %w", err)
+	}
+
+	// A calendar is still useful without logged workouts (upcoming bookings)
+	if _, isCalendar := exporter.(*export.ICSExporter); len(workouts) == 0 && !isCalendar {
+		slog.Info("No workouts found in the specified date range")
+		return nil
 	}
 
-	fmt.Println("🔐 Logging into Aimharder...")
-	if err := ahClient.Login(); err != nil {
-		return fmt.Errorf("failed to login: %w", err)
+	slog.Info("Found workouts", logging.Icon("📋"), "count", len(workouts))
+
+	// Upcoming bookings only exist on Aimharder
+	ahClient, fromAimharder := src.(*aimharder.Client)
+	if ics, ok := exporter.(*export.ICSExporter); ok && fromAimharder {
+		slog.Info("Fetching upcoming booked classes", logging.Icon("📅"))
+		bookings, err := ahClient.GetUpcomingBookings(ctx, upcomingBookingDays)
+		if err != nil {
+			slog.Warn("Could not fetch upcoming bookings", "error", err)
+		}
+		ics.Bookings = bookings
 	}
 
-	workouts, err := ahClient.GetWorkoutHistory(ctx, start, end)
+	slog.Info("Generating files", logging.Icon("📝"), "format", exporter.Format())
+
+	files, err := exporter.Export(workouts, outputDir)
+	if err != nil {
+		return fmt.Errorf("failed to export %s files: %w", strings.ToUpper(exporter.Format()), err)
+	}
+
+	for _, f := range files {
+		slog.Info("Exported file", logging.Icon("📄"), "file", filepath.Base(f))
+	}
+	slog.Info("Export complete", logging.Icon("✅"), "files", len(files), "format", exporter.Format(), "dir", outputDir)
+
+	return nil
+}
+
+func runExportBundle(days int, startDate, endDate, dest, from string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		slog.Warn("Cancelling (press Ctrl+C again to force)")
+		cancel()
+		<-sigCh
+		os.Exit(1)
+	}()
+
+	start, end, err := parseDateRange(days, startDate, endDate)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Fetching workouts", logging.Icon("📥"), "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
+
+	src, err := openSource(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	workouts, err := src.Workouts(ctx, start, end)
 	if err != nil {
 		if ctx.Err() != nil {
 			return fmt.Errorf("cancelled")
@@ -863,73 +1021,247 @@ func runExport(days int, startDate, endDate, outputDir string) error {
 	}
 
 	if len(workouts) == 0 {
-		fmt.Println("ℹ️  No workouts found")
+		slog.Info("No workouts found in the specified date range")
 		return nil
 	}
 
-	fmt.Printf("📋 Found %d workouts\n", len(workouts))
-	fmt.Println("📝 Generating TCX files...")
+	slog.Info("Found workouts", logging.Icon("📋"), "count", len(workouts))
+	if err := cfg.EnsureDirectories(); err != nil {
+		return err
+	}
+
+	renderer, err := newRenderer(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Bundle the files already in the TCX directory, which are the ones that
+	// were uploaded; only workouts that were never synced get new ones
+	tcxGen := tcx.NewGenerator(cfg.Storage.TCXDir, cfg.Sync.DefaultDuration)
+	tcxGen.SetRenderer(renderer)
+	files := make(map[string][]string)
+	fileCount := 0
+	for i := range workouts {
+		path := filepath.Join(cfg.Storage.TCXDir, tcxGen.Filename(&workouts[i]))
+		if _, err := os.Stat(path); err != nil {
+			if path, err = tcxGen.Generate(&workouts[i]); err != nil {
+				slog.Warn("Failed to generate TCX", "workout", workouts[i].ID, "error", err)
+				continue
+			}
+		}
+		files[workouts[i].ID] = append(files[workouts[i].ID], path)
+		fileCount++
+	}
+
+	history := loadSyncHistory(cfg.Storage.HistoryFile)
+
+	slog.Info("Writing bundle", logging.Icon("📦"), "file", dest)
+	if err := bundle.Write(dest, workouts, files, history); err != nil {
+		return err
+	}
+
+	slog.Info("Bundle complete", logging.Icon("✅"), "workouts", len(workouts), "files", fileCount, "file", dest)
+	return nil
+}
+
+func runImportBundle(src string) error {
+	if err := cfg.EnsureDirectories(); err != nil {
+		return err
+	}
+
+	slog.Info("Reading bundle", logging.Icon("📦"), "file", src)
+	b, err := bundle.Read(src)
+	if err != nil {
+		return err
+	}
+
+	workouts := b.Workouts()
+	slog.Info("Read bundle", logging.Icon("📋"), "created", b.Manifest.CreatedAt.Format("2006-01-02 15:04"), "workouts", len(workouts))
 
-	tcxGen := tcx.NewGenerator(outputDir, cfg.Sync.DefaultDuration)
-	files, err := tcxGen.GenerateAll(workouts)
+	files, err := b.ExtractFiles(cfg.Storage.TCXDir)
 	if err != nil {
-		return fmt.Errorf("failed to generate TCX files: %w", err)
+		return err
+	}
+	slog.Info("Restored activity files", logging.Icon("📄"), "files", len(files), "dir", cfg.Storage.TCXDir)
+
+	cache := mergeWorkouts(loadWorkoutCache(cfg.Storage.CacheFile), workouts)
+	if err := saveWorkoutCache(cfg.Storage.CacheFile, cache); err != nil {
+		return fmt.Errorf("failed to save workout cache: %w", err)
+	}
+	slog.Info("Updated workout cache", logging.Icon("💾"), "workouts", len(cache))
+
+	history := loadSyncHistory(cfg.Storage.HistoryFile)
+	added := mergeSyncHistory(history, b.History)
+	if err := saveSyncHistory(cfg.Storage.HistoryFile, history); err != nil {
+		return fmt.Errorf("failed to save sync history: %w", err)
+	}
+	slog.Info("Restored sync history", logging.Icon("📈"), "entries", added)
+	slog.Info("Import complete", logging.Icon("✅"))
+	return nil
+}
+
+func runValidate(files []string, output string) error {
+	if len(files) == 0 {
+		matches, err := filepath.Glob(filepath.Join(cfg.Storage.TCXDir, "*.tcx"))
+		if err != nil {
+			return fmt.Errorf("failed to list TCX files: %w", err)
+		}
+		files = matches
+	}
+
+	if len(files) == 0 {
+		slog.Info("No TCX files found")
+		return nil
 	}
 
-	fmt.Printf("\n✅ Exported %d TCX files to %s\n", len(files), outputDir)
+	slog.Info("Validating TCX files", logging.Icon("🔍"), "files", len(files))
+
+	var (
+		workouts []models.Workout
+		invalid  int
+	)
 	for _, f := range files {
-		fmt.Printf("   📄 %s\n", filepath.Base(f))
+		db, err := tcx.ParseFile(f)
+		if err == nil {
+			err = tcx.Validate(db)
+		}
+		if err != nil {
+			invalid++
+			fmt.Printf("  %s %s\n", mark(false), filepath.Base(f))
+			if verrs, ok := err.(tcx.ValidationErrors); ok {
+				for _, verr := range verrs {
+					fmt.Printf("     • %s\n", verr.Error())
+				}
+			} else {
+				fmt.Printf("     • %v\n", err)
+			}
+			continue
+		}
+
+		fmt.Printf("  %s %s\n", mark(true), filepath.Base(f))
+
+		if output != "" {
+			imported, err := db.Workouts()
+			if err != nil {
+				slog.Warn("Could not re-import", "file", filepath.Base(f), "error", err)
+				continue
+			}
+			workouts = append(workouts, imported...)
+		}
 	}
 
+	fmt.Printf("\n%sSummary: %d valid, %d invalid\n", emoji("📊"), len(files)-invalid, invalid)
+
+	if output != "" {
+		data, err := json.MarshalIndent(workouts, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal workouts: %w", err)
+		}
+		if err := os.WriteFile(output, data, 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		slog.Info("Saved re-imported workouts", logging.Icon("💾"), "workouts", len(workouts), "file", output)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d TCX files are invalid", invalid, len(files))
+	}
+	return nil
+}
+
+func runSheetMerge(output string, inputs []string) error {
+	wb, err := sheet.Open(output)
+	if err != nil {
+		return err
+	}
+
+	for _, input := range inputs {
+		if _, err := os.Stat(input); err != nil {
+			return fmt.Errorf("failed to read %s: %w", input, err)
+		}
+		src, err := sheet.Open(input)
+		if err != nil {
+			return err
+		}
+		for _, s := range src.Sheets {
+			slog.Info("Merging sheet", logging.Icon("📄"), "file", filepath.Base(input), "sheet", s.Name, "rows", max(len(s.Rows)-1, 0))
+		}
+		wb.Merge(src)
+	}
+
+	if err := wb.Save(output); err != nil {
+		return err
+	}
+
+	for _, s := range wb.Sheets {
+		slog.Info("Merged sheet", logging.Icon("👤"), "sheet", s.Name, "rows", max(len(s.Rows)-1, 0))
+	}
+	slog.Info("Merge complete", logging.Icon("✅"), "workbooks", len(inputs), "file", output)
 	return nil
 }
 
 func runStatus() error {
-	fmt.Println("📊 AimHarder Sync Status")
+	fmt.Println(emoji("📊") + "AimHarder Sync Status")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	fmt.Println("\n🏋️  Aimharder:")
+	fmt.Println("\n" + emoji("🏋️ ") + "Aimharder:")
 	if cfg.Aimharder.Email != "" {
 		fmt.Printf("   Email: %s\n", cfg.Aimharder.Email)
 	} else {
-		fmt.Println("   ❌ Not configured (set AIMHARDER_EMAIL)")
+		fmt.Println("   " + emoji("❌") + "Not configured (set AIMHARDER_EMAIL)")
 	}
 	fmt.Printf("   Box: %s (ID: %s)\n", cfg.Aimharder.BoxName, cfg.Aimharder.BoxID)
 
-	fmt.Println("\n🏃 Strava:")
+	fmt.Println("\n" + emoji("🏃") + "Strava:")
 	if cfg.Strava.ClientID != "" {
 		fmt.Printf("   Client ID: %s\n", cfg.Strava.ClientID)
 
 		stravaClient, err := strava.NewClient(cfg)
 		if err == nil {
 			if stravaClient.IsAuthenticated() {
-				fmt.Println("   ✅ Authenticated")
+				fmt.Println("   " + emoji("✅") + "Authenticated")
 			} else if cfg.Strava.RefreshToken != "" {
-				fmt.Println("   🔄 Has refresh token (will authenticate on first use)")
+				fmt.Println("   " + emoji("🔄") + "Has refresh token (will authenticate on first use)")
 			} else {
-				fmt.Println("   ❌ Not authenticated (run 'auth' or set STRAVA_REFRESH_TOKEN)")
+				fmt.Println("   " + emoji("❌") + "Not authenticated (run 'auth' or set STRAVA_REFRESH_TOKEN)")
 			}
 		} else {
-			fmt.Println("   ❌ Not authenticated (run 'auth')")
+			fmt.Println("   " + emoji("❌") + "Not authenticated (run 'auth')")
 		}
 	} else {
-		fmt.Println("   ❌ Not configured (set STRAVA_CLIENT_ID, STRAVA_CLIENT_SECRET)")
+		fmt.Println("   " + emoji("❌") + "Not configured (set STRAVA_CLIENT_ID, STRAVA_CLIENT_SECRET)")
 	}
 
-	fmt.Println("\n💾 Storage:")
+	fmt.Println("\n" + emoji("💾") + "Storage:")
 	fmt.Printf("   Data dir: %s\n", cfg.Storage.DataDir)
 	fmt.Printf("   TCX dir: %s\n", cfg.Storage.TCXDir)
 
+	fmt.Printf("\n%sDestinations: %s (available: %s)\n", emoji("🎯"),
+		strings.Join(cfg.Sync.Destinations, ", "), strings.Join(destination.Names(), ", "))
+
+	printSchedule(cfg)
+
 	history := loadSyncHistory(cfg.Storage.HistoryFile)
 	totalSynced := 0
-	for _, statuses := range history {
-		for _, s := range statuses {
-			if s.Success {
-				totalSynced++
+	perPlatform := make(map[string]int)
+	for workoutID := range history {
+		synced := false
+		for _, name := range destination.Names() {
+			if destination.LastSuccess(history, workoutID, name) != nil {
+				perPlatform[name]++
+				synced = true
 			}
 		}
+		if synced {
+			totalSynced++
+		}
+	}
+	fmt.Printf("\n%sSync History: %d workouts synced\n", emoji("📈"), totalSynced)
+	for _, name := range destination.Names() {
+		if perPlatform[name] > 0 {
+			fmt.Printf("   %s: %d\n", name, perPlatform[name])
+		}
 	}
-	fmt.Printf("\n📈 Sync History: %d workouts synced\n", totalSynced)
 
 	return nil
 }
@@ -983,33 +1315,75 @@ func saveSyncHistory(filepath string, history map[string][]models.SyncStatus) er
 	return os.WriteFile(filepath, data, 0644)
 }
 
-func recordSync(history map[string][]models.SyncStatus, workoutID, externalID string, success bool, errorMsg string) {
-	status := models.SyncStatus{
-		WorkoutID:    workoutID,
-		Platform:     "strava",
-		ExternalID:   externalID,
-		SyncedAt:     time.Now(),
-		Success:      success,
-		ErrorMessage: errorMsg,
+// mergeSyncHistory adds the entries of src that dst doesn't have yet and
+// returns how many were added
+func mergeSyncHistory(dst, src map[string][]models.SyncStatus) int {
+	added := 0
+	for workoutID, statuses := range src {
+		for _, status := range statuses {
+			exists := false
+			for _, existing := range dst[workoutID] {
+				if existing.Platform == status.Platform &&
+					existing.ExternalID == status.ExternalID &&
+					existing.SyncedAt.Equal(status.SyncedAt) {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				dst[workoutID] = append(dst[workoutID], status)
+				added++
+			}
+		}
 	}
+	return added
+}
 
-	history[workoutID] = append(history[workoutID], status)
+func loadWorkoutCache(filepath string) []models.Workout {
+	var workouts []models.Workout
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return workouts
+	}
+	json.Unmarshal(data, &workouts)
+	return workouts
 }
 
-func formatDurationForDisplay(d time.Duration) string {
-	if d == 0 {
-		return "Not specified"
+func saveWorkoutCache(filepath string, workouts []models.Workout) error {
+	data, err := json.MarshalIndent(workouts, "", "  ")
+	if err != nil {
+		return err
 	}
 
-	h := int(d.Hours())
-	m := int(d.Minutes()) % 60
-	s := int(d.Seconds()) % 60
+	return os.WriteFile(filepath, data, 0644)
+}
+
+// updateWorkoutCache merges freshly fetched workouts into the local cache
+func updateWorkoutCache(filepath string, workouts []models.Workout) error {
+	return saveWorkoutCache(filepath, mergeWorkouts(loadWorkoutCache(filepath), workouts))
+}
 
-	if h > 0 {
-		return fmt.Sprintf("%dh %dm %ds", h, m, s)
+// mergeWorkouts returns existing with updates applied by workout ID,
+// sorted by date
+func mergeWorkouts(existing, updates []models.Workout) []models.Workout {
+	index := make(map[string]int, len(existing))
+	merged := append([]models.Workout(nil), existing...)
+	for i, w := range merged {
+		index[w.ID] = i
 	}
-	if m > 0 {
-		return fmt.Sprintf("%dm %ds", m, s)
+
+	for _, w := range updates {
+		if i, ok := index[w.ID]; ok {
+			merged[i] = w
+			continue
+		}
+		index[w.ID] = len(merged)
+		merged = append(merged, w)
 	}
-	return fmt.Sprintf("%ds", s)
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Date.Before(merged[j].Date)
+	})
+
+	return merged
 }
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"github.com/aimharder-sync/internal/metrics"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// recordSyncMetrics counts a finished sync and its uploads per platform
+func recordSyncMetrics(result *SyncResult) {
+	for _, d := range result.Destinations {
+		metrics.Uploads.Add(float64(d.Uploaded), d.Platform, "uploaded")
+		metrics.Uploads.Add(float64(d.Updated), d.Platform, "updated")
+		metrics.Uploads.Add(float64(d.Skipped), d.Platform, "skipped")
+		metrics.Uploads.Add(float64(d.Errors), d.Platform, "failed")
+	}
+	metrics.RecordSync(result.Success, result.CompletedAt.Sub(result.StartedAt), result.CompletedAt)
+}
+
+// lastSyncedAt returns when a workout was last synced successfully, so the
+// webhook server knows the age of the last sync after a restart
+func lastSyncedAt(history map[string][]models.SyncStatus) time.Time {
+	var last time.Time
+	for _, statuses := range history {
+		for _, s := range statuses {
+			if s.Success && s.SyncedAt.After(last) {
+				last = s.SyncedAt
+			}
+		}
+	}
+	return last
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/homeassistant"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/mqtt"
+	"github.com/spf13/cobra"
+)
+
+func newMQTTCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mqtt",
+		Short: "Publish to Home Assistant over MQTT",
+		Long: `Publish sync state to Home Assistant using MQTT discovery.
+
+When mqtt.broker (MQTT_BROKER) is set, every sync publishes the last sync
+result, the last workout and this week's workout and PR counts, and fires
+a "new_workout" event for each workout synced for the first time. The
+webhook server also keeps a connection open for the "Sync now" button.
+
+Examples:
+  # Publish discovery configs and current state from the workout cache
+  aimharder-sync mqtt publish
+
+  # Try it out without Mosquitto: run an embedded broker in one terminal...
+  aimharder-sync mqtt broker --port 1883
+
+  # ...and point the sync at it in another
+  MQTT_BROKER=tcp://localhost:1883 aimharder-sync sync`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "publish",
+		Short: "Publish discovery configs and current state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMQTTPublish()
+		},
+	})
+
+	var port int
+	brokerCmd := &cobra.Command{
+		Use:   "broker",
+		Short: "Run an embedded MQTT broker for local testing",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMQTTBroker(port)
+		},
+	}
+	brokerCmd.Flags().IntVar(&port, "port", 1883, "port to listen on")
+	cmd.AddCommand(brokerCmd)
+
+	return cmd
+}
+
+func runMQTTPublish() error {
+	if !cfg.MQTT.Enabled() {
+		return fmt.Errorf("mqtt.broker is required (set MQTT_BROKER)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	slog.Info("Publishing to Home Assistant", logging.Icon("🏠"), "broker", cfg.MQTT.Broker)
+	if err := publishHomeAssistant(ctx, cfg, nil); err != nil {
+		return err
+	}
+	slog.Info("Published discovery configs and state", logging.Icon("✅"))
+	return nil
+}
+
+func runMQTTBroker(port int) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	broker := mqtt.NewBroker()
+	log := logging.Component("mqtt")
+	broker.Logf = func(format string, args ...interface{}) {
+		log.Info(strings.TrimSpace(fmt.Sprintf(format, args...)))
+	}
+
+	log.Info("MQTT broker listening (Ctrl+C to stop)", logging.Icon("📡"), "port", port)
+	return broker.ListenAndServe(ctx, fmt.Sprintf(":%d", port))
+}
+
+// publishMQTT reports a finished sync to Home Assistant if an MQTT broker
+// is configured. Failures are only logged; they never fail the sync.
+func publishMQTT(c *config.Config, result *SyncResult, log *slog.Logger) {
+	if !c.MQTT.Enabled() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := publishHomeAssistant(ctx, c, result); err != nil {
+		log.Warn("Failed to publish to MQTT", "error", err)
+	}
+}
+
+// publishHomeAssistant publishes discovery configs, the sync result (if
+// any), stats from the workout cache and new workout events
+func publishHomeAssistant(ctx context.Context, c *config.Config, result *SyncResult) error {
+	pub, err := homeassistant.Dial(ctx, c.MQTT, fmt.Sprintf("sync-%d", os.Getpid()))
+	if err != nil {
+		return err
+	}
+	defer pub.Close()
+
+	if err := pub.Discovery(ctx); err != nil {
+		return err
+	}
+
+	if result != nil {
+		status := "success"
+		if !result.Success {
+			status = "failed"
+		}
+		err := pub.SyncResult(ctx, homeassistant.SyncReport{
+			Result:      status,
+			Message:     result.Message,
+			Uploaded:    result.Uploaded,
+			Updated:     result.Updated,
+			Skipped:     result.Skipped,
+			Errors:      result.Errors,
+			NewWorkouts: len(result.newWorkouts),
+			CompletedAt: result.CompletedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := pub.Stats(ctx, loadWorkoutCache(c.Storage.CacheFile), time.Now()); err != nil {
+		return err
+	}
+
+	if result != nil {
+		for i := range result.newWorkouts {
+			if err := pub.NewWorkout(ctx, &result.newWorkouts[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syncedIDs returns the workouts history marks as synced to any of the
+// destinations
+func syncedIDs(history map[string][]models.SyncStatus, destinations []string) map[string]bool {
+	synced := make(map[string]bool)
+	for workoutID := range history {
+		for _, name := range destinations {
+			if destination.LastSuccess(history, workoutID, name) != nil {
+				synced[workoutID] = true
+				break
+			}
+		}
+	}
+	return synced
+}
+
+// newlySynced returns the workouts that were uploaded (not just matched to
+// an existing activity) and were not synced anywhere before
+func newlySynced(workouts []models.Workout, history map[string][]models.SyncStatus, destinations []string, before map[string]bool) []models.Workout {
+	var result []models.Workout
+	for _, w := range workouts {
+		if before[w.ID] {
+			continue
+		}
+		for _, name := range destinations {
+			if last := destination.LastSuccess(history, w.ID, name); last != nil && last.ErrorMessage == "" {
+				result = append(result, w)
+				break
+			}
+		}
+	}
+	return result
+}
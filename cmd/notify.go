@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/aimharder"
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+func newNotifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Manage sync notifications",
+		Long: `Send notifications about syncs to ntfy, Telegram, email or webhooks.
+
+Channels are configured under 'notifications' in config.yaml. After every
+sync, notifications are sent for:
+  sync_failed   a sync failed (other than authentication errors)
+  auth_failed   Aimharder or a destination failed to log in several times in a row
+  new_activity  a workout was uploaded for the first time
+  pr            a newly uploaded workout contains PRs
+
+Identical messages are sent once per notifications.dedupe_window. Messages
+are rendered with the "notify" template, which can be overridden like the
+other templates.
+
+Examples:
+  # Send a test message to every channel
+  aimharder-sync notify test
+
+  # Preview how a PR notification looks
+  aimharder-sync notify test --event pr`,
+	}
+
+	var event string
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Send a test notification to every channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNotifyTest(event)
+		},
+	}
+	testCmd.Flags().StringVar(&event, "event", notify.EventNewActivity, "event to simulate: "+strings.Join(notify.Events, ", "))
+	cmd.AddCommand(testCmd)
+
+	return cmd
+}
+
+func runNotifyTest(event string) error {
+	dispatcher, err := newDispatcher(cfg)
+	if err != nil {
+		return err
+	}
+	if !dispatcher.Enabled() {
+		return fmt.Errorf("no notification channels configured (add them under 'notifications.channels' in config.yaml)")
+	}
+
+	valid := false
+	for _, e := range notify.Events {
+		valid = valid || e == event
+	}
+	if !valid {
+		return fmt.Errorf("unknown event %q (available: %s)", event, strings.Join(notify.Events, ", "))
+	}
+
+	// Use the latest cached workout, or a sample one
+	workout := &models.Workout{
+		ID:   "test",
+		Date: time.Now(),
+		Name: "Fran",
+		Type: models.WorkoutTypeForTime,
+		Exercises: []models.Exercise{
+			{Name: "Thruster", Reps: 45, Weight: 43, WeightUnit: "kg", PR: true},
+			{Name: "Pull-up", Reps: 45},
+		},
+	}
+	if cached := loadWorkoutCache(cfg.Storage.CacheFile); len(cached) > 0 {
+		workout = &cached[len(cached)-1]
+		for i := range cached {
+			if cached[i].Date.After(workout.Date) {
+				workout = &cached[i]
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	slog.Info("Sending test notification", logging.Icon("🔔"), "event", event)
+	return dispatcher.Test(ctx, event, workout)
+}
+
+// newDispatcher creates the notification dispatcher for the configured
+// channels, using the configured template overrides
+func newDispatcher(c *config.Config) (*notify.Dispatcher, error) {
+	renderer, err := newRenderer(c)
+	if err != nil {
+		return nil, err
+	}
+	return notify.NewDispatcher(c.Notify, renderer)
+}
+
+// reportSync records metrics for a finished sync, publishes it to Home
+// Assistant and sends notifications about it
+func reportSync(c *config.Config, result *SyncResult, log *slog.Logger) {
+	recordSyncMetrics(result)
+	publishMQTT(c, result, log)
+	notifySync(c, result, log)
+}
+
+// notifySync sends notifications for a finished sync. Failures are only
+// logged; they never fail the sync.
+func notifySync(c *config.Config, result *SyncResult, log *slog.Logger) {
+	if len(c.Notify.Channels) == 0 {
+		return
+	}
+
+	dispatcher, err := newDispatcher(c)
+	if err != nil {
+		log.Warn("Notifications disabled", "error", err)
+		return
+	}
+	dispatcher.Logger = log
+
+	var platforms []string
+	for _, d := range result.Destinations {
+		platforms = append(platforms, d.Platform)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	err = dispatcher.Sync(ctx, notify.Outcome{
+		Success:       result.Success,
+		Message:       result.Message,
+		Failures:      result.failures,
+		Authenticated: result.authenticated,
+		NewWorkouts:   result.newWorkouts,
+		Platforms:     platforms,
+	})
+	if err != nil {
+		log.Warn("Some notifications failed", "error", err)
+	}
+}
+
+// syncFailure describes an error for notifications, flagging failed logins
+func syncFailure(source string, err error) notify.Failure {
+	auth := errors.Is(err, aimharder.ErrLogin) || errors.Is(err, destination.ErrAuth)
+	if errors.Is(err, aimharder.ErrLogin) {
+		source = "aimharder"
+	}
+	return notify.Failure{Source: source, Error: err.Error(), Auth: auth}
+}
+
+// uploadFailures returns the uploads to a destination that failed since
+func uploadFailures(history map[string][]models.SyncStatus, workouts []models.Workout, platform string, since time.Time) []notify.Failure {
+	var failures []notify.Failure
+	for _, w := range workouts {
+		statuses := history[w.ID]
+		for i := len(statuses) - 1; i >= 0; i-- {
+			s := statuses[i]
+			if s.Platform != platform {
+				continue
+			}
+			if !s.Success && !s.SyncedAt.Before(since) {
+				failures = append(failures, notify.Failure{
+					Source: platform,
+					Error:  fmt.Sprintf("%s %s: %s", w.Date.Format("2006-01-02"), w.Name, s.ErrorMessage),
+				})
+			}
+			break
+		}
+	}
+	return failures
+}
+
+// recordDestination adds a destination's outcome to a sync result
+func (r *SyncResult) recordDestination(name string, summary *destination.Summary, err error, history map[string][]models.SyncStatus, workouts []models.Workout, since time.Time) {
+	if summary != nil {
+		r.Uploaded += summary.Uploaded
+		r.Updated += summary.Updated
+		r.Skipped += summary.Skipped
+		r.Errors += summary.Errors
+		r.Destinations = append(r.Destinations, *summary)
+	}
+	if err != nil {
+		r.Errors++
+		r.failures = append(r.failures, syncFailure(name, err))
+		if r.run != nil {
+			r.run.DestinationFailed(name, err)
+		}
+	}
+	if err == nil || !errors.Is(err, destination.ErrAuth) {
+		r.authenticated = append(r.authenticated, name)
+	}
+	r.failures = append(r.failures, uploadFailures(history, workouts, name, since)...)
+}
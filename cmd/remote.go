@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/client"
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/jobs"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/source"
+	"github.com/spf13/cobra"
+)
+
+// remoteAnnotation marks commands that run against the server in
+// remote.url (--remote) instead of locally
+const remoteAnnotation = "remote"
+
+var (
+	remoteURL   string
+	remoteToken string
+)
+
+// supportsRemote marks cmd as able to run against a remote server
+func supportsRemote(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[remoteAnnotation] = "true"
+	return cmd
+}
+
+// applyRemoteFlags puts --remote and --remote-token in cfg. An explicit
+// --remote on a command that can't run remotely is an error; remote.url
+// from the config or environment is ignored by such commands.
+func applyRemoteFlags(cmd *cobra.Command, c *config.Config) error {
+	flags := cmd.Flags()
+	if flags.Changed("remote") {
+		if cmd.Annotations[remoteAnnotation] != "true" {
+			return fmt.Errorf("%s can't run with --remote; only sync, status and runs can", cmd.CommandPath())
+		}
+		c.Remote.URL = remoteURL
+	}
+	if flags.Changed("remote-token") {
+		c.Remote.Token = remoteToken
+	}
+	return nil
+}
+
+// remoteClient returns a client for remote.url, or nil to run locally
+func remoteClient(c *config.Config) *client.Client {
+	if c.Remote.URL == "" {
+		return nil
+	}
+	rc := client.New(c.Remote.URL, c.Remote.Token)
+	rc.Key = c.Remote.Key
+	return rc
+}
+
+// runRemoteSync queues a sync on the server and follows it to the end
+func runRemoteSync(rc *client.Client, days int, startDate, endDate string, force bool, to []string, from string) error {
+	if from != source.Aimharder {
+		return fmt.Errorf("--from is not supported with --remote; the server syncs from Aimharder")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	req := api.SyncRequest{Start: startDate, End: endDate, Platforms: to}
+	if startDate == "" {
+		req.Days = &days
+	}
+	if force {
+		req.Force = &force
+	}
+	if dryRun {
+		req.DryRun = &dryRun
+	}
+
+	accepted, err := rc.Sync(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to queue sync: %w", err)
+	}
+	slog.Info("Queued sync", logging.Icon("📋"), "job", accepted.JobID, "server", rc.BaseURL)
+
+	err = rc.StreamEvents(ctx, accepted.JobID, func(ev jobs.Event) error {
+		printRemoteEvent(ev)
+		return nil
+	})
+	if ctx.Err() != nil {
+		slog.Warn("Stopped following the job; it keeps running on the server", "job", accepted.JobID)
+		return fmt.Errorf("cancelled")
+	}
+	if err != nil {
+		slog.Warn("Lost the event stream", "job", accepted.JobID, "error", err)
+	}
+
+	// The stream ends when the job finishes; poll in case it broke early
+	var job *jobs.Job
+	for {
+		if job, err = rc.Job(ctx, accepted.JobID); err != nil {
+			return fmt.Errorf("failed to get job %s: %w", accepted.JobID, err)
+		}
+		if job.Finished() {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cancelled")
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	var result api.SyncResult
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, &result); err != nil {
+			return fmt.Errorf("failed to decode the sync result: %w", err)
+		}
+	}
+	printRemoteResult(&result)
+	if job.State != jobs.StateSucceeded {
+		return fmt.Errorf("sync failed: %s", job.Message)
+	}
+	return nil
+}
+
+// printRemoteEvent logs a job event like the local sync does
+func printRemoteEvent(ev jobs.Event) {
+	switch ev.Type {
+	case jobs.EventState:
+		if ev.State == jobs.StateRunning {
+			slog.Info("Sync started on the server", logging.Icon("🔄"))
+		}
+	case jobs.EventFetched:
+		if ev.Workout != nil {
+			slog.Info("Fetched workout", logging.Icon("📥"), "date", ev.Workout.Date, "workout", ev.Workout.Name)
+		}
+	case jobs.EventGenerated:
+		if ev.Workout != nil {
+			slog.Debug("Generated TCX", "file", ev.Workout.File)
+		}
+	case jobs.EventSynced:
+		name := ""
+		if ev.Workout != nil {
+			name = ev.Workout.Date + " - " + ev.Workout.Name
+		}
+		if ev.Error != "" {
+			slog.Error("Workout failed", "workout", name, "destination", ev.Platform, "error", ev.Error)
+		} else {
+			slog.Info("Workout synced", logging.Icon("✅"), "workout", name, "destination", ev.Platform, "outcome", ev.Outcome)
+		}
+	case jobs.EventLog:
+		slog.Info(ev.Message)
+	}
+}
+
+// printRemoteResult prints a remote dry run's previews and logs the outcome
+// of a remote sync
+func printRemoteResult(result *api.SyncResult) {
+	for _, p := range result.Previews {
+		if p.Action == "skip" {
+			fmt.Printf("%s%s on %s (already synced)\n", emoji("⏭️ "), p.WorkoutID, p.Platform)
+			continue
+		}
+		if p.Activity != nil {
+			fmt.Printf("%s%s would upload %q (%s, %s)\n", emoji("📋"), p.Platform, p.Activity.Name, p.Activity.Type, p.Activity.StartDate)
+		}
+	}
+	for _, d := range result.Destinations {
+		slog.Info("Destination done", logging.Icon("📊"), "destination", d.Platform,
+			"uploaded", d.Uploaded, "updated", d.Updated, "skipped", d.Skipped, "errors", d.Errors)
+	}
+	// Failures are returned as the error
+	if result.Success {
+		slog.Info("Sync complete", logging.Icon("✅"), "message", result.Message, "duration", result.Duration)
+	}
+}
+
+// runRemoteStatus prints the server's last sync and Strava connection
+func runRemoteStatus(rc *client.Client) error {
+	ctx := context.Background()
+	status, err := rc.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	fmt.Printf("%sAimHarder Sync Status (%s)\n", emoji("📊"), rc.BaseURL)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	fmt.Println("\n" + emoji("🔄") + "Last sync:")
+	if status.Result == nil {
+		fmt.Printf("   %s\n", status.Message)
+	} else {
+		fmt.Printf("   %s %s (%s)\n", mark(status.Result.Success), status.LastSync.Local().Format("2006-01-02 15:04"), status.Result.Duration)
+		fmt.Printf("   %s\n", status.Result.Message)
+	}
+
+	if policy := status.Schedule; policy != nil {
+		fmt.Printf("\n%sPolling: %s, every %s until %s\n", emoji("⏰"), policy.Polling, policy.Interval, policy.Until.Local().Format("2006-01-02 15:04"))
+	}
+
+	fmt.Println("\n" + emoji("🏃") + "Strava:")
+	conn, err := rc.StravaConnection(ctx)
+	switch {
+	case err != nil:
+		fmt.Printf("   %s%v\n", emoji("⚠️ "), err)
+	case !conn.Configured:
+		fmt.Println("   " + emoji("❌") + "Not configured on the server")
+	case conn.Authenticated:
+		fmt.Println("   " + emoji("✅") + "Authenticated")
+	case conn.CanRefresh:
+		fmt.Println("   " + emoji("🔄") + "Has refresh token (will authenticate on first use)")
+	default:
+		fmt.Println("   " + emoji("❌") + "Not authenticated (connect Strava from the dashboard)")
+	}
+
+	if list, err := rc.Jobs(ctx); err == nil && len(list.Jobs) > 0 {
+		fmt.Println("\n" + emoji("📋") + "Recent jobs:")
+		for _, job := range list.Jobs[:min(5, len(list.Jobs))] {
+			fmt.Printf("   %s  %-9s %s  %s\n", job.ID, job.State, job.CreatedAt.Local().Format("2006-01-02 15:04"), strings.TrimSpace(job.Message))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/runlog"
+	"github.com/spf13/cobra"
+)
+
+// defaultRunsLimit is how many runs are listed when no limit is given
+const defaultRunsLimit = 20
+
+func newRunsCmd() *cobra.Command {
+	var (
+		query  api.RunsQuery
+		asJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Show the log of past syncs",
+		Long: `Show past syncs from the CLI, the webhook server, MQTT and the schedule,
+with what they were asked to do and how they went. 'runs show <id>' lists
+the decision for every workout on every platform, e.g. why it was skipped.
+
+Runs are kept in storage.runs_file, up to storage.run_history runs and no
+older than storage.run_max_age.
+
+Examples:
+  # Recent runs
+  aimharder-sync runs
+
+  # Failed scheduled syncs of the last week
+  aimharder-sync runs --trigger schedule --failed --since 2026-10-11
+
+  # Runs that handled a workout, then one of them in detail
+  aimharder-sync runs --workout 12345
+  aimharder-sync runs show 3f2a9c0d1b4e5f60`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rc := remoteClient(cfg); rc != nil {
+				list, err := rc.Runs(context.Background(), query)
+				if err != nil {
+					return fmt.Errorf("failed to list runs: %w", err)
+				}
+				return printRuns(list.Runs, asJSON)
+			}
+			filter, err := runFilter(query)
+			if err != nil {
+				return err
+			}
+			runs, err := runStore(cfg).List(filter)
+			if err != nil {
+				return fmt.Errorf("failed to read run log: %w", err)
+			}
+			return printRuns(runs, asJSON)
+		},
+	}
+
+	cmd.Flags().IntVar(&query.Limit, "limit", defaultRunsLimit, "number of runs to show")
+	cmd.Flags().StringVar(&query.Trigger, "trigger", "", "only runs started by cli, webhook, mqtt or schedule")
+	cmd.Flags().BoolVar(&query.Failed, "failed", false, "only runs that failed")
+	cmd.Flags().StringVar(&query.Workout, "workout", "", "only runs that handled this workout ID")
+	cmd.Flags().StringVar(&query.Since, "since", "", "only runs started on or after this day (YYYY-MM-DD)")
+	cmd.PersistentFlags().BoolVar(&asJSON, "json", false, "print runs as JSON")
+
+	show := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a run with the decision for every workout",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				run *runlog.Run
+				err error
+			)
+			if rc := remoteClient(cfg); rc != nil {
+				run, err = rc.Run(context.Background(), args[0])
+			} else {
+				run, err = runStore(cfg).Get(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get run %s: %w", args[0], err)
+			}
+			return printRun(run, asJSON)
+		},
+	}
+	supportsRemote(show)
+
+	cmd.AddCommand(show)
+	supportsRemote(cmd)
+
+	return cmd
+}
+
+// runStore opens the configured run log
+func runStore(c *config.Config) *runlog.Store {
+	return runlog.NewStore(c.Storage.RunsFile, c.Storage.RunHistory, c.Storage.RunMaxAge)
+}
+
+// runFilter turns runs query parameters into a run log filter
+func runFilter(q api.RunsQuery) (runlog.Filter, error) {
+	filter := runlog.Filter{Trigger: q.Trigger, Failed: q.Failed, Workout: q.Workout, Limit: q.Limit}
+	if q.Limit < 0 {
+		return filter, fmt.Errorf("limit must not be negative")
+	}
+	if q.Since != "" {
+		since, err := time.ParseInLocation("2006-01-02", q.Since, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since date: %w", err)
+		}
+		filter.Since = since
+	}
+	return filter, nil
+}
+
+// recordRun adds a finished sync to the run log. Failures are only logged;
+// they never fail the sync.
+func recordRun(c *config.Config, result *SyncResult, log *slog.Logger) {
+	run := result.run
+	if run == nil {
+		return
+	}
+	run.Uploaded, run.Updated, run.Skipped, run.Errors = result.Uploaded, result.Updated, result.Skipped, result.Errors
+	run.Finish(result.Success, result.Message)
+	if err := runStore(c).Add(run); err != nil {
+		log.Warn("Failed to record the run", "error", err)
+	}
+}
+
+// recordPreviews records what a dry run would do with each workout
+func (r *SyncResult) recordPreviews(workouts []models.Workout) {
+	byID := make(map[string]*models.Workout, len(workouts))
+	for i := range workouts {
+		byID[workouts[i].ID] = &workouts[i]
+	}
+	for _, p := range r.Previews {
+		w := byID[p.WorkoutID]
+		if w == nil {
+			continue
+		}
+		if p.Action == "skip" {
+			r.run.Add(w, p.Platform, destination.OutcomeSkipped, destination.ReasonAlreadySynced, "", nil)
+		} else {
+			r.run.Add(w, p.Platform, runlog.OutcomeWouldUpload, "", "", nil)
+		}
+	}
+}
+
+// progress calls each destination progress callback in turn, skipping nil
+// ones
+func progress(fns ...func(*models.Workout, destination.Decision)) func(*models.Workout, destination.Decision) {
+	return func(w *models.Workout, d destination.Decision) {
+		for _, fn := range fns {
+			if fn != nil {
+				fn(w, d)
+			}
+		}
+	}
+}
+
+// outcomeIcons mark workout decisions in run output
+var outcomeIcons = map[string]string{
+	destination.OutcomeUploaded: "✅",
+	destination.OutcomeUpdated:  "🔄",
+	destination.OutcomeSkipped:  "⏭️ ",
+	destination.OutcomeFailed:   "❌",
+	runlog.OutcomeWouldUpload:   "📋",
+}
+
+// printRuns lists runs, one per line
+func printRuns(runs []runlog.Run, asJSON bool) error {
+	if asJSON {
+		return printJSON(api.RunList{Runs: runs})
+	}
+	if len(runs) == 0 {
+		slog.Info("No runs recorded")
+		return nil
+	}
+	fmt.Println(emoji("📜") + "Sync runs (newest first)")
+	for _, run := range runs {
+		dry := ""
+		if run.Params.DryRun {
+			dry = " (dry run)"
+		}
+		fmt.Printf("   %s %s  %s  %-8s %8s  %s%s\n", mark(run.Success), run.ID, run.StartedAt.Local().Format("2006-01-02 15:04"),
+			run.Trigger, run.Duration, strings.TrimSpace(run.Message), dry)
+	}
+	return nil
+}
+
+// printRun prints a run with its workout decisions
+func printRun(run *runlog.Run, asJSON bool) error {
+	if asJSON {
+		return printJSON(run)
+	}
+	fmt.Printf("%sRun %s\n", emoji("📜"), run.ID)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("   %s %s\n", mark(run.Success), run.Message)
+	fmt.Printf("   Trigger: %s", run.Trigger)
+	if run.JobID != "" {
+		fmt.Printf(" (job %s)", run.JobID)
+	}
+	fmt.Println()
+	if run.TraceID != "" {
+		fmt.Printf("   Trace: %s\n", run.TraceID)
+	}
+	fmt.Printf("   Started: %s (%s)\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.Duration)
+
+	p := run.Params
+	fmt.Printf("   Range: %s to %s\n", p.Start, p.End)
+	fmt.Printf("   Destinations: %s\n", strings.Join(p.Destinations, ", "))
+	if p.Source != "" {
+		fmt.Printf("   Source: %s\n", p.Source)
+	}
+	if len(p.WorkoutIDs) > 0 {
+		fmt.Printf("   Workouts: %s\n", strings.Join(p.WorkoutIDs, ", "))
+	}
+	if p.Force || p.DryRun {
+		fmt.Printf("   Force: %t, dry run: %t\n", p.Force, p.DryRun)
+	}
+	fmt.Printf("   Fetched %d, uploaded %d, updated %d, skipped %d, errors %d\n",
+		run.Fetched, run.Uploaded, run.Updated, run.Skipped, run.Errors)
+
+	for _, platform := range slices.Sorted(maps.Keys(run.DestinationErrors)) {
+		fmt.Printf("   %s%s: %s\n", emoji("❌"), platform, run.DestinationErrors[platform])
+	}
+
+	if len(run.Workouts) == 0 {
+		return nil
+	}
+	fmt.Println("\n" + emoji("🏋️ ") + "Workouts:")
+	for _, e := range run.Workouts {
+		platform := e.Platform
+		if platform == "" {
+			platform = "-"
+		}
+		line := fmt.Sprintf("   %s%s %s → %s: %s", emoji(outcomeIcons[e.Outcome]), e.Date, e.Name, platform, e.Outcome)
+		if e.Reason != "" {
+			line += " (" + e.Reason + ")"
+		}
+		if e.ExternalID != "" {
+			line += " [" + e.ExternalID + "]"
+		}
+		fmt.Println(line)
+		if e.Error != "" {
+			fmt.Printf("      %s\n", e.Error)
+		}
+	}
+	return nil
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/tracing"
+)
+
+// setupTracing starts exporting traces when tracing.enabled is set
+func setupTracing(c *config.Config) error {
+	if !c.Tracing.Enabled {
+		return nil
+	}
+	err := tracing.Setup(tracing.Options{
+		Endpoint:       c.Tracing.Endpoint,
+		ServiceName:    c.Tracing.ServiceName,
+		ServiceVersion: version,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	return nil
+}
+
+// shutdownTracing exports the last spans before exiting
+func shutdownTracing() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracing.Shutdown(ctx); err != nil {
+		slog.Warn("Some spans were not exported", "error", err)
+	}
+}
+
+// startSyncSpan starts the root span of a sync; its fetch, generate and
+// upload stages are child spans
+func startSyncSpan(ctx context.Context, trigger string, start, end time.Time, destinations []string, dryRun bool) (context.Context, *tracing.Span) {
+	return tracing.Start(ctx, "sync",
+		tracing.String("trigger", trigger),
+		tracing.String("start", start.Format("2006-01-02")),
+		tracing.String("end", end.Format("2006-01-02")),
+		tracing.String("destinations", strings.Join(destinations, ",")),
+		tracing.Bool("dry_run", dryRun),
+	)
+}
+
+// endSyncSpan ends the root span of a sync with its outcome
+func endSyncSpan(span *tracing.Span, result *SyncResult) {
+	span.SetAttributes(
+		tracing.Int("uploaded", result.Uploaded),
+		tracing.Int("updated", result.Updated),
+		tracing.Int("skipped", result.Skipped),
+		tracing.Int("errors", result.Errors),
+	)
+	if !result.Success {
+		span.SetStatus(tracing.StatusError, result.Message)
+	}
+	span.End()
+}
@@ -1,66 +1,164 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/aimharder-sync/internal/aimharder"
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/apisec"
 	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/dashboard"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/export"
+	"github.com/aimharder-sync/internal/homeassistant"
+	"github.com/aimharder-sync/internal/jobs"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/metrics"
 	"github.com/aimharder-sync/internal/models"
-	"github.com/aimharder-sync/internal/strava"
+	"github.com/aimharder-sync/internal/notify"
+	"github.com/aimharder-sync/internal/runlog"
+	"github.com/aimharder-sync/internal/schedule"
+	"github.com/aimharder-sync/internal/source"
 	"github.com/aimharder-sync/internal/tcx"
+	"github.com/aimharder-sync/internal/tracing"
 )
 
 // WebhookServer handles HTTP triggers for sync
 type WebhookServer struct {
-	cfg        *config.Config
-	port       string
-	authToken  string
-	syncMutex  sync.Mutex
+	cfg       *config.Config
+	port      string
+	auth      *apisec.Authenticator
+	limiter   *apisec.Limiter
+	certs     *apisec.CertReloader // nil serves plain HTTP
+	dryRun    bool
+	jobs      *jobs.Manager
+	historyMu sync.Mutex        // held while a sync or DELETE /workouts/{id}/sync uses history
+	polling   *schedule.Classes // set by the daemon in classes mode
+	log       *slog.Logger
+
+	statusMu   sync.Mutex // guards lastSync and lastResult, set by sync jobs
 	lastSync   time.Time
 	lastResult *SyncResult
+
+	oauthMu     sync.Mutex
+	oauthStates map[string]time.Time // Strava authorizations started from the dashboard
+
+	calendarMutex sync.Mutex
+	calendarCache []byte
+	calendarDays  int
+	calendarAt    time.Time
 }
 
+// calendarCacheTTL is how long a generated calendar feed is served before
+// Aimharder is queried again. Calendar apps poll feeds frequently.
+const calendarCacheTTL = 15 * time.Minute
+
 // SyncResult holds the result of a sync operation
 type SyncResult struct {
-	Success     bool      `json:"success"`
-	Message     string    `json:"message"`
-	Uploaded    int       `json:"uploaded"`
-	Skipped     int       `json:"skipped"`
-	Errors      int       `json:"errors"`
-	StartedAt   time.Time `json:"started_at"`
-	CompletedAt time.Time `json:"completed_at"`
-	Duration    string    `json:"duration"`
+	api.SyncResult
+
+	// newWorkouts were synced for the first time; failures and
+	// authenticated feed notifications
+	newWorkouts   []models.Workout
+	failures      []notify.Failure
+	authenticated []string
+
+	// run is recorded in the run log when the sync finishes
+	run *runlog.Run
 }
 
-// NewWebhookServer creates a new webhook server
-func NewWebhookServer(cfg *config.Config, port, authToken string) *WebhookServer {
-	return &WebhookServer{
-		cfg:       cfg,
-		port:      port,
-		authToken: authToken,
+// NewWebhookServer creates a new webhook server. authToken (--token)
+// overrides server.token.
+func NewWebhookServer(cfg *config.Config, port, authToken string) (*WebhookServer, error) {
+	tokens := []apisec.Token{{Name: "default", Secret: cmp.Or(authToken, cfg.Server.Token), Scope: apisec.ScopeTrigger}}
+	for _, t := range cfg.Server.Tokens {
+		tokens = append(tokens, apisec.Token{Name: t.Name, Secret: t.Token, Scope: t.Scope, Signed: t.Signed})
+	}
+	auth, err := apisec.NewAuthenticator(tokens, cfg.Server.QueryToken, cfg.Server.SignatureWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &WebhookServer{
+		cfg:     cfg,
+		port:    port,
+		auth:    auth,
+		limiter: apisec.NewLimiter(cfg.Server.RateLimit, cfg.Server.RateBurst),
+		jobs:    jobs.NewManager(cfg.Storage.JobsFile, cfg.Server.JobHistory),
+		log:     logging.Component("webhook"),
+
+		oauthStates: make(map[string]time.Time),
+	}
+	if cfg.Server.TLSEnabled() {
+		if s.certs, err = apisec.NewCertReloader(cfg.Server.TLSCert, cfg.Server.TLSKey); err != nil {
+			return nil, err
+		}
 	}
+	return s, nil
 }
 
 // Start starts the webhook server
 func (s *WebhookServer) Start(ctx context.Context) error {
+	if last := lastSyncedAt(loadSyncHistory(s.cfg.Storage.HistoryFile)); !last.IsZero() {
+		metrics.SetLastSuccess(last)
+	}
+
 	mux := http.NewServeMux()
 
 	// Sync endpoint
 	mux.HandleFunc("/sync", s.handleSync)
 	mux.HandleFunc("/api/sync", s.handleSync)
 
+	// Sync jobs
+	mux.HandleFunc("GET /jobs", s.handleJobs)
+	mux.HandleFunc("GET /jobs/{id}", s.handleJob)
+	mux.HandleFunc("GET /jobs/{id}/events", s.handleJobEvents)
+
+	// Run log
+	mux.HandleFunc("GET /runs", s.handleRuns)
+	mux.HandleFunc("GET /runs/{id}", s.handleRun)
+
+	// Fetched workouts and per-workout actions
+	mux.HandleFunc("GET /workouts", s.handleWorkouts)
+	mux.HandleFunc("GET /workouts/{id}", s.handleWorkout)
+	mux.HandleFunc("POST /workouts/{id}/resync", s.handleResync)
+	mux.HandleFunc("DELETE /workouts/{id}/sync", s.handleUnsync)
+
 	// Status endpoint
 	mux.HandleFunc("/status", s.handleStatus)
 	mux.HandleFunc("/api/status", s.handleStatus)
 
+	// Calendar feed
+	mux.HandleFunc("/calendar.ics", s.handleCalendar)
+
+	// Dashboard and Strava authorization
+	mux.Handle("GET /ui/", http.StripPrefix("/ui", dashboard.Handler()))
+	mux.HandleFunc("GET /auth/strava", s.handleStravaStatus)
+	mux.HandleFunc("POST /auth/strava", s.handleStravaAuthorize)
+	mux.HandleFunc("GET "+s.stravaCallbackPath(), s.handleStravaCallback)
+
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", s.handleMetrics)
+
+	// API description
+	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+
 	// Health check
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/", s.handleHealth)
@@ -69,9 +167,15 @@ func (s *WebhookServer) Start(ctx context.Context) error {
 		Addr:         ":" + s.port,
 		Handler:      mux,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 5 * time.Minute, // Long timeout for sync
+		WriteTimeout: 5 * time.Minute, // Long timeout for ?wait=true syncs
 	}
 
+	// Run queued syncs in the background
+	go s.jobs.Run(ctx.Done(), func(job *jobs.Job) (any, bool, string) {
+		result := s.runJob(ctx, job)
+		return result, result.Success, result.Message
+	})
+
 	// Graceful shutdown
 	go func() {
 		<-ctx.Done()
@@ -80,234 +184,584 @@ func (s *WebhookServer) Start(ctx context.Context) error {
 		server.Shutdown(shutdownCtx)
 	}()
 
-	fmt.Printf("🌐 Webhook server listening on port %s\n", s.port)
-	fmt.Printf("   POST /sync        - Trigger a sync\n")
-	fmt.Printf("   GET  /status      - Get last sync status\n")
-	fmt.Printf("   GET  /health      - Health check\n")
-	if s.authToken != "" {
-		fmt.Printf("   🔒 Authentication required (X-Auth-Token header)\n")
+	scheme := "HTTP"
+	if s.certs != nil {
+		server.TLSConfig = s.certs.Config()
+		scheme = "HTTPS"
+	}
+
+	s.log.Info("Webhook server listening", logging.Icon("🌐"), "port", s.port, "scheme", scheme, "api_version", api.Version)
+
+	// The endpoint overview is for people starting the server by hand
+	if logging.IsTerminal(os.Stdout) {
+		printEndpoints()
 	}
 
+	if s.auth.Enabled() {
+		for _, t := range s.auth.Tokens() {
+			s.log.Info("API token", logging.Icon("🔒"), "name", t.Name, "scope", t.Scope, "signed", t.Signed)
+		}
+		if s.cfg.Server.QueryToken {
+			s.log.Warn("Tokens accepted in ?token= (they may end up in logs)")
+		}
+	} else {
+		s.log.Warn("No auth token set: anyone who can reach the server can sync")
+	}
+
+	if s.cfg.Server.IngressPort > 0 {
+		s.log.Info("Home Assistant ingress", logging.Icon("🏠"), "port", s.cfg.Server.IngressPort)
+		go s.serveIngress(ctx, mux)
+	}
+
+	if s.cfg.MQTT.Enabled() {
+		mqttLog := logging.Component("mqtt")
+		go homeassistant.Serve(ctx, s.cfg.MQTT, func() {
+			mqttLog.Info("Sync button pressed", logging.Icon("🔘"))
+			if job, err := s.jobs.Enqueue("mqtt", jobs.Request{Days: 1}); err != nil {
+				mqttLog.Info("Sync not queued", logging.Icon("⏭️ "), "reason", err)
+			} else {
+				mqttLog.Info("Queued sync job", logging.Icon("📋"), "job", job.ID)
+			}
+		})
+	}
+
+	if s.certs != nil {
+		return server.ListenAndServeTLS("", "")
+	}
 	return server.ListenAndServe()
 }
 
-// handleSync handles sync requests
-func (s *WebhookServer) handleSync(w http.ResponseWriter, r *http.Request) {
-	if s.authToken != "" {
-		token := r.Header.Get("X-Auth-Token")
-		if token == "" {
-			token = r.URL.Query().Get("token")
-		}
-		if token != s.authToken {
-			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
-			return
+// printEndpoints lists the endpoints when the server starts
+func printEndpoints() {
+	fmt.Printf("   POST /sync        - Queue a sync (returns a job ID)\n")
+	fmt.Printf("   GET  /jobs/{id}   - Sync job state and progress (/events streams it)\n")
+	fmt.Printf("   GET  /runs        - Past syncs and their per-workout decisions\n")
+	fmt.Printf("   GET  /workouts    - Fetched workouts and their sync state\n")
+	fmt.Printf("   GET  /status      - Get last sync status\n")
+	fmt.Printf("   GET  /calendar.ics - Workouts and booked classes (iCalendar)\n")
+	fmt.Printf("   GET  /ui/         - Dashboard\n")
+	fmt.Printf("   GET  /metrics     - Prometheus metrics\n")
+	fmt.Printf("   GET  /openapi.json - API description (version %s)\n", api.Version)
+	fmt.Printf("   GET  /health      - Health check\n")
+}
+
+// authorize authenticates a request and checks its scope and the client's
+// rate limit, writing the error response when one fails. Clients are
+// identified by token, or by address before they authenticate.
+func (s *WebhookServer) authorize(w http.ResponseWriter, r *http.Request, scope string) bool {
+	client := "ip:" + clientIP(r)
+	var token *apisec.Token
+	var authErr error
+	if fromIngress(r) {
+		token = ingressToken
+		client = "token:" + token.Name
+	} else if s.auth.Enabled() {
+		if token, authErr = s.auth.Authenticate(r); authErr == nil {
+			client = "token:" + token.Name
 		}
 	}
 
-	if !s.syncMutex.TryLock() {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusConflict)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error":   "sync already in progress",
-			"message": "A sync operation is already running. Please wait.",
+	if ok, retryAfter := s.limiter.Allow(client); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeError(w, &api.Error{
+			Status:  http.StatusTooManyRequests,
+			Code:    "rate_limited",
+			Message: fmt.Sprintf("Too many requests. Try again in %s.", retryAfter.Round(time.Second)),
 		})
+		return false
+	}
+	if authErr != nil {
+		s.log.Warn("Rejected request", logging.Icon("🚫"), "method", r.Method, "path", r.URL.Path, "client", clientIP(r), "error", authErr)
+		writeError(w, &api.Error{Status: http.StatusUnauthorized, Code: "unauthorized", Message: authErr.Error()})
+		return false
+	}
+	if token != nil && !token.Allows(scope) {
+		writeError(w, &api.Error{
+			Status:  http.StatusForbidden,
+			Code:    "forbidden",
+			Message: fmt.Sprintf("token %q has the %s scope; this needs %s", token.Name, token.Scope, scope),
+		})
+		return false
+	}
+	return true
+}
+
+// clientIP returns the address a request came from
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// handleSync queues a sync and returns its job ID. With ?wait=true it
+// waits for the sync and returns its result instead. Options come from the
+// query string or a JSON body: days, start, end, dry_run, force, platforms.
+func (s *WebhookServer) handleSync(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeTrigger) {
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, &api.Error{Status: http.StatusMethodNotAllowed, Code: "method_not_allowed", Message: "use POST /sync"})
 		return
 	}
-	defer s.syncMutex.Unlock()
 
-	// Parse days parameter
-	days := 1
-	if d := r.URL.Query().Get("days"); d != "" {
-		fmt.Sscanf(d, "%d", &days)
+	p, apiErr := parseSyncParams(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	req, apiErr := p.request(s.cfg, 1)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	s.enqueueSync(w, r, req, p.wait())
+}
+
+// waitForJob blocks until a job finishes and writes its result, like
+// POST /sync did before syncs were queued
+func (s *WebhookServer) waitForJob(w http.ResponseWriter, r *http.Request, id string) {
+	job, err := s.jobs.Wait(r.Context(), id)
+	if err != nil {
+		if r.Context().Err() == nil {
+			writeError(w, &api.Error{Status: http.StatusInternalServerError, Code: "job_lost", Message: err.Error()})
+		}
+		return
 	}
 
-	// Run sync
+	w.Header().Set("Content-Type", "application/json")
+	if job.State == jobs.StateSucceeded {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write(job.Result)
+}
+
+// runJob runs a queued sync, then records its result and publishes it
+func (s *WebhookServer) runJob(ctx context.Context, job *jobs.Job) *SyncResult {
 	startTime := time.Now()
-	result := s.runSync(r.Context(), days)
+	s.historyMu.Lock()
+	result := s.runSync(ctx, job.Request, job)
+	s.historyMu.Unlock()
 	result.StartedAt = startTime
 	result.CompletedAt = time.Now()
 	result.Duration = result.CompletedAt.Sub(startTime).Round(time.Millisecond).String()
 
+	s.statusMu.Lock()
 	s.lastSync = startTime
 	s.lastResult = result
-
-	// Return result
-	w.Header().Set("Content-Type", "application/json")
+	s.statusMu.Unlock()
+	log := s.log.With("job", job.ID)
 	if result.Success {
-		w.WriteHeader(http.StatusOK)
+		log.Info("Sync finished", logging.Icon("✅"), "message", result.Message, "duration", result.Duration)
 	} else {
-		w.WriteHeader(http.StatusInternalServerError)
+		log.Error("Sync failed", "message", result.Message, "duration", result.Duration)
+	}
+	recordRun(s.cfg, result, log)
+
+	if !s.dryRun && !job.DryRun {
+		reportSync(s.cfg, result, log)
+	}
+	return result
+}
+
+// scheduledSync runs a sync for the scheduler through the job queue,
+// skipping it while another sync is queued or running
+func (s *WebhookServer) scheduledSync(ctx context.Context, days int) error {
+	if s.jobs.Busy() {
+		return schedule.ErrBusy
+	}
+	job, err := s.jobs.Enqueue("schedule", jobs.Request{Days: days})
+	if err != nil {
+		return err
+	}
+	if job, err = s.jobs.Wait(ctx, job.ID); err != nil {
+		return err
+	}
+	if job.State != jobs.StateSucceeded {
+		return errors.New(job.Message)
+	}
+	return nil
+}
+
+// handleJobs lists recent sync jobs, newest first
+func (s *WebhookServer) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	writeJSON(w, http.StatusOK, api.JobList{Jobs: s.jobs.List()})
+}
+
+// handleJob returns a sync job's state and per-workout progress
+func (s *WebhookServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, errJobNotFound)
+		return
+	}
+	job.Events = nil
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleJobEvents streams a sync job's progress as Server-Sent Events,
+// replaying earlier events first (after Last-Event-ID when reconnecting)
+func (s *WebhookServer) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+
+	after, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	past, events, cancel, ok := s.jobs.Subscribe(r.PathValue("id"), after)
+	if !ok {
+		writeError(w, errJobNotFound)
+		return
+	}
+	defer cancel()
+
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(ev jobs.Event) error {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+		return err
+	}
+	for _, ev := range past {
+		if write(ev) != nil {
+			return
+		}
+	}
+	rc.Flush()
+	if events == nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if write(ev) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		rc.Flush()
 	}
-	json.NewEncoder(w).Encode(result)
 }
 
 // handleStatus returns the last sync status
 func (s *WebhookServer) handleStatus(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	if s.lastResult == nil {
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":    "no_sync_yet",
-			"message":   "No sync has been performed yet",
-			"last_sync": nil,
-		})
+	if !s.authorize(w, r, apisec.ScopeRead) {
 		return
 	}
+	status := api.Status{Status: "no_sync_yet", Message: "No sync has been performed yet"}
+	s.statusMu.Lock()
+	if s.lastResult != nil {
+		lastSync, result := s.lastSync, s.lastResult.SyncResult
+		status = api.Status{Status: "ok", LastSync: &lastSync, Result: &result}
+	}
+	s.statusMu.Unlock()
+	if s.polling != nil {
+		state := s.polling.State()
+		policy := state.Policy(time.Now())
+		status.Schedule = &policy
+	}
+	writeJSON(w, http.StatusOK, status)
+}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":    "ok",
-		"last_sync": s.lastSync,
-		"result":    s.lastResult,
-	})
+// handleMetrics serves the Prometheus metrics, which need the read scope
+// like the other data endpoints; scrapers send the token as a bearer token
+func (s *WebhookServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+	metrics.Handler().ServeHTTP(w, r)
 }
 
-// handleHealth returns health status
-func (s *WebhookServer) handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "healthy",
-		"service": "aimharder-sync",
-		"time":    time.Now(),
-	})
+// handleCalendar serves logged workouts and upcoming booked classes as an
+// iCalendar feed that calendar apps can subscribe to (optional: ?days=N)
+func (s *WebhookServer) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, apisec.ScopeRead) {
+		return
+	}
+
+	days := s.cfg.Sync.DefaultDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 1 || n > maxRequestDays {
+			writeError(w, invalidParam("days", fmt.Sprintf("days must be a whole number between 1 and %d", maxRequestDays)))
+			return
+		}
+		days = n
+	}
+
+	data, err := s.calendar(r.Context(), days)
+	if err != nil {
+		writeError(w, &api.Error{Status: http.StatusBadGateway, Code: "calendar_unavailable", Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="aimharder.ics"`)
+	w.Write(data)
 }
 
-// runSync performs the actual sync
-func (s *WebhookServer) runSync(ctx context.Context, days int) *SyncResult {
-	result := &SyncResult{Success: true}
+// calendar returns the calendar feed, regenerating it when the cache is stale
+func (s *WebhookServer) calendar(ctx context.Context, days int) (data []byte, err error) {
+	s.calendarMutex.Lock()
+	defer s.calendarMutex.Unlock()
+
+	if s.calendarCache != nil && s.calendarDays == days && time.Since(s.calendarAt) < calendarCacheTTL {
+		return s.calendarCache, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "calendar", tracing.Int("days", days))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if err := s.cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	ahClient, err := aimharder.NewClient(s.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Aimharder client: %w", err)
+	}
+	if err := ahClient.Login(ctx); err != nil {
+		return nil, fmt.Errorf("failed to login to Aimharder: %w", err)
+	}
 
-	// Calculate date range
 	end := time.Now()
 	start := end.AddDate(0, 0, -days)
 	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
 	end = time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, time.Local)
 
-	fmt.Printf("[webhook] 🔄 Syncing workouts from %s to %s\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	workouts, err := ahClient.GetWorkoutHistory(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %w", err)
+	}
 
-	// Login to Aimharder
-	ahClient, err := aimharder.NewClient(s.cfg)
+	bookings, err := ahClient.GetUpcomingBookings(ctx, upcomingBookingDays)
 	if err != nil {
-		result.Success = false
-		result.Message = fmt.Sprintf("Failed to create Aimharder client: %v", err)
-		return result
+		s.log.Warn("Could not fetch upcoming bookings", "error", err)
 	}
 
-	if err := ahClient.Login(); err != nil {
-		result.Success = false
-		result.Message = fmt.Sprintf("Failed to login to Aimharder: %v", err)
-		return result
+	renderer, err := newRenderer(s.cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fetch workouts
-	workouts, err := ahClient.GetWorkoutHistory(ctx, start, end)
+	var buf bytes.Buffer
+	opts := export.Options{DefaultDuration: s.cfg.Sync.DefaultDuration, Renderer: renderer}
+	if err := export.WriteCalendar(&buf, workouts, bookings, opts); err != nil {
+		return nil, err
+	}
+
+	s.calendarCache = buf.Bytes()
+	s.calendarDays = days
+	s.calendarAt = time.Now()
+
+	return s.calendarCache, nil
+}
+
+// handleHealth returns health status
+func (s *WebhookServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.Health{Status: "healthy", Service: "aimharder-sync", Time: time.Now()})
+}
+
+// runSync performs the actual sync, reporting progress to job
+func (s *WebhookServer) runSync(ctx context.Context, req jobs.Request, job *jobs.Job) *SyncResult {
+	trigger := "webhook"
+	log := s.log
+	if job != nil {
+		trigger = job.Trigger
+		log = log.With("job", job.ID)
+	}
+	run := runlog.New(trigger, runlog.Params{
+		Source:     source.Aimharder,
+		Force:      req.Force,
+		DryRun:     s.dryRun || req.DryRun,
+		WorkoutIDs: req.WorkoutIDs,
+	})
+	if job != nil {
+		run.JobID = job.ID
+	}
+	result := &SyncResult{SyncResult: api.SyncResult{Success: true}, run: run}
+
+	start, end, err := parseDateRange(req.Days, req.Start, req.End)
 	if err != nil {
 		result.Success = false
-		result.Message = fmt.Sprintf("Failed to fetch workouts: %v", err)
+		result.Message = err.Error()
 		return result
 	}
+	run.Params.Start, run.Params.End = start.Format("2006-01-02"), end.Format("2006-01-02")
 
-	if len(workouts) == 0 {
-		result.Message = "No workouts found in date range"
+	destinations, err := s.destinations(req)
+	run.Params.Destinations = destinations
+	if err != nil {
+		result.Success = false
+		result.Message = err.Error()
 		return result
 	}
 
-	toSync := workouts
-	history := make(map[string][]models.SyncStatus)
+	log.Info("Syncing workouts", logging.Icon("🔄"), "start", start.Format("2006-01-02"), "end", end.Format("2006-01-02"))
+	job.Logf("Fetching workouts from %s to %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
 
-	// Generate TCX files
-	tcxGen := tcx.NewGenerator(s.cfg.Storage.TCXDir, s.cfg.Sync.DefaultDuration)
-	tcxFiles, err := tcxGen.GenerateAll(toSync)
+	ctx, span := startSyncSpan(ctx, trigger, start, end, destinations, s.dryRun || req.DryRun)
+	defer endSyncSpan(span, result)
+	run.TraceID = span.TraceID()
+
+	// Login to Aimharder and fetch workouts
+	ahClient, err := aimharder.NewClient(s.cfg)
 	if err != nil {
 		result.Success = false
-		result.Message = fmt.Sprintf("Failed to generate TCX files: %v", err)
+		result.Message = fmt.Sprintf("Failed to create Aimharder client: %v", err)
 		return result
 	}
 
-	// Upload to Strava
-	stravaClient, err := strava.NewClient(s.cfg)
-	if err != nil {
+	fetchCtx, fetchSpan := tracing.Start(ctx, "fetch", tracing.String("source", ahClient.Name()))
+	if err := ahClient.Login(fetchCtx); err != nil {
+		fetchSpan.RecordError(err)
+		fetchSpan.End()
 		result.Success = false
-		result.Message = fmt.Sprintf("Failed to create Strava client: %v", err)
+		result.Message = fmt.Sprintf("Failed to login to Aimharder: %v", err)
+		result.failures = append(result.failures, syncFailure("aimharder", err))
 		return result
 	}
 
-	if err := stravaClient.EnsureValidToken(ctx); err != nil {
+	workouts, err := ahClient.GetWorkoutHistory(fetchCtx, start, end)
+	fetchSpan.SetAttributes(tracing.Int("workouts", len(workouts)))
+	fetchSpan.RecordError(err)
+	fetchSpan.End()
+	if err != nil {
 		result.Success = false
-		result.Message = fmt.Sprintf("Strava authentication failed: %v", err)
+		result.Message = fmt.Sprintf("Failed to fetch workouts: %v", err)
+		result.failures = append(result.failures, syncFailure("aimharder", err))
 		return result
 	}
+	result.authenticated = append(result.authenticated, ahClient.Name())
+	metrics.WorkoutsFetched.Add(float64(len(workouts)), ahClient.Name())
+	job.Fetched(workouts)
+	run.Fetched = len(workouts)
 
-	// Fetch existing activities to avoid duplicates
-	var minDate, maxDate time.Time
-	for _, w := range toSync {
-		if minDate.IsZero() || w.Date.Before(minDate) {
-			minDate = w.Date
-		}
-		if maxDate.IsZero() || w.Date.After(maxDate) {
-			maxDate = w.Date
-		}
+	if err := updateWorkoutCache(s.cfg.Storage.CacheFile, workouts); err != nil {
+		log.Warn("Failed to update workout cache", "error", err)
 	}
 
-	existingActivities, _ := stravaClient.GetActivitiesInRange(ctx, minDate.AddDate(0, 0, -1), maxDate.AddDate(0, 0, 1))
-
-	// Upload each workout
-	for i, workout := range toSync {
-		if i >= len(tcxFiles) {
-			break
-		}
+	if len(workouts) == 0 {
+		result.Message = "No workouts found in date range"
+		return result
+	}
 
-		// Check if already exists on Strava
-		if existingActivities != nil {
-			if existing := stravaClient.ActivityExistsForWorkout(existingActivities, &workout); existing != nil {
-				fmt.Printf("[webhook] ⏭️  Skipping %s (exists as %d)\n", workout.Date.Format("2006-01-02"), existing.ID)
-				result.Skipped++
-				recordSyncInHistory(history, workout.ID, fmt.Sprintf("%d", existing.ID), true, "already_exists")
-				continue
-			}
+	toSync := workouts
+	if len(req.WorkoutIDs) > 0 {
+		toSync = selectWorkouts(workouts, req.WorkoutIDs)
+		if len(toSync) == 0 {
+			result.Success = false
+			result.Message = fmt.Sprintf("Workout %s not found in date range", strings.Join(req.WorkoutIDs, ", "))
+			return result
 		}
+	}
+	history := loadSyncHistory(s.cfg.Storage.HistoryFile)
+	synced := syncedIDs(history, destinations)
 
-		tcxFile := tcxFiles[i]
-		fmt.Printf("[webhook] 📤 Uploading %s - %s\n", workout.Date.Format("2006-01-02"), workout.Name)
-
-		uploadResp, err := stravaClient.UploadActivity(ctx, tcxFile, &workout)
-		if err != nil {
-			fmt.Printf("[webhook] ❌ Upload error: %v\n", err)
-			result.Errors++
-			recordSyncInHistory(history, workout.ID, "", false, err.Error())
-			continue
-		}
+	// Generate TCX files
+	renderer, err := newRenderer(s.cfg)
+	if err != nil {
+		result.Success = false
+		result.Message = err.Error()
+		return result
+	}
 
-		status, err := stravaClient.WaitForUpload(ctx, uploadResp.ID, 2*time.Minute)
+	tcxGen := tcx.NewGenerator(s.cfg.Storage.TCXDir, s.cfg.Sync.DefaultDuration)
+	tcxGen.SetRenderer(renderer)
+	// Workouts without a file are dropped, so toSync[i] stays paired with
+	// tcxFiles[i]
+	var (
+		tcxFiles  []string
+		generated []models.Workout
+	)
+	_, genSpan := tracing.Start(ctx, "generate", tracing.Int("workouts", len(toSync)))
+	for i := range toSync {
+		file, err := tcxGen.Generate(&toSync[i])
+		job.Generated(&toSync[i], file, err)
 		if err != nil {
-			fmt.Printf("[webhook] ❌ Wait error: %v\n", err)
+			run.Add(&toSync[i], "", destination.OutcomeFailed, runlog.ReasonGenerateFailed, "", err)
+			log.Warn("Failed to generate TCX", "workout", toSync[i].ID, "error", err)
 			result.Errors++
-			recordSyncInHistory(history, workout.ID, "", false, err.Error())
 			continue
 		}
-
-		if status.Error != "" {
-			if status.Error == "duplicate" {
-				fmt.Printf("[webhook] ⏭️  Duplicate\n")
-				result.Skipped++
-				recordSyncInHistory(history, workout.ID, "", true, "duplicate")
-			} else {
-				fmt.Printf("[webhook] ❌ Error: %s\n", status.Error)
-				result.Errors++
-				recordSyncInHistory(history, workout.ID, "", false, status.Error)
+		tcxFiles = append(tcxFiles, file)
+		generated = append(generated, toSync[i])
+	}
+	toSync = generated
+	genSpan.SetAttributes(tracing.Int("files", len(tcxFiles)))
+	genSpan.End()
+
+	if s.dryRun || req.DryRun {
+		if s.dryRun {
+			if err := previewSync(destinations, toSync, tcxFiles, history, req.Force, renderer); err != nil {
+				result.Success = false
+				result.Message = err.Error()
+				return result
 			}
-			continue
 		}
+		result.Previews = buildPreviews(s.cfg, destinations, toSync, tcxFiles, history, req.Force)
+		result.recordPreviews(toSync)
+		result.Message = fmt.Sprintf("Dry run: previewed %d workouts, nothing uploaded", len(toSync))
+		return result
+	}
 
-		fmt.Printf("[webhook] ✅ Created activity %d\n", status.ActivityID)
-		result.Uploaded++
-		recordSyncInHistory(history, workout.ID, fmt.Sprintf("%d", status.ActivityID), true, "")
-
-		time.Sleep(500 * time.Millisecond)
+	// Upload to every configured destination
+	for _, name := range destinations {
+		job.Logf("Uploading to %s", name)
+		started := time.Now()
+		summary, err := syncDestination(ctx, s.cfg, name, toSync, tcxFiles, history, destination.SyncOptions{
+			Force:    req.Force,
+			Logger:   log,
+			Delay:    500 * time.Millisecond,
+			Progress: progress(job.Synced(name), run.Synced(name)),
+		})
+		if err != nil {
+			log.Error("Destination failed", "destination", name, "error", err)
+			job.Logf("%s: %v", name, err)
+		}
+		result.recordDestination(name, summary, err, history, toSync, started)
 	}
 
 	// Save history
-	saveSyncHistoryToFile(s.cfg.Storage.HistoryFile, history)
+	if err := saveSyncHistory(s.cfg.Storage.HistoryFile, history); err != nil {
+		log.Warn("Failed to save sync history", "error", err)
+	}
+	result.newWorkouts = newlySynced(toSync, history, destinations, synced)
 
-	result.Message = fmt.Sprintf("Uploaded %d, skipped %d, errors %d", result.Uploaded, result.Skipped, result.Errors)
+	result.Message = fmt.Sprintf("Uploaded %d, updated %d, skipped %d, errors %d", result.Uploaded, result.Updated, result.Skipped, result.Errors)
 	if result.Errors > 0 {
 		result.Success = false
 	}
@@ -315,26 +769,6 @@ func (s *WebhookServer) runSync(ctx context.Context, days int) *SyncResult {
 	return result
 }
 
-func saveSyncHistoryToFile(filepath string, history map[string][]models.SyncStatus) error {
-	data, err := json.MarshalIndent(history, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(filepath, data, 0644)
-}
-
-func recordSyncInHistory(history map[string][]models.SyncStatus, workoutID, externalID string, success bool, errorMsg string) {
-	status := models.SyncStatus{
-		WorkoutID:    workoutID,
-		Platform:     "strava",
-		ExternalID:   externalID,
-		SyncedAt:     time.Now(),
-		Success:      success,
-		ErrorMessage: errorMsg,
-	}
-	history[workoutID] = append(history[workoutID], status)
-}
-
 // RunWebhookServer is the main entry point for the webhook server
 func RunWebhookServer(cfg *config.Config, port, authToken string) error {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -345,10 +779,14 @@ func RunWebhookServer(cfg *config.Config, port, authToken string) error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\n⚠️  Shutting down webhook server...")
+		slog.Warn("Shutting down webhook server")
 		cancel()
 	}()
 
-	server := NewWebhookServer(cfg, port, authToken)
+	server, err := NewWebhookServer(cfg, port, authToken)
+	if err != nil {
+		return err
+	}
+	server.dryRun = dryRun
 	return server.Start(ctx)
 }
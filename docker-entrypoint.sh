@@ -6,6 +6,8 @@ export AIMHARDER_STORAGE_DATA_DIR="${AIMHARDER_STORAGE_DATA_DIR:-/data}"
 export AIMHARDER_STORAGE_TOKENS_FILE="${AIMHARDER_STORAGE_TOKENS_FILE:-/data/tokens.json}"
 export AIMHARDER_STORAGE_HISTORY_FILE="${AIMHARDER_STORAGE_HISTORY_FILE:-/data/sync_history.json}"
 export AIMHARDER_STORAGE_TCX_DIR="${AIMHARDER_STORAGE_TCX_DIR:-/data/tcx}"
+export AIMHARDER_STORAGE_CACHE_FILE="${AIMHARDER_STORAGE_CACHE_FILE:-/data/workouts_cache.json}"
+export AIMHARDER_STORAGE_TEMPLATES_DIR="${AIMHARDER_STORAGE_TEMPLATES_DIR:-/data/templates}"
 
 # Check required environment variables for sync operations
 check_aimharder_config() {
@@ -1,8 +1,10 @@
 package aimharder
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -10,6 +12,10 @@ import (
 	"time"
 
 	"golang.org/x/net/publicsuffix"
+
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/metrics"
+	"github.com/aimharder-sync/internal/tracing"
 )
 
 const (
@@ -19,14 +25,15 @@ const (
 	authCookieKey = "amhrdrauth"
 )
 
-// HTTPClient wraps http.Client with browser-like behavior
+// HTTPClient wraps http.Client with browser-like behavior. Requests and the
+// login steps are logged at debug level.
 type HTTPClient struct {
-	client  *http.Client
-	verbose bool
+	client *http.Client
+	log    *slog.Logger
 }
 
 // NewHTTPClient creates a new HTTP client with cookie jar and browser-like settings
-func NewHTTPClient(verbose bool) (*HTTPClient, error) {
+func NewHTTPClient() (*HTTPClient, error) {
 	jar, err := cookiejar.New(&cookiejar.Options{
 		PublicSuffixList: publicsuffix.List,
 	})
@@ -36,10 +43,11 @@ func NewHTTPClient(verbose bool) (*HTTPClient, error) {
 
 	return &HTTPClient{
 		client: &http.Client{
-			Jar:     jar,
-			Timeout: 30 * time.Second,
+			Jar:       jar,
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport("aimharder", metrics.Transport("aimharder", nil)),
 		},
-		verbose: verbose,
+		log: logging.Component("aimharder"),
 	}, nil
 }
 
@@ -53,32 +61,22 @@ func (h *HTTPClient) GetHTTPClient() *http.Client {
 	return h.client
 }
 
-// SetVerbose sets the verbose flag
-func (h *HTTPClient) SetVerbose(verbose bool) {
-	h.verbose = verbose
-}
-
 // DoRequest performs an HTTP request with browser-like headers
-func (h *HTTPClient) DoRequest(method, urlStr string, body io.Reader, opts RequestOptions) (*http.Response, error) {
-	req, err := http.NewRequest(method, urlStr, body)
+func (h *HTTPClient) DoRequest(ctx context.Context, method, urlStr string, body io.Reader, opts RequestOptions) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	h.applyHeaders(req, opts)
-
-	if h.verbose {
-		h.logRequest(req)
-	}
+	h.logRequest(req)
 
 	resp, err := h.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
-	if h.verbose {
-		h.logResponse(resp)
-	}
+	h.logResponse(resp)
 
 	return resp, nil
 }
@@ -143,19 +141,22 @@ func (h *HTTPClient) applyHeaders(req *http.Request, opts RequestOptions) {
 }
 
 func (h *HTTPClient) logRequest(req *http.Request) {
-	fmt.Printf("  → %s %s\n", req.Method, req.URL.String())
+	h.log.Debug("Request", "method", req.Method, "url", req.URL.String())
 }
 
+// logResponse logs a response's status and redirect, and whether it set the
+// auth cookie (never its value)
 func (h *HTTPClient) logResponse(resp *http.Response) {
-	fmt.Printf("  ← Status: %d\n", resp.StatusCode)
+	args := []any{"status", resp.StatusCode}
 	if location := resp.Header.Get("Location"); location != "" {
-		fmt.Printf("  ← Redirect: %s\n", location)
+		args = append(args, "redirect", location)
 	}
 	for _, c := range resp.Cookies() {
 		if c.Name == authCookieKey {
-			fmt.Printf("  ← Set-Cookie: %s ✓\n", c.Name)
+			args = append(args, "auth_cookie_set", true)
 		}
 	}
+	h.log.Debug("Response", args...)
 }
 
 // AuthResult contains the result of a successful authentication
@@ -169,11 +170,9 @@ type AuthResult struct {
 // 2. Visit login.aimharder.com (simulates clicking login button)
 // 3. POST credentials to login.aimharder.com
 // 4. Follow redirect to /home to verify login success
-func (h *HTTPClient) Login(email, password string) (*AuthResult, error) {
-	if h.verbose {
-		fmt.Println("  [1/4] Visiting aimharder.com...")
-	}
-	resp, err := h.DoRequest("GET", baseURL, nil, RequestOptions{})
+func (h *HTTPClient) Login(ctx context.Context, email, password string) (*AuthResult, error) {
+	h.log.Debug("Visiting aimharder.com", "step", "1/4")
+	resp, err := h.DoRequest(ctx, "GET", baseURL, nil, RequestOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to visit main page: %w", err)
 	}
@@ -186,10 +185,8 @@ func (h *HTTPClient) Login(email, password string) (*AuthResult, error) {
 
 	time.Sleep(300 * time.Millisecond)
 
-	if h.verbose {
-		fmt.Println("  [2/4] Visiting login.aimharder.com...")
-	}
-	resp, err = h.DoRequest("GET", loginURL, nil, RequestOptions{
+	h.log.Debug("Visiting login.aimharder.com", "step", "2/4")
+	resp, err = h.DoRequest(ctx, "GET", loginURL, nil, RequestOptions{
 		Referer: baseURL,
 	})
 	if err != nil {
@@ -204,9 +201,7 @@ func (h *HTTPClient) Login(email, password string) (*AuthResult, error) {
 
 	time.Sleep(200 * time.Millisecond)
 
-	if h.verbose {
-		fmt.Println("  [3/4] Submitting credentials...")
-	}
+	h.log.Debug("Submitting credentials", "step", "3/4")
 	formData := url.Values{
 		"mail":             {email},
 		"pw":               {password},
@@ -215,7 +210,7 @@ func (h *HTTPClient) Login(email, password string) (*AuthResult, error) {
 		"login":            {"Iniciar sesión"},
 	}
 
-	resp, err = h.DoRequest("POST", loginURL, strings.NewReader(formData.Encode()), RequestOptions{
+	resp, err = h.DoRequest(ctx, "POST", loginURL, strings.NewReader(formData.Encode()), RequestOptions{
 		Referer:     loginURL,
 		ContentType: "application/x-www-form-urlencoded",
 		Origin:      loginURL,
@@ -237,19 +232,15 @@ func (h *HTTPClient) Login(email, password string) (*AuthResult, error) {
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
-	if h.verbose {
-		fmt.Println("  [4/4] Verifying authentication...")
-	}
+	h.log.Debug("Verifying authentication", "step", "4/4")
 
 	authCookie := h.findAuthCookie()
 	if authCookie == "" {
 		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
 			location := resp.Header.Get("Location")
 			if location != "" {
-				if h.verbose {
-					fmt.Printf("  → Following redirect to: %s\n", location)
-				}
-				resp, err = h.DoRequest("GET", location, nil, RequestOptions{
+				h.log.Debug("Following redirect", "location", location)
+				resp, err = h.DoRequest(ctx, "GET", location, nil, RequestOptions{
 					Referer: loginURL,
 				})
 				if err != nil {
@@ -264,10 +255,8 @@ func (h *HTTPClient) Login(email, password string) (*AuthResult, error) {
 	}
 
 	if authCookie == "" {
-		if h.verbose {
-			fmt.Println("  → Visiting /home to verify session...")
-		}
-		resp, err = h.DoRequest("GET", baseURL+"/home", nil, RequestOptions{
+		h.log.Debug("Visiting /home to verify session")
+		resp, err = h.DoRequest(ctx, "GET", baseURL+"/home", nil, RequestOptions{
 			Referer: loginURL,
 		})
 		if err != nil {
@@ -283,9 +272,7 @@ func (h *HTTPClient) Login(email, password string) (*AuthResult, error) {
 		return nil, fmt.Errorf("authentication failed: %s cookie not received", authCookieKey)
 	}
 
-	if h.verbose {
-		fmt.Printf("  ✓ Got %s cookie\n", authCookieKey)
-	}
+	h.log.Debug("Got the auth cookie")
 
 	return &AuthResult{
 		AuthCookie: authCookie,
@@ -325,18 +312,3 @@ func (h *HTTPClient) getAllCookies() []*http.Cookie {
 func (h *HTTPClient) HasAuthCookie() bool {
 	return h.findAuthCookie() != ""
 }
-
-// PrintCookies prints all cookies (for debugging)
-func (h *HTTPClient) PrintCookies() {
-	domains := []string{baseURL, loginURL}
-	for _, domain := range domains {
-		u, _ := url.Parse(domain)
-		cookies := h.client.Jar.Cookies(u)
-		if len(cookies) > 0 {
-			fmt.Printf("  Cookies for %s:\n", domain)
-			for _, c := range cookies {
-				fmt.Printf("    - %s = %s\n", c.Name, c.Value[:min(20, len(c.Value))]+"...")
-			}
-		}
-	}
-}
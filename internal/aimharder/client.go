@@ -3,8 +3,10 @@ package aimharder
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -12,7 +14,11 @@ import (
 	"time"
 
 	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/metrics"
 	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
+	"github.com/aimharder-sync/internal/tracing"
 )
 
 // Client handles communication with Aimharder
@@ -23,40 +29,51 @@ type Client struct {
 	loggedIn bool
 	userID   string
 	familyID string
-	verbose  bool
+	renderer *render.Renderer
+	log      *slog.Logger
 }
 
 // NewClient creates a new Aimharder client
 func NewClient(cfg *config.Config) (*Client, error) {
-	httpClient, err := NewHTTPClient(true)
+	httpClient, err := NewHTTPClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
 
+	renderer, err := render.New(cfg.Storage.TemplatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
 	client := &Client{
 		http:     httpClient,
 		config:   cfg,
 		boxURL:   cfg.GetBoxURL(),
 		userID:   cfg.Aimharder.UserID,
 		familyID: cfg.Aimharder.FamilyID,
-		verbose:  true,
+		renderer: renderer,
+		log:      logging.Component("aimharder"),
 	}
 
 	return client, nil
 }
 
+// ErrLogin is wrapped by errors from a failed Aimharder login
+var ErrLogin = errors.New("login failed")
+
 // Login authenticates with Aimharder using the auth module
-func (c *Client) Login() error {
-	c.http.SetVerbose(c.verbose)
+func (c *Client) Login(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "aimharder.login")
+	defer span.End()
 
-	result, err := c.http.Login(c.config.Aimharder.Email, c.config.Aimharder.Password)
+	result, err := c.http.Login(ctx, c.config.Aimharder.Email, c.config.Aimharder.Password)
+	metrics.RecordLogin("aimharder", err)
 	if err != nil {
-		return fmt.Errorf("login failed: %w", err)
+		span.RecordError(err)
+		return fmt.Errorf("%w: %w", ErrLogin, err)
 	}
 
-	if c.verbose {
-		fmt.Printf("  ✓ Authentication successful (cookie: %s...)\n", result.AuthCookie[:min(10, len(result.AuthCookie))])
-	}
+	c.log.Debug("Authentication successful", "cookies", len(result.Cookies))
 
 	// User ID should be set from config
 	if c.userID == "" {
@@ -64,9 +81,7 @@ func (c *Client) Login() error {
 	}
 
 	c.loggedIn = true
-	if c.verbose {
-		fmt.Println("  ✓ Login successful")
-	}
+	c.log.Info("Logged in", logging.Icon("🔑"))
 	return nil
 }
 
@@ -170,9 +185,7 @@ func (c *Client) GetBookings(ctx context.Context, date time.Time) ([]models.Book
 
 	body, _ := io.ReadAll(resp.Body)
 
-	if c.verbose {
-		fmt.Printf("\n  → Activity API (%d): %s\n", resp.StatusCode, string(body)[:min(1000, len(body))])
-	}
+	c.log.Debug("Activity API response", "status", resp.StatusCode, "bytes", len(body), "body", string(body)[:min(1000, len(body))])
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, nil // No results
@@ -313,6 +326,119 @@ func (c *Client) parseResultsAsBookings(body []byte, dateStr string) ([]models.B
 	return bookings, nil
 }
 
+// GetUpcomingBookings fetches the classes the user has booked from today
+// for the given number of days using the box schedule API
+func (c *Client) GetUpcomingBookings(ctx context.Context, days int) ([]models.Booking, error) {
+	if !c.loggedIn {
+		return nil, fmt.Errorf("not logged in")
+	}
+
+	var bookings []models.Booking
+	today := time.Now()
+
+	for i := 0; i < days; i++ {
+		select {
+		case <-ctx.Done():
+			return bookings, ctx.Err()
+		default:
+		}
+
+		day := today.AddDate(0, 0, i)
+		dateStr := day.Format("20060102")
+
+		apiURL := fmt.Sprintf("%s/api/bookings?day=%s&familyId=%s&box=%s&_=%d",
+			c.boxURL, dateStr, c.familyID, c.config.Aimharder.BoxID, time.Now().UnixMilli())
+
+		resp, err := c.doAPIRequest(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return bookings, fmt.Errorf("request failed: %w", err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+
+		bookings = append(bookings, c.parseScheduleBookings(body, dateStr)...)
+
+		// Be nice to the server
+		select {
+		case <-ctx.Done():
+			return bookings, ctx.Err()
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+
+	return bookings, nil
+}
+
+// parseScheduleBookings extracts the classes booked by the user from a
+// schedule API response. Classes the user has booked carry a non-empty
+// bookState.
+func (c *Client) parseScheduleBookings(body []byte, dateStr string) []models.Booking {
+	var response struct {
+		Bookings []map[string]interface{} `json:"bookings"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	var bookings []models.Booking
+	for _, class := range response.Bookings {
+		if !isBooked(class["bookState"]) {
+			continue
+		}
+
+		booking := models.Booking{
+			Date:    dateStr,
+			BoxID:   c.config.Aimharder.BoxID,
+			BoxName: c.config.Aimharder.BoxName,
+		}
+
+		if id, ok := class["id"].(float64); ok {
+			booking.ID = strconv.FormatFloat(id, 'f', 0, 64)
+		} else if id, ok := class["id"].(string); ok {
+			booking.ID = id
+		}
+
+		for _, key := range []string{"className", "name", "nombre"} {
+			if name, ok := class[key].(string); ok && name != "" {
+				booking.ClassName = name
+				break
+			}
+		}
+
+		for _, key := range []string{"time", "hora", "timeid"} {
+			if t, ok := class[key].(string); ok && t != "" {
+				booking.Time = t
+				break
+			}
+		}
+
+		if booking.ID != "" {
+			bookings = append(bookings, booking)
+		}
+	}
+
+	return bookings
+}
+
+// isBooked interprets the bookState field of a schedule entry
+func isBooked(state interface{}) bool {
+	switch v := state.(type) {
+	case float64:
+		return v > 0
+	case bool:
+		return v
+	case string:
+		return v != "" && v != "0"
+	default:
+		return false
+	}
+}
+
 // GetWOD fetches the WOD for a specific date and class
 func (c *Client) GetWOD(ctx context.Context, date time.Time, classID string) (*models.WODInfo, error) {
 	if !c.loggedIn {
@@ -353,13 +479,24 @@ func (c *Client) GetWOD(ctx context.Context, date time.Time, classID string) (*m
 	return nil, nil
 }
 
+// Name returns the source name
+func (c *Client) Name() string {
+	return "aimharder"
+}
+
+// Workouts returns the logged workouts between start and end, so the
+// client can be used as a workout source
+func (c *Client) Workouts(ctx context.Context, start, end time.Time) ([]models.Workout, error) {
+	return c.GetWorkoutHistory(ctx, start, end)
+}
+
 // GetWorkoutHistory fetches historical workout data with progress
 func (c *Client) GetWorkoutHistory(ctx context.Context, startDate, endDate time.Time) ([]models.Workout, error) {
 	if !c.loggedIn {
 		return nil, fmt.Errorf("not logged in")
 	}
 
-	fmt.Printf("  📥 Fetching your logged workouts from %s to %s...\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	c.log.Info("Fetching logged workouts", logging.Icon("📥"), "start", startDate.Format("2006-01-02"), "end", endDate.Format("2006-01-02"))
 
 	// Fetch all activities from the user's profile
 	allActivities, err := c.fetchAllActivities(ctx)
@@ -367,7 +504,7 @@ func (c *Client) GetWorkoutHistory(ctx context.Context, startDate, endDate time.
 		return nil, fmt.Errorf("failed to fetch activities: %w", err)
 	}
 
-	fmt.Printf("  → Found %d total activities\n", len(allActivities))
+	c.log.Debug("Fetched activities", "count", len(allActivities))
 
 	// Filter activities by date range
 	var allWorkouts []models.Workout
@@ -394,7 +531,7 @@ func (c *Client) GetWorkoutHistory(ctx context.Context, startDate, endDate time.
 		allWorkouts = append(allWorkouts, workout)
 	}
 
-	fmt.Printf("  ✅ Found %d workouts in date range\n", len(allWorkouts))
+	c.log.Info("Found workouts in date range", logging.Icon("✅"), "count", len(allWorkouts))
 
 	return allWorkouts, nil
 }
@@ -404,7 +541,7 @@ func (c *Client) fetchAllActivities(ctx context.Context) ([]ActivityItem, error)
 	var allActivities []ActivityItem
 	var lastLoaded int64 = 0
 
-	for {
+	for page := 1; ; page++ {
 		select {
 		case <-ctx.Done():
 			return allActivities, ctx.Err()
@@ -426,24 +563,28 @@ func (c *Client) fetchAllActivities(ctx context.Context) ([]ActivityItem, error)
 			)
 		}
 
-		resp, err := c.doAPIRequest(ctx, "GET", apiURL, nil)
+		pageCtx, span := tracing.Start(ctx, "aimharder.activity_page", tracing.Int("page", page))
+		resp, err := c.doAPIRequest(pageCtx, "GET", apiURL, nil)
 		if err != nil {
+			span.RecordError(err)
+			span.End()
 			return nil, err
 		}
 
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		span.SetAttributes(tracing.Int("bytes", len(body)))
 
-		if c.verbose && lastLoaded == 0 {
-			fmt.Printf("  → Activity API URL: %s\n", apiURL)
-			fmt.Printf("  → Activity API response (%d bytes): %s\n", len(body), string(body)[:min(500, len(body))])
-		}
+		c.log.Debug("Activity API response", "url", apiURL, "status", resp.StatusCode, "bytes", len(body), "body", string(body)[:min(500, len(body))])
 
 		if resp.StatusCode != http.StatusOK {
+			span.End()
 			break
 		}
 
 		activities, newLastLoaded := c.parseActivityResponse(body)
+		span.SetAttributes(tracing.Int("activities", len(activities)))
+		span.End()
 		if len(activities) == 0 {
 			break
 		}
@@ -455,11 +596,16 @@ func (c *Client) fetchAllActivities(ctx context.Context) ([]ActivityItem, error)
 		}
 		lastLoaded = newLastLoaded
 
-		fmt.Printf("\r  → Loaded %d activities...", len(allActivities))
-		time.Sleep(500 * time.Millisecond) // Be nice to the server
+		c.log.Debug("Loaded activities", "count", len(allActivities))
+
+		// Be nice to the server
+		select {
+		case <-ctx.Done():
+			return allActivities, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
 	}
 
-	fmt.Println()
 	return allActivities, nil
 }
 
@@ -901,7 +1047,7 @@ func (c *Client) activityToWorkout(activity ActivityItem, date time.Time) models
 	}
 
 	// Generate description
-	workout.Description = workout.FormatDescription()
+	workout.Description = c.renderer.Description(&workout)
 
 	return workout
 }
@@ -0,0 +1,209 @@
+// Package api defines the webhook API: the request and response types shared
+// by the server and the client package, and the routes the OpenAPI document
+// and the generated client are built from.
+package api
+
+import (
+	"time"
+
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/jobs"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/runlog"
+	"github.com/aimharder-sync/internal/schedule"
+	"github.com/aimharder-sync/internal/strava"
+)
+
+// Version is the version of the API in the OpenAPI document. It changes
+// when fields or routes are removed or change meaning.
+const Version = "1.0.0"
+
+// Error is the body of every error response
+type Error struct {
+	Status  int    `json:"-"`
+	Code    string `json:"error" doc:"Machine-readable code, e.g. invalid_request, not_found, unauthorized, rate_limited"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty" doc:"The offending parameter"`
+}
+
+func (e *Error) Error() string {
+	if e.Field != "" {
+		return e.Message + " (" + e.Field + ")"
+	}
+	return e.Message
+}
+
+// SyncRequest holds the options of POST /sync and POST /workouts/{id}/resync,
+// sent as query parameters or a JSON body. Pointers tell unset from false or
+// zero.
+type SyncRequest struct {
+	Days      *int     `json:"days,omitempty" doc:"Days to look back, 1-365 (default: 1)"`
+	Start     string   `json:"start,omitempty" doc:"First day to sync (YYYY-MM-DD), instead of days"`
+	End       string   `json:"end,omitempty" doc:"Last day to sync (YYYY-MM-DD, default: today)"`
+	DryRun    *bool    `json:"dry_run,omitempty" doc:"Describe the activities instead of uploading them"`
+	Force     *bool    `json:"force,omitempty" doc:"Upload workouts that were synced before"`
+	Wait      *bool    `json:"wait,omitempty" doc:"Wait for the sync and return its result instead of a job"`
+	Platforms []string `json:"platforms,omitempty" doc:"Destinations to sync to (default: sync.destinations)"`
+}
+
+// JobAccepted is the response to a queued sync
+type JobAccepted struct {
+	JobID     string `json:"job_id"`
+	State     string `json:"state"`
+	StatusURL string `json:"status_url"`
+	EventsURL string `json:"events_url"`
+}
+
+// SyncResult holds the result of a sync
+type SyncResult struct {
+	Success     bool      `json:"success"`
+	Message     string    `json:"message"`
+	Uploaded    int       `json:"uploaded"`
+	Updated     int       `json:"updated"`
+	Skipped     int       `json:"skipped" doc:"Workouts already on the platform"`
+	Errors      int       `json:"errors"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Duration    string    `json:"duration" doc:"e.g. 12.5s"`
+
+	// Destinations holds the outcome per platform
+	Destinations []destination.Summary `json:"destinations,omitempty"`
+
+	// Previews describes what a dry run would upload
+	Previews []SyncPreview `json:"previews,omitempty"`
+}
+
+// SyncPreview is what a dry run would do with a workout on one platform
+type SyncPreview struct {
+	Platform    string               `json:"platform"`
+	WorkoutID   string               `json:"workout_id"`
+	Action      string               `json:"action" doc:"upload, or skip when already synced"`
+	Activity    *destination.Preview `json:"activity"`
+	ElapsedTime string               `json:"elapsed_time,omitempty"`
+}
+
+// Status is the response of GET /status
+type Status struct {
+	Status   string           `json:"status" doc:"ok, or no_sync_yet"`
+	Message  string           `json:"message,omitempty"`
+	LastSync *time.Time       `json:"last_sync"`
+	Result   *SyncResult      `json:"result,omitempty"`
+	Schedule *schedule.Policy `json:"schedule,omitempty" doc:"Polling around booked classes, when the daemon runs in classes mode"`
+}
+
+// JobList is the response of GET /jobs, newest first
+type JobList struct {
+	Jobs []*jobs.Job `json:"jobs"`
+}
+
+// RunList is the response of GET /runs, newest first
+type RunList struct {
+	Runs []runlog.Run `json:"runs"`
+}
+
+// WorkoutList is the response of GET /workouts
+type WorkoutList struct {
+	Start    string         `json:"start"`
+	End      string         `json:"end"`
+	Count    int            `json:"count"`
+	Workouts []WorkoutState `json:"workouts"`
+}
+
+// WorkoutState is a fetched workout with its sync state per platform
+type WorkoutState struct {
+	ID        string                   `json:"id"`
+	Date      time.Time                `json:"date"`
+	Name      string                   `json:"name"`
+	Type      models.WorkoutType       `json:"type"`
+	ClassTime string                   `json:"class_time,omitempty"`
+	Synced    bool                     `json:"synced" doc:"Synced to every platform"`
+	Platforms map[string]PlatformState `json:"platforms"`
+}
+
+// PlatformState is the sync state of a workout on one platform
+type PlatformState struct {
+	Status     string     `json:"status" doc:"synced, failed or pending"`
+	ExternalID string     `json:"external_id,omitempty"`
+	SyncedAt   *time.Time `json:"synced_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// WorkoutDetail is a fetched workout, rendered with the html template, and
+// its sync state per platform
+type WorkoutDetail struct {
+	Workout   *models.Workout          `json:"workout"`
+	HTML      string                   `json:"html"`
+	Platforms map[string]PlatformState `json:"platforms"`
+}
+
+// UnsyncResult is the response of DELETE /workouts/{id}/sync
+type UnsyncResult struct {
+	WorkoutID string            `json:"workout_id"`
+	Platforms []PlatformRemoval `json:"platforms"`
+}
+
+// PlatformRemoval is the outcome of DELETE /workouts/{id}/sync on a platform
+type PlatformRemoval struct {
+	Platform        string `json:"platform"`
+	ExternalID      string `json:"external_id,omitempty"`
+	Forgotten       bool   `json:"forgotten" doc:"Removed from the sync history"`
+	ActivityDeleted bool   `json:"activity_deleted"`
+	Error           string `json:"error,omitempty"`
+}
+
+// StravaConnection is the response of GET /auth/strava
+type StravaConnection struct {
+	Configured bool `json:"configured"`
+	strava.Connection
+	RedirectURI string `json:"redirect_uri,omitempty"`
+}
+
+// StravaAuthorization is the response of POST /auth/strava
+type StravaAuthorization struct {
+	AuthorizeURL string    `json:"authorize_url" doc:"Send the user here to authorize"`
+	RedirectURI  string    `json:"redirect_uri"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Health is the response of GET /health
+type Health struct {
+	Status  string    `json:"status"`
+	Service string    `json:"service"`
+	Time    time.Time `json:"time"`
+}
+
+// Query parameters of GET requests, encoded by the client and described in
+// the OpenAPI document. Lists are comma-separated.
+
+// WorkoutsQuery holds the parameters of GET /workouts
+type WorkoutsQuery struct {
+	Days      int      `query:"days" doc:"Days to look back (default: sync.default_days)"`
+	Start     string   `query:"start" doc:"First day (YYYY-MM-DD)"`
+	End       string   `query:"end" doc:"Last day (YYYY-MM-DD)"`
+	Platforms []string `query:"platforms" doc:"Platforms to report (default: sync.destinations)"`
+}
+
+// WorkoutQuery holds the parameters of GET /workouts/{id}
+type WorkoutQuery struct {
+	Platforms []string `query:"platforms" doc:"Platforms to report (default: sync.destinations)"`
+}
+
+// UnsyncQuery holds the parameters of DELETE /workouts/{id}/sync
+type UnsyncQuery struct {
+	Platforms      []string `query:"platforms" doc:"Platforms to forget (default: all with history)"`
+	DeleteActivity bool     `query:"delete_activity" doc:"Also delete the uploaded activity where the platform allows it"`
+}
+
+// RunsQuery holds the parameters of GET /runs
+type RunsQuery struct {
+	Limit   int    `query:"limit" doc:"Newest runs to return (default: 20)"`
+	Trigger string `query:"trigger" doc:"Only runs started by cli, webhook, mqtt or schedule"`
+	Failed  bool   `query:"failed" doc:"Only runs that failed"`
+	Workout string `query:"workout" doc:"Only runs that handled this workout ID"`
+	Since   string `query:"since" doc:"Only runs started on or after this day (YYYY-MM-DD)"`
+}
+
+// CalendarQuery holds the parameters of GET /calendar.ics
+type CalendarQuery struct {
+	Days int `query:"days" doc:"Days of workouts to include"`
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aimharder-sync/internal/apisec"
+	"github.com/aimharder-sync/internal/jobs"
+	"github.com/aimharder-sync/internal/runlog"
+)
+
+// schemaNames renames types whose names clash with other schemas
+var schemaNames = map[reflect.Type]string{
+	reflect.TypeFor[jobs.Workout]():  "JobWorkout",
+	reflect.TypeFor[jobs.Event]():    "JobEvent",
+	reflect.TypeFor[runlog.Entry]():  "RunEntry",
+	reflect.TypeFor[runlog.Params](): "RunParams",
+}
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+	rawType      = reflect.TypeFor[json.RawMessage]()
+)
+
+// OpenAPI returns the OpenAPI 3.1 document describing Routes
+func OpenAPI() map[string]any {
+	s := &schemas{defs: map[string]any{}, names: map[reflect.Type]string{}}
+	paths := map[string]any{}
+
+	for _, route := range Routes {
+		op := map[string]any{
+			"operationId": lowerFirst(route.Operation),
+			"summary":     route.Summary,
+		}
+		if route.Description != "" {
+			op["description"] = route.Description
+		}
+
+		var params []any
+		for _, name := range PathParams(route.Path) {
+			params = append(params, map[string]any{
+				"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, p := range QueryParams(route.Query) {
+			param := map[string]any{"name": p.Name, "in": "query", "schema": s.schema(p.Type)}
+			if p.Doc != "" {
+				param["description"] = p.Doc
+			}
+			params = append(params, param)
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		if route.Body != nil {
+			op["requestBody"] = map[string]any{
+				"content": map[string]any{ContentJSON: map[string]any{"schema": s.schema(reflect.TypeOf(route.Body))}},
+			}
+		}
+
+		responses := map[string]any{}
+		for _, resp := range route.Responses {
+			responses[statusKey(resp.Status)] = s.response(resp)
+		}
+		if route.Scope != "" {
+			responses[statusKey(http.StatusUnauthorized)] = s.response(errorResponse(http.StatusUnauthorized, "Missing or invalid token"))
+			responses[statusKey(http.StatusForbidden)] = s.response(errorResponse(http.StatusForbidden, "The token's scope doesn't allow this"))
+			responses[statusKey(http.StatusTooManyRequests)] = s.response(errorResponse(http.StatusTooManyRequests, "Rate limited; see Retry-After"))
+			op["security"] = []any{map[string]any{"token": []any{}}, map[string]any{"bearer": []any{}}}
+			op["x-scope"] = route.Scope
+		} else {
+			op["security"] = []any{}
+		}
+		op["responses"] = responses
+
+		item, _ := paths[route.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "AimHarder Sync webhook API",
+			"version": Version,
+			"description": "Trigger and follow workout syncs. Tokens have the " + apisec.ScopeRead + " or " +
+				apisec.ScopeTrigger + " scope (x-scope); signed tokens send X-Auth-Key, X-Auth-Timestamp and " +
+				"X-Auth-Signature instead, the hex HMAC-SHA256 of \"<timestamp>\\n<method>\\n<path and query>\\n<body>\".",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": s.defs,
+			"securitySchemes": map[string]any{
+				"token":  map[string]any{"type": "apiKey", "in": "header", "name": "X-Auth-Token"},
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// Param is a query parameter
+type Param struct {
+	Name  string
+	Field string // struct field name
+	Type  reflect.Type
+	Doc   string
+}
+
+// QueryParams lists the query parameters of a Query struct
+func QueryParams(query any) []Param {
+	if query == nil {
+		return nil
+	}
+	t := reflect.TypeOf(query)
+	var params []Param
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if name := f.Tag.Get("query"); name != "" {
+			params = append(params, Param{Name: name, Field: f.Name, Type: f.Type, Doc: f.Tag.Get("doc")})
+		}
+	}
+	return params
+}
+
+// PathParams returns the names of the {name} parameters of a path
+func PathParams(p string) []string {
+	var names []string
+	for _, part := range strings.Split(p, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			names = append(names, strings.Trim(part, "{}"))
+		}
+	}
+	return names
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// schemas builds JSON schemas from Go types, collecting named structs in
+// components/schemas
+type schemas struct {
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+func (s *schemas) response(resp Response) map[string]any {
+	out := map[string]any{"description": resp.Description}
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = ContentJSON
+	}
+	schema := map[string]any{}
+	if resp.Body != nil {
+		schema = s.schema(reflect.TypeOf(resp.Body))
+	} else if contentType != ContentJSON {
+		schema = map[string]any{"type": "string"}
+	}
+	out["content"] = map[string]any{contentType: map[string]any{"schema": schema}}
+	return out
+}
+
+func (s *schemas) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "description": "Nanoseconds"}
+	case rawType:
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		return s.ref(t)
+	}
+	return map[string]any{}
+}
+
+// ref returns a reference to the schema of a named struct, adding it to
+// the components first
+func (s *schemas) ref(t reflect.Type) map[string]any {
+	name, ok := s.names[t]
+	if !ok {
+		name = s.name(t)
+		s.names[t] = name
+		s.defs[name] = nil // reserved while the fields refer back to t
+		s.defs[name] = s.object(t)
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemas) name(t reflect.Type) string {
+	if name, ok := schemaNames[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := s.defs[name]; taken {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	return name
+}
+
+func (s *schemas) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	s.fields(t, props, &required)
+	obj := map[string]any{"type": "object", "properties": props}
+	if required != nil {
+		obj["required"] = required
+	}
+	return obj
+}
+
+// fields adds the JSON fields of t, inlining embedded structs like
+// encoding/json does
+func (s *schemas) fields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		// Every call returns a new map, and OpenAPI 3.1 allows a description
+		// next to $ref
+		schema := s.schema(f.Type)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			schema["description"] = doc
+		}
+		props[name] = schema
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
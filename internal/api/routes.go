@@ -0,0 +1,231 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/aimharder-sync/internal/apisec"
+	"github.com/aimharder-sync/internal/jobs"
+	"github.com/aimharder-sync/internal/runlog"
+)
+
+// Content types of non-JSON responses
+const (
+	ContentJSON        = "application/json"
+	ContentEventStream = "text/event-stream"
+	ContentCalendar    = "text/calendar"
+	ContentText        = "text/plain"
+)
+
+// Route describes an API endpoint
+type Route struct {
+	Method      string
+	Path        string // with {name} path parameters
+	Operation   string // client method name and OpenAPI operationId
+	Summary     string
+	Description string
+	Scope       string // apisec.ScopeRead or ScopeTrigger; empty for open endpoints
+	Query       any    // struct with `query` tags, or nil
+	Body        any    // JSON request body, or nil
+	Responses   []Response
+}
+
+// Response describes a response of a route; the first one is the
+// successful response the client returns
+type Response struct {
+	Status      int
+	Description string
+	Body        any    // nil for no body
+	ContentType string // default: ContentJSON
+}
+
+// errorResponse is a documented error response
+func errorResponse(status int, description string) Response {
+	return Response{Status: status, Description: description, Body: Error{}}
+}
+
+// Routes are the documented endpoints of the webhook server
+var Routes = []Route{
+	{
+		Method:    http.MethodPost,
+		Path:      "/sync",
+		Operation: "Sync",
+		Summary:   "Queue a sync",
+		Description: "Queues a sync and returns its job ID right away; follow it with GET /jobs/{id} or " +
+			"/jobs/{id}/events. With wait it waits for the sync and returns its result instead. " +
+			"The options may also be sent as query parameters.",
+		Scope: apisec.ScopeTrigger,
+		Body:  SyncRequest{},
+		Responses: []Response{
+			{Status: http.StatusAccepted, Description: "The sync was queued", Body: JobAccepted{}},
+			{Status: http.StatusOK, Description: "The sync finished (with wait)", Body: SyncResult{}},
+			errorResponse(http.StatusBadRequest, "Invalid options"),
+			errorResponse(http.StatusServiceUnavailable, "Too many syncs are queued"),
+		},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/jobs",
+		Operation: "Jobs",
+		Summary:   "List recent sync jobs, newest first",
+		Scope:     apisec.ScopeRead,
+		Responses: []Response{{Status: http.StatusOK, Description: "Recent jobs, without their events", Body: JobList{}}},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/jobs/{id}",
+		Operation: "Job",
+		Summary:   "Get a sync job's state and per-workout progress",
+		Scope:     apisec.ScopeRead,
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The job; result holds the SyncResult when it finished", Body: jobs.Job{}},
+			errorResponse(http.StatusNotFound, "Unknown job"),
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/jobs/{id}/events",
+		Operation:   "JobEvents",
+		Summary:     "Stream a sync job's progress",
+		Description: "Server-Sent Events named after the event type; earlier events are replayed first (after Last-Event-ID). The stream ends when the job finishes.",
+		Scope:       apisec.ScopeRead,
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Each event's data is a JobEvent", Body: jobs.Event{}, ContentType: ContentEventStream},
+			errorResponse(http.StatusNotFound, "Unknown job"),
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/runs",
+		Operation:   "Runs",
+		Summary:     "List recorded sync runs, newest first",
+		Description: "Every sync from the CLI, webhook, MQTT or the schedule is recorded in the run log, within storage.run_history and storage.run_max_age.",
+		Scope:       apisec.ScopeRead,
+		Query:       RunsQuery{},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Matching runs, without their workouts", Body: RunList{}},
+			errorResponse(http.StatusBadRequest, "Invalid parameters"),
+		},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/runs/{id}",
+		Operation: "Run",
+		Summary:   "Get a sync run with the decision for every workout",
+		Scope:     apisec.ScopeRead,
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The run", Body: runlog.Run{}},
+			errorResponse(http.StatusNotFound, "Unknown or pruned run"),
+		},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/workouts",
+		Operation: "Workouts",
+		Summary:   "List fetched workouts with their sync state per platform",
+		Scope:     apisec.ScopeRead,
+		Query:     WorkoutsQuery{},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Workouts, newest first", Body: WorkoutList{}},
+			errorResponse(http.StatusBadRequest, "Invalid parameters"),
+		},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/workouts/{id}",
+		Operation: "Workout",
+		Summary:   "Get a fetched workout, rendered as HTML, with its sync state",
+		Scope:     apisec.ScopeRead,
+		Query:     WorkoutQuery{},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The workout", Body: WorkoutDetail{}},
+			errorResponse(http.StatusNotFound, "The workout has not been fetched"),
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/workouts/{id}/resync",
+		Operation:   "Resync",
+		Summary:     "Queue a sync of one workout",
+		Description: "Uploads the workout again, even if it was synced before unless force is false. days, start and end are not allowed.",
+		Scope:       apisec.ScopeTrigger,
+		Body:        SyncRequest{},
+		Responses: []Response{
+			{Status: http.StatusAccepted, Description: "The sync was queued", Body: JobAccepted{}},
+			{Status: http.StatusOK, Description: "The sync finished (with wait)", Body: SyncResult{}},
+			errorResponse(http.StatusNotFound, "The workout has not been fetched"),
+		},
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/workouts/{id}/sync",
+		Operation:   "Unsync",
+		Summary:     "Forget that a workout was synced",
+		Description: "Removes the workout's sync history so the next sync uploads it again.",
+		Scope:       apisec.ScopeTrigger,
+		Query:       UnsyncQuery{},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The outcome per platform", Body: UnsyncResult{}},
+			errorResponse(http.StatusNotFound, "The workout has no sync history"),
+			errorResponse(http.StatusConflict, "A sync is running"),
+		},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/status",
+		Operation: "Status",
+		Summary:   "Get the last sync result",
+		Scope:     apisec.ScopeRead,
+		Responses: []Response{{Status: http.StatusOK, Description: "The last sync since the server started", Body: Status{}}},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/calendar.ics",
+		Operation: "Calendar",
+		Summary:   "Workouts and booked classes as an iCalendar feed",
+		Scope:     apisec.ScopeRead,
+		Query:     CalendarQuery{},
+		Responses: []Response{{Status: http.StatusOK, Description: "The feed", ContentType: ContentCalendar}},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/auth/strava",
+		Operation: "StravaConnection",
+		Summary:   "Get the Strava connection state",
+		Scope:     apisec.ScopeRead,
+		Responses: []Response{{Status: http.StatusOK, Description: "The stored authorization, without tokens", Body: StravaConnection{}}},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/auth/strava",
+		Operation:   "AuthorizeStrava",
+		Summary:     "Start a Strava authorization",
+		Description: "Returns the URL to send the user to; Strava redirects back to strava.redirect_uri on this server.",
+		Scope:       apisec.ScopeTrigger,
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The authorization URL", Body: StravaAuthorization{}},
+			errorResponse(http.StatusConflict, "Strava is not configured"),
+		},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/health",
+		Operation: "Health",
+		Summary:   "Health check",
+		Responses: []Response{{Status: http.StatusOK, Description: "The server is up", Body: Health{}}},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/metrics",
+		Operation: "Metrics",
+		Summary:   "Prometheus metrics",
+		Scope:     apisec.ScopeRead,
+		Responses: []Response{{Status: http.StatusOK, Description: "Metrics in the Prometheus text format", ContentType: ContentText}},
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/openapi.json",
+		Operation: "OpenAPI",
+		Summary:   "This document",
+		Responses: []Response{{Status: http.StatusOK, Description: "The OpenAPI document"}},
+	},
+}
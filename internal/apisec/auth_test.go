@@ -0,0 +1,40 @@
+package apisec
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignedRejectsReplays(t *testing.T) {
+	a, err := NewAuthenticator([]Token{{Name: "ci", Secret: "s3cret"}}, false, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := time.Now()
+	signature := Sign("s3cret", ts, "POST", "/sync", []byte("{}"))
+	request := func(signature string) error {
+		r := httptest.NewRequest("POST", "/sync", strings.NewReader("{}"))
+		r.Header.Set(HeaderKey, "ci")
+		r.Header.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+		r.Header.Set(HeaderSignature, signature)
+		_, err := a.Authenticate(r)
+		return err
+	}
+
+	if err := request(signature); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	for _, replay := range []string{
+		signature,
+		strings.ToUpper(signature),
+		"sha256=" + signature,
+	} {
+		if err := request(replay); err == nil {
+			t.Errorf("replay with signature %q was accepted", replay)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package apisec
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aimharder-sync/internal/logging"
+)
+
+// reloadCheckInterval is how often the certificate files are checked for
+// changes, at most
+const reloadCheckInterval = 10 * time.Second
+
+// CertReloader serves a TLS certificate from files and reloads it when they
+// change, e.g. after a Let's Encrypt renewal, without restarting the server
+type CertReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// NewCertReloader loads the certificate and key
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	c := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the certificate files, keeping the current certificate on
+// failure. The caller holds c.mu or owns c.
+func (c *CertReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	c.cert = &cert
+	c.modTime = c.lastModified()
+	c.checked = time.Now()
+	return nil
+}
+
+// lastModified returns the newest modification time of the two files
+func (c *CertReloader) lastModified() time.Time {
+	var latest time.Time
+	for _, path := range []string{c.certFile, c.keyFile} {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// GetCertificate implements tls.Config.GetCertificate. A certificate that
+// fails to reload is reported and the previous one kept.
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) >= reloadCheckInterval {
+		c.checked = time.Now()
+		if c.lastModified().After(c.modTime) {
+			// A half-written pair fails to load; it's retried on the next check
+			if err := c.load(); err != nil {
+				slog.Warn("Keeping the current TLS certificate", "error", err)
+			} else {
+				slog.Info("Reloaded TLS certificate", logging.Icon("🔐"), "file", c.certFile)
+			}
+		}
+	}
+	return c.cert, nil
+}
+
+// Config returns a TLS config serving the reloaded certificate
+func (c *CertReloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+	}
+}
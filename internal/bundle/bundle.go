@@ -0,0 +1,291 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Archive layout
+const (
+	ManifestName = "workouts.json"
+	HistoryName  = "sync_history.json"
+	ReadmeName   = "README.md"
+	FilesDir     = "files"
+
+	// manifestVersion is bumped when the manifest format changes
+	manifestVersion = 1
+
+	// maxFileSize guards against decompression bombs on import
+	maxFileSize = 64 << 20
+)
+
+// Manifest is stored as workouts.json and describes every workout in the
+// bundle together with the archive paths of its generated files
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Entries   []Entry   `json:"workouts"`
+}
+
+// Entry is a single workout in the manifest
+type Entry struct {
+	Workout models.Workout `json:"workout"`
+	Files   []string       `json:"files,omitempty"`
+}
+
+// Bundle is the content of a bundle archive
+type Bundle struct {
+	Manifest Manifest
+	History  map[string][]models.SyncStatus
+
+	// Files maps archive paths (files/<name>) to their content
+	Files map[string][]byte
+}
+
+// Write creates a bundle archive at dest. files maps workout IDs to the
+// generated files (TCX, FIT, ...) on disk for that workout.
+func Write(dest string, workouts []models.Workout, files map[string][]string, history map[string][]models.SyncStatus) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+
+	manifest := Manifest{
+		Version:   manifestVersion,
+		CreatedAt: time.Now(),
+	}
+
+	used := make(map[string]bool)
+	for _, w := range workouts {
+		entry := Entry{Workout: w}
+		for _, src := range files[w.ID] {
+			name := path.Join(FilesDir, filepath.Base(src))
+			if used[name] {
+				// Keep entries unique even if two sources share a file name
+				name = path.Join(FilesDir, w.ID+"_"+filepath.Base(src))
+			}
+			used[name] = true
+
+			if err := addFile(zw, name, src); err != nil {
+				return err
+			}
+			entry.Files = append(entry.Files, name)
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	if err := addJSON(zw, ManifestName, manifest); err != nil {
+		return err
+	}
+
+	if history == nil {
+		history = map[string][]models.SyncStatus{}
+	}
+	if err := addJSON(zw, HistoryName, history); err != nil {
+		return err
+	}
+
+	if err := addBytes(zw, ReadmeName, []byte(readme(&manifest, history))); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize bundle: %w", err)
+	}
+
+	return out.Close()
+}
+
+// Read loads a bundle archive
+func Read(src string) (*Bundle, error) {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer zr.Close()
+
+	b := &Bundle{
+		History: make(map[string][]models.SyncStatus),
+		Files:   make(map[string][]byte),
+	}
+
+	var hasManifest bool
+	for _, f := range zr.File {
+		name := path.Clean(f.Name)
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, "..") || path.IsAbs(name) {
+			return nil, fmt.Errorf("invalid path in bundle: %s", f.Name)
+		}
+
+		data, err := readFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name == ManifestName:
+			if err := json.Unmarshal(data, &b.Manifest); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", ManifestName, err)
+			}
+			hasManifest = true
+		case name == HistoryName:
+			if err := json.Unmarshal(data, &b.History); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", HistoryName, err)
+			}
+		case strings.HasPrefix(name, FilesDir+"/"):
+			b.Files[name] = data
+		}
+	}
+
+	if !hasManifest {
+		return nil, fmt.Errorf("bundle has no %s manifest", ManifestName)
+	}
+	if b.Manifest.Version > manifestVersion {
+		return nil, fmt.Errorf("bundle version %d is newer than supported version %d", b.Manifest.Version, manifestVersion)
+	}
+
+	return b, nil
+}
+
+// Workouts returns the workouts in the manifest
+func (b *Bundle) Workouts() []models.Workout {
+	workouts := make([]models.Workout, len(b.Manifest.Entries))
+	for i, e := range b.Manifest.Entries {
+		workouts[i] = e.Workout
+	}
+	return workouts
+}
+
+// ExtractFiles writes the bundled files into dir and returns their paths
+func (b *Bundle) ExtractFiles(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(b.Files))
+	for name := range b.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var written []string
+	for _, name := range names {
+		// Only the base name is used so archive paths can't escape dir
+		dest := filepath.Join(dir, path.Base(name))
+		if err := os.WriteFile(dest, b.Files[name], 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", dest, err)
+		}
+		written = append(written, dest)
+	}
+
+	return written, nil
+}
+
+func addFile(zw *zip.Writer, name, src string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	return addBytes(zw, name, data)
+}
+
+func addJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	return addBytes(zw, name, data)
+}
+
+func addBytes(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+func readFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(rc, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if n > maxFileSize {
+		return nil, fmt.Errorf("%s exceeds maximum size of %d bytes", f.Name, maxFileSize)
+	}
+	return buf.Bytes(), nil
+}
+
+// readme renders the README.md index of the bundle
+func readme(m *Manifest, history map[string][]models.SyncStatus) string {
+	var sb strings.Builder
+
+	sb.WriteString("# AimHarder Sync bundle\n\n")
+	fmt.Fprintf(&sb, "Created %s with %d workouts.\n\n", m.CreatedAt.Format("2006-01-02 15:04"), len(m.Entries))
+	sb.WriteString("- `" + ManifestName + "` - full workout data and the files generated for each workout\n")
+	sb.WriteString("- `" + HistoryName + "` - sync history per workout and platform\n")
+	sb.WriteString("- `" + FilesDir + "/` - generated activity files\n\n")
+	sb.WriteString("Restore with `aimharder-sync import --bundle <file>`.\n\n")
+
+	sb.WriteString("| Date | Workout | Type | Synced to | Files |\n")
+	sb.WriteString("|------|---------|------|-----------|-------|\n")
+	for _, e := range m.Entries {
+		var platforms []string
+		seen := make(map[string]bool)
+		for _, s := range history[e.Workout.ID] {
+			if s.Success && !seen[s.Platform] {
+				seen[s.Platform] = true
+				platforms = append(platforms, s.Platform)
+			}
+		}
+
+		var files []string
+		for _, f := range e.Files {
+			files = append(files, fmt.Sprintf("[%s](%s)", path.Base(f), f))
+		}
+
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+			e.Workout.Date.Format("2006-01-02"),
+			strings.ReplaceAll(e.Workout.Name, "|", "\\|"),
+			e.Workout.Type,
+			strings.Join(platforms, ", "),
+			strings.Join(files, ", "),
+		)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,196 @@
+// Package client calls the webhook API of a running aimharder-sync server.
+// The endpoint methods in client_gen.go are generated from api.Routes, so
+// they change with the routes; this file holds the transport and the
+// endpoints that don't return JSON. Sync and Resync return the queued job,
+// so leave api.SyncRequest.Wait unset and follow the job with StreamEvents.
+package client
+
+//go:generate go run ./gen
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/apisec"
+	"github.com/aimharder-sync/internal/jobs"
+)
+
+// Client calls a webhook server
+type Client struct {
+	BaseURL string // e.g. http://homeassistant.local:8080
+	Token   string
+
+	// Key is the name of a signed token (server.tokens[].signed): requests
+	// are signed with Token instead of sending it
+	Key string
+
+	HTTPClient *http.Client // default: http.DefaultClient
+}
+
+// New returns a client for the server at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+// do sends a request and decodes the JSON response into out. Error
+// responses are returned as *api.Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	resp, err := c.send(ctx, method, path, query, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// send sends an authenticated request, turning error statuses into
+// *api.Error
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body any, accept string) (*http.Response, error) {
+	u, err := url.Parse(c.BaseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	u.RawQuery = query.Encode()
+
+	var data []byte
+	if body != nil {
+		if data, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", accept)
+	c.authenticate(req, data)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", c.BaseURL, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		apiErr := &api.Error{}
+		if json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(apiErr) != nil || apiErr.Message == "" {
+			apiErr = &api.Error{Code: "http_error", Message: fmt.Sprintf("%s %s: %s", method, path, resp.Status)}
+		}
+		apiErr.Status = resp.StatusCode
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// authenticate adds the token, or the signature headers for signed tokens
+func (c *Client) authenticate(req *http.Request, body []byte) {
+	if c.Token == "" {
+		return
+	}
+	if c.Key == "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		return
+	}
+	now := time.Now()
+	req.Header.Set(apisec.HeaderKey, c.Key)
+	req.Header.Set(apisec.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(apisec.HeaderSignature, apisec.Sign(c.Token, now, req.Method, req.URL.RequestURI(), body))
+}
+
+// encodeQuery turns a struct with `query` tags into query parameters,
+// leaving out zero values. Lists are comma-separated.
+func encodeQuery(query any) url.Values {
+	values := url.Values{}
+	v := reflect.ValueOf(query)
+	for i := range v.NumField() {
+		name := v.Type().Field(i).Tag.Get("query")
+		field := v.Field(i)
+		if name == "" || field.IsZero() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Slice:
+			parts := make([]string, field.Len())
+			for j := range parts {
+				parts[j] = fmt.Sprint(field.Index(j).Interface())
+			}
+			values.Set(name, strings.Join(parts, ","))
+		default:
+			values.Set(name, fmt.Sprint(field.Interface()))
+		}
+	}
+	return values
+}
+
+// StreamEvents calls fn with a job's events as they happen, earlier ones
+// first, until the job finishes, fn returns an error or ctx is done
+func (c *Client) StreamEvents(ctx context.Context, id string, fn func(jobs.Event) error) error {
+	resp, err := c.send(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id)+"/events", nil, nil, api.ContentEventStream)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			// Only data matters: the event name and ID are also in it
+			if rest, ok := strings.CutPrefix(line, "data:"); ok {
+				data.WriteString(strings.TrimPrefix(rest, " "))
+			}
+			continue
+		}
+		if data.Len() == 0 {
+			continue
+		}
+		var ev jobs.Event
+		if err := json.Unmarshal([]byte(data.String()), &ev); err != nil {
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+		data.Reset()
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("event stream interrupted: %w", err)
+	}
+	return ctx.Err()
+}
+
+// Calendar returns the iCalendar feed
+func (c *Client) Calendar(ctx context.Context, query api.CalendarQuery) ([]byte, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/calendar.ics", encodeQuery(query), nil, api.ContentCalendar)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,129 @@
+// Code generated by go run ./gen; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/aimharder-sync/internal/api"
+	"github.com/aimharder-sync/internal/jobs"
+	"github.com/aimharder-sync/internal/runlog"
+)
+
+// Sync calls POST /sync: queue a sync
+func (c *Client) Sync(ctx context.Context, req api.SyncRequest) (*api.JobAccepted, error) {
+	var out api.JobAccepted
+	if err := c.do(ctx, "POST", "/sync", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Jobs calls GET /jobs: list recent sync jobs, newest first
+func (c *Client) Jobs(ctx context.Context) (*api.JobList, error) {
+	var out api.JobList
+	if err := c.do(ctx, "GET", "/jobs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Job calls GET /jobs/{id}: get a sync job's state and per-workout progress
+func (c *Client) Job(ctx context.Context, id string) (*jobs.Job, error) {
+	var out jobs.Job
+	if err := c.do(ctx, "GET", "/jobs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Runs calls GET /runs: list recorded sync runs, newest first
+func (c *Client) Runs(ctx context.Context, query api.RunsQuery) (*api.RunList, error) {
+	var out api.RunList
+	if err := c.do(ctx, "GET", "/runs", encodeQuery(query), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Run calls GET /runs/{id}: get a sync run with the decision for every workout
+func (c *Client) Run(ctx context.Context, id string) (*runlog.Run, error) {
+	var out runlog.Run
+	if err := c.do(ctx, "GET", "/runs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Workouts calls GET /workouts: list fetched workouts with their sync state per platform
+func (c *Client) Workouts(ctx context.Context, query api.WorkoutsQuery) (*api.WorkoutList, error) {
+	var out api.WorkoutList
+	if err := c.do(ctx, "GET", "/workouts", encodeQuery(query), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Workout calls GET /workouts/{id}: get a fetched workout, rendered as HTML, with its sync state
+func (c *Client) Workout(ctx context.Context, id string, query api.WorkoutQuery) (*api.WorkoutDetail, error) {
+	var out api.WorkoutDetail
+	if err := c.do(ctx, "GET", "/workouts/"+url.PathEscape(id), encodeQuery(query), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Resync calls POST /workouts/{id}/resync: queue a sync of one workout
+func (c *Client) Resync(ctx context.Context, id string, req api.SyncRequest) (*api.JobAccepted, error) {
+	var out api.JobAccepted
+	if err := c.do(ctx, "POST", "/workouts/"+url.PathEscape(id)+"/resync", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Unsync calls DELETE /workouts/{id}/sync: forget that a workout was synced
+func (c *Client) Unsync(ctx context.Context, id string, query api.UnsyncQuery) (*api.UnsyncResult, error) {
+	var out api.UnsyncResult
+	if err := c.do(ctx, "DELETE", "/workouts/"+url.PathEscape(id)+"/sync", encodeQuery(query), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Status calls GET /status: get the last sync result
+func (c *Client) Status(ctx context.Context) (*api.Status, error) {
+	var out api.Status
+	if err := c.do(ctx, "GET", "/status", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StravaConnection calls GET /auth/strava: get the Strava connection state
+func (c *Client) StravaConnection(ctx context.Context) (*api.StravaConnection, error) {
+	var out api.StravaConnection
+	if err := c.do(ctx, "GET", "/auth/strava", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AuthorizeStrava calls POST /auth/strava: start a Strava authorization
+func (c *Client) AuthorizeStrava(ctx context.Context) (*api.StravaAuthorization, error) {
+	var out api.StravaAuthorization
+	if err := c.do(ctx, "POST", "/auth/strava", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Health calls GET /health: health check
+func (c *Client) Health(ctx context.Context) (*api.Health, error) {
+	var out api.Health
+	if err := c.do(ctx, "GET", "/health", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,128 @@
+// Command gen writes client_gen.go: a Client method per JSON route in
+// api.Routes. Run it with go generate in internal/client.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/aimharder-sync/internal/api"
+)
+
+const output = "client_gen.go"
+
+var fileTemplate = template.Must(template.New(output).Parse(`// Code generated by go run ./gen; DO NOT EDIT.
+
+package client
+
+import (
+{{- range .Std}}
+	"{{.}}"
+{{- end}}
+{{range .Module}}
+	"{{.}}"
+{{- end}}
+)
+{{range .Methods}}
+// {{.Name}} calls {{.Method}} {{.Path}}: {{.Summary}}
+func (c *Client) {{.Name}}(ctx context.Context{{range .PathParams}}, {{.}} string{{end}}{{if .Query}}, query {{.Query}}{{end}}{{if .Body}}, req {{.Body}}{{end}}) (*{{.Result}}, error) {
+	var out {{.Result}}
+	if err := c.do(ctx, "{{.Method}}", {{.PathExpr}}, {{if .Query}}encodeQuery(query){{else}}nil{{end}}, {{if .Body}}req{{else}}nil{{end}}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+{{end}}`))
+
+type method struct {
+	Name, Method, Path, Summary string
+	PathParams                  []string
+	PathExpr                    string
+	Query, Body, Result         string
+}
+
+func main() {
+	imports := map[string]bool{"context": true}
+	typeName := func(v any) string {
+		t := reflect.TypeOf(v)
+		imports[t.PkgPath()] = true
+		return t.String()
+	}
+
+	var methods []method
+	for _, route := range api.Routes {
+		// Streams and feeds have hand-written methods in client.go
+		success := route.Responses[0]
+		if success.Body == nil || (success.ContentType != "" && success.ContentType != api.ContentJSON) {
+			continue
+		}
+		m := method{
+			Name:       route.Operation,
+			Method:     route.Method,
+			Path:       route.Path,
+			Summary:    strings.ToLower(route.Summary[:1]) + route.Summary[1:],
+			PathParams: api.PathParams(route.Path),
+			PathExpr:   pathExpr(route.Path),
+			Result:     typeName(success.Body),
+		}
+		if route.Query != nil {
+			m.Query = typeName(route.Query)
+		}
+		if route.Body != nil {
+			m.Body = typeName(route.Body)
+		}
+		if len(m.PathParams) > 0 {
+			imports["net/url"] = true
+		}
+		methods = append(methods, m)
+	}
+
+	var std, module []string
+	for path := range imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			module = append(module, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	slices.Sort(std)
+	slices.Sort(module)
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, map[string]any{"Std": std, "Module": module, "Methods": methods}); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format %s: %v\n%s", output, err, buf.Bytes())
+	}
+	if err := os.WriteFile(output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %d methods to %s\n", len(methods), output)
+}
+
+// pathExpr turns "/jobs/{id}/events" into "/jobs/"+url.PathEscape(id)+"/events"
+func pathExpr(path string) string {
+	var parts []string
+	for {
+		before, rest, found := strings.Cut(path, "{")
+		if !found {
+			break
+		}
+		name, after, _ := strings.Cut(rest, "}")
+		parts = append(parts, fmt.Sprintf("%q", before), "url.PathEscape("+name+")")
+		path = after
+	}
+	if path != "" {
+		parts = append(parts, fmt.Sprintf("%q", path))
+	}
+	return strings.Join(parts, " + ")
+}
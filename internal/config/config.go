@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,6 +14,17 @@ import (
 type Config struct {
 	Aimharder AimharderConfig `mapstructure:"aimharder"`
 	Strava    StravaConfig    `mapstructure:"strava"`
+	Intervals IntervalsConfig `mapstructure:"intervals"`
+	Garmin    GarminConfig    `mapstructure:"garmin"`
+	Webhooks  []WebhookConfig `mapstructure:"webhooks"`
+	Sheet     SheetConfig     `mapstructure:"sheet"`
+	MQTT      MQTTConfig      `mapstructure:"mqtt"`
+	Notify    NotifyConfig    `mapstructure:"notifications"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Remote    RemoteConfig    `mapstructure:"remote"`
+	Log       LogConfig       `mapstructure:"log"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
+	Schedule  ScheduleConfig  `mapstructure:"schedule"`
 	Storage   StorageConfig   `mapstructure:"storage"`
 	Sync      SyncConfig      `mapstructure:"sync"`
 }
@@ -35,14 +47,192 @@ type StravaConfig struct {
 	RedirectURI  string `mapstructure:"redirect_uri"`
 	AccessToken  string `mapstructure:"access_token"`
 	RefreshToken string `mapstructure:"refresh_token"`
+	Update       bool   `mapstructure:"update"` // update activities whose workout changed (default true)
+}
+
+// IntervalsConfig holds intervals.icu API config
+type IntervalsConfig struct {
+	APIKey    string `mapstructure:"api_key"`    // Settings > Developer Settings on intervals.icu
+	AthleteID string `mapstructure:"athlete_id"` // "0" means the athlete owning the API key
+	BaseURL   string `mapstructure:"base_url"`   // defaults to https://intervals.icu
+	Update    bool   `mapstructure:"update"`     // update activities whose workout changed (default true)
+}
+
+// GarminConfig holds Garmin Connect config. Garmin has no public upload
+// API, so tokens are taken from a Garmin Connect login (e.g. via garth)
+type GarminConfig struct {
+	AccessToken  string `mapstructure:"access_token"`
+	RefreshToken string `mapstructure:"refresh_token"`
+	ClientID     string `mapstructure:"client_id"` // OAuth2 client the refresh token was issued to
+	BaseURL      string `mapstructure:"base_url"`  // defaults to https://connectapi.garmin.com
+	TokenURL     string `mapstructure:"token_url"` // OAuth2 token endpoint used for refreshes
+	Update       bool   `mapstructure:"update"`    // update activities whose workout changed (default true)
+}
+
+// WebhookConfig is an outbound webhook that receives new and updated
+// workouts as JSON, selected as destination "webhook:<name>"
+type WebhookConfig struct {
+	Name    string            `mapstructure:"name"`
+	URL     string            `mapstructure:"url"`
+	Secret  string            `mapstructure:"secret"`  // HMAC-SHA256 signing key
+	Types   []string          `mapstructure:"types"`   // only send these workout types (default: all)
+	Headers map[string]string `mapstructure:"headers"` // extra request headers
+}
+
+// Webhook returns the outbound webhook with the given name
+func (c *Config) Webhook(name string) (*WebhookConfig, error) {
+	for i := range c.Webhooks {
+		if strings.EqualFold(c.Webhooks[i].Name, name) {
+			return &c.Webhooks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("webhook %q is not configured (add it under 'webhooks' in config.yaml)", name)
+}
+
+// SheetConfig holds settings for the spreadsheet training log
+type SheetConfig struct {
+	File    string `mapstructure:"file"`    // .xlsx or .ods workbook
+	Athlete string `mapstructure:"athlete"` // sheet name (default: the Aimharder email's user name)
+}
+
+// ServerConfig holds settings for the webhook server
+type ServerConfig struct {
+	JobHistory int `mapstructure:"job_history"` // Sync jobs kept for GET /jobs/{id}
+
+	// Authentication: Token (or --token) is a token with the trigger scope
+	// named "default"; Tokens adds named tokens with scopes
+	Token           string           `mapstructure:"token"`
+	Tokens          []APITokenConfig `mapstructure:"tokens"`
+	QueryToken      bool             `mapstructure:"query_token"`      // Accept ?token= (e.g. for calendar apps)
+	SignatureWindow time.Duration    `mapstructure:"signature_window"` // Max age of signed requests
+
+	RateLimit int `mapstructure:"rate_limit"` // Requests per minute per client (0 disables)
+	RateBurst int `mapstructure:"rate_burst"` // Requests a client may make at once
+
+	// Native TLS; the files are reloaded when they change
+	TLSCert string `mapstructure:"tls_cert"`
+	TLSKey  string `mapstructure:"tls_key"`
+
+	// IngressPort serves the API and dashboard to the Home Assistant
+	// ingress proxy, which authenticates users itself
+	IngressPort int `mapstructure:"ingress_port"`
+}
+
+// APITokenConfig is a named webhook API token
+type APITokenConfig struct {
+	Name   string `mapstructure:"name"`
+	Token  string `mapstructure:"token"`
+	Scope  string `mapstructure:"scope"`  // "read" or "trigger" (default)
+	Signed bool   `mapstructure:"signed"` // Only accept HMAC-signed requests
+}
+
+// TLSEnabled reports whether the webhook server serves HTTPS
+func (s ServerConfig) TLSEnabled() bool {
+	return s.TLSCert != "" && s.TLSKey != ""
+}
+
+// RemoteConfig points the CLI at a running webhook server (--remote):
+// sync and status then call its API instead of doing the work locally
+type RemoteConfig struct {
+	URL   string `mapstructure:"url"`   // e.g. http://homeassistant.local:8080
+	Token string `mapstructure:"token"` // Needs the trigger scope to sync
+	Key   string `mapstructure:"key"`   // Name of a signed token; Token then signs requests
+}
+
+// LogConfig selects how log records are written (--log-format, --log-level)
+type LogConfig struct {
+	Format string `mapstructure:"format"` // auto, pretty, text or json
+	Level  string `mapstructure:"level"`  // debug, info, warn or error
+}
+
+// TracingConfig exports OpenTelemetry traces of syncs over OTLP/HTTP
+type TracingConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Endpoint    string `mapstructure:"endpoint"`     // collector base URL; spans go to <endpoint>/v1/traces
+	ServiceName string `mapstructure:"service_name"` // service.name of the traces
+}
+
+// ScheduleConfig holds settings for the daemon's scheduled syncs
+type ScheduleConfig struct {
+	Mode         string        `mapstructure:"mode"`          // "classes" (poll after booked classes) or "cron"
+	Cron         string        `mapstructure:"cron"`          // Cron expression or "@every 15m" (cron mode)
+	Days         int           `mapstructure:"days"`          // Days to look back on each sync
+	QuietHours   string        `mapstructure:"quiet_hours"`   // No syncs in this window, e.g. "23:00-07:00"
+	Jitter       time.Duration `mapstructure:"jitter"`        // Random delay added to each sync
+	RunOnStart   bool          `mapstructure:"run_on_start"`  // Sync when the daemon starts
+	BackoffAfter int           `mapstructure:"backoff_after"` // Consecutive failures before backing off
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`   // Longest delay between failing syncs
+
+	// Classes mode: poll every FastInterval for Window after each booked
+	// class ends and every SlowInterval otherwise
+	FastInterval time.Duration `mapstructure:"fast_interval"`
+	SlowInterval time.Duration `mapstructure:"slow_interval"`
+	Window       time.Duration `mapstructure:"window"`
+	Refresh      time.Duration `mapstructure:"refresh"`   // How often booked classes are re-read
+	Timetable    []string      `mapstructure:"timetable"` // Classes when none are booked, e.g. "mon-fri 07:00-08:00"
+}
+
+// MQTTConfig holds settings for publishing to Home Assistant over MQTT
+type MQTTConfig struct {
+	Broker          string `mapstructure:"broker"` // e.g. tcp://homeassistant.local:1883; empty disables MQTT
+	Username        string `mapstructure:"username"`
+	Password        string `mapstructure:"password"`
+	ClientID        string `mapstructure:"client_id"`
+	TopicPrefix     string `mapstructure:"topic_prefix"`     // base topic for state and commands
+	DiscoveryPrefix string `mapstructure:"discovery_prefix"` // Home Assistant discovery prefix
+}
+
+// Enabled reports whether an MQTT broker is configured
+func (m MQTTConfig) Enabled() bool {
+	return m.Broker != ""
+}
+
+// NotifyConfig holds settings for sync notifications
+type NotifyConfig struct {
+	Events               []string         `mapstructure:"events"`                 // events to send (default: all)
+	AuthFailureThreshold int              `mapstructure:"auth_failure_threshold"` // consecutive auth errors before alerting
+	DedupeWindow         time.Duration    `mapstructure:"dedupe_window"`          // suppress identical messages for this long
+	StateFile            string           `mapstructure:"state_file"`             // sent messages and auth error streaks
+	Channels             []NotifierConfig `mapstructure:"channels"`
+}
+
+// NotifierConfig is a notification channel. Which fields apply depends on
+// its type: ntfy, telegram, smtp or webhook.
+type NotifierConfig struct {
+	Name     string            `mapstructure:"name"`
+	Type     string            `mapstructure:"type"`
+	URL      string            `mapstructure:"url"`      // server or API endpoint (ntfy, telegram, webhook)
+	Topic    string            `mapstructure:"topic"`    // ntfy topic
+	Token    string            `mapstructure:"token"`    // ntfy access token or Telegram bot token
+	ChatID   string            `mapstructure:"chat_id"`  // Telegram chat
+	Host     string            `mapstructure:"host"`     // SMTP server
+	Port     int               `mapstructure:"port"`     // SMTP port (default 587; 465 for implicit TLS)
+	Username string            `mapstructure:"username"` // ntfy or SMTP user
+	Password string            `mapstructure:"password"`
+	From     string            `mapstructure:"from"`     // email sender
+	To       []string          `mapstructure:"to"`       // email recipients
+	Secret   string            `mapstructure:"secret"`   // webhook HMAC-SHA256 signing key
+	Headers  map[string]string `mapstructure:"headers"`  // extra webhook request headers
+	Events   []string          `mapstructure:"events"`   // events for this channel (default: all)
+	Template string            `mapstructure:"template"` // message template (default: notify)
 }
 
 // StorageConfig holds storage paths
 type StorageConfig struct {
-	DataDir     string `mapstructure:"data_dir"`     // Where to store data files
-	TokensFile  string `mapstructure:"tokens_file"`  // OAuth tokens
-	HistoryFile string `mapstructure:"history_file"` // Sync history
-	TCXDir      string `mapstructure:"tcx_dir"`      // Generated TCX files
+	DataDir      string `mapstructure:"data_dir"`      // Where to store data files
+	TokensFile   string `mapstructure:"tokens_file"`   // OAuth tokens
+	HistoryFile  string `mapstructure:"history_file"`  // Sync history
+	TCXDir       string `mapstructure:"tcx_dir"`       // Generated TCX files
+	CacheFile    string `mapstructure:"cache_file"`    // Last fetched workouts
+	TemplatesDir string `mapstructure:"templates_dir"` // Template overrides (*.tmpl)
+	JobsFile     string `mapstructure:"jobs_file"`     // Recent webhook sync jobs
+	ScheduleFile string `mapstructure:"schedule_file"` // Daemon polling windows, shown by status
+
+	// Log of every sync run (runs command, GET /runs), pruned to the last
+	// RunHistory runs no older than RunMaxAge
+	RunsFile   string        `mapstructure:"runs_file"`
+	RunHistory int           `mapstructure:"run_history"`
+	RunMaxAge  time.Duration `mapstructure:"run_max_age"`
 }
 
 // SyncConfig holds sync preferences
@@ -55,6 +245,7 @@ type SyncConfig struct {
 	IncludeNoScore    bool          `mapstructure:"include_no_score"` // Sync workouts without scores
 	MarkAsCommute     bool          `mapstructure:"mark_as_commute"`
 	DefaultVisibility string        `mapstructure:"default_visibility"` // "everyone", "followers_only", "only_me"
+	Destinations      []string      `mapstructure:"destinations"`       // Platforms to upload to (e.g. "strava")
 }
 
 // DefaultConfig returns sensible defaults
@@ -70,12 +261,69 @@ func DefaultConfig() *Config {
 		},
 		Strava: StravaConfig{
 			RedirectURI: "http://localhost:8080/callback",
+			Update:      true,
+		},
+		Intervals: IntervalsConfig{
+			AthleteID: "0",
+			BaseURL:   "https://intervals.icu",
+			Update:    true,
+		},
+		Garmin: GarminConfig{
+			BaseURL:  "https://connectapi.garmin.com",
+			TokenURL: "https://diauth.garmin.com/di-oauth2-service/oauth/token",
+			Update:   true,
+		},
+		Sheet: SheetConfig{
+			File: filepath.Join(dataDir, "training_log.xlsx"),
+		},
+		MQTT: MQTTConfig{
+			ClientID:        "aimharder-sync",
+			TopicPrefix:     "aimharder_sync",
+			DiscoveryPrefix: "homeassistant",
+		},
+		Notify: NotifyConfig{
+			AuthFailureThreshold: 2,
+			DedupeWindow:         12 * time.Hour,
+			StateFile:            filepath.Join(dataDir, "notify_state.json"),
+		},
+		Server: ServerConfig{
+			JobHistory:      20,
+			SignatureWindow: 5 * time.Minute,
+			RateLimit:       60,
+			RateBurst:       10,
+		},
+		Schedule: ScheduleConfig{
+			Mode:         "classes",
+			Cron:         "@every 1m",
+			Days:         1,
+			RunOnStart:   true,
+			BackoffAfter: 3,
+			MaxBackoff:   time.Hour,
+			FastInterval: 5 * time.Minute,
+			SlowInterval: time.Hour,
+			Window:       2 * time.Hour,
+			Refresh:      3 * time.Hour,
+		},
+		Log: LogConfig{
+			Format: "auto",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "aimharder-sync",
 		},
 		Storage: StorageConfig{
-			DataDir:     dataDir,
-			TokensFile:  filepath.Join(dataDir, "tokens.json"),
-			HistoryFile: filepath.Join(dataDir, "sync_history.json"),
-			TCXDir:      filepath.Join(dataDir, "tcx"),
+			DataDir:      dataDir,
+			TokensFile:   filepath.Join(dataDir, "tokens.json"),
+			HistoryFile:  filepath.Join(dataDir, "sync_history.json"),
+			TCXDir:       filepath.Join(dataDir, "tcx"),
+			CacheFile:    filepath.Join(dataDir, "workouts_cache.json"),
+			TemplatesDir: filepath.Join(dataDir, "templates"),
+			JobsFile:     filepath.Join(dataDir, "jobs.json"),
+			ScheduleFile: filepath.Join(dataDir, "schedule.json"),
+			RunsFile:     filepath.Join(dataDir, "runs.jsonl"),
+			RunHistory:   200,
+			RunMaxAge:    90 * 24 * time.Hour,
 		},
 		Sync: SyncConfig{
 			DefaultDays:       30,
@@ -85,6 +333,7 @@ func DefaultConfig() *Config {
 			ActivityType:      "crossfit",
 			IncludeNoScore:    true,
 			DefaultVisibility: "followers_only",
+			Destinations:      []string{"strava"},
 		},
 	}
 }
@@ -101,10 +350,50 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("aimharder.box_name", cfg.Aimharder.BoxName)
 	v.SetDefault("aimharder.box_id", cfg.Aimharder.BoxID)
 	v.SetDefault("strava.redirect_uri", cfg.Strava.RedirectURI)
+	v.SetDefault("strava.update", cfg.Strava.Update)
+	v.SetDefault("intervals.athlete_id", cfg.Intervals.AthleteID)
+	v.SetDefault("intervals.base_url", cfg.Intervals.BaseURL)
+	v.SetDefault("intervals.update", cfg.Intervals.Update)
+	v.SetDefault("garmin.base_url", cfg.Garmin.BaseURL)
+	v.SetDefault("garmin.token_url", cfg.Garmin.TokenURL)
+	v.SetDefault("garmin.update", cfg.Garmin.Update)
+	v.SetDefault("sheet.file", cfg.Sheet.File)
+	v.SetDefault("mqtt.client_id", cfg.MQTT.ClientID)
+	v.SetDefault("mqtt.topic_prefix", cfg.MQTT.TopicPrefix)
+	v.SetDefault("mqtt.discovery_prefix", cfg.MQTT.DiscoveryPrefix)
+	v.SetDefault("notifications.auth_failure_threshold", cfg.Notify.AuthFailureThreshold)
+	v.SetDefault("notifications.dedupe_window", cfg.Notify.DedupeWindow)
+	v.SetDefault("notifications.state_file", cfg.Notify.StateFile)
 	v.SetDefault("storage.data_dir", cfg.Storage.DataDir)
 	v.SetDefault("storage.tokens_file", cfg.Storage.TokensFile)
 	v.SetDefault("storage.history_file", cfg.Storage.HistoryFile)
 	v.SetDefault("storage.tcx_dir", cfg.Storage.TCXDir)
+	v.SetDefault("storage.cache_file", cfg.Storage.CacheFile)
+	v.SetDefault("storage.templates_dir", cfg.Storage.TemplatesDir)
+	v.SetDefault("storage.jobs_file", cfg.Storage.JobsFile)
+	v.SetDefault("storage.schedule_file", cfg.Storage.ScheduleFile)
+	v.SetDefault("storage.runs_file", cfg.Storage.RunsFile)
+	v.SetDefault("storage.run_history", cfg.Storage.RunHistory)
+	v.SetDefault("storage.run_max_age", cfg.Storage.RunMaxAge)
+	v.SetDefault("server.job_history", cfg.Server.JobHistory)
+	v.SetDefault("log.format", cfg.Log.Format)
+	v.SetDefault("log.level", cfg.Log.Level)
+	v.SetDefault("tracing.enabled", cfg.Tracing.Enabled)
+	v.SetDefault("tracing.endpoint", cfg.Tracing.Endpoint)
+	v.SetDefault("tracing.service_name", cfg.Tracing.ServiceName)
+	v.SetDefault("server.signature_window", cfg.Server.SignatureWindow)
+	v.SetDefault("server.rate_limit", cfg.Server.RateLimit)
+	v.SetDefault("server.rate_burst", cfg.Server.RateBurst)
+	v.SetDefault("schedule.mode", cfg.Schedule.Mode)
+	v.SetDefault("schedule.cron", cfg.Schedule.Cron)
+	v.SetDefault("schedule.days", cfg.Schedule.Days)
+	v.SetDefault("schedule.run_on_start", cfg.Schedule.RunOnStart)
+	v.SetDefault("schedule.backoff_after", cfg.Schedule.BackoffAfter)
+	v.SetDefault("schedule.max_backoff", cfg.Schedule.MaxBackoff)
+	v.SetDefault("schedule.fast_interval", cfg.Schedule.FastInterval)
+	v.SetDefault("schedule.slow_interval", cfg.Schedule.SlowInterval)
+	v.SetDefault("schedule.window", cfg.Schedule.Window)
+	v.SetDefault("schedule.refresh", cfg.Schedule.Refresh)
 	v.SetDefault("sync.default_days", cfg.Sync.DefaultDays)
 	v.SetDefault("sync.default_duration", cfg.Sync.DefaultDuration)
 	v.SetDefault("sync.retry_attempts", cfg.Sync.RetryAttempts)
@@ -112,6 +401,7 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("sync.activity_type", cfg.Sync.ActivityType)
 	v.SetDefault("sync.include_no_score", cfg.Sync.IncludeNoScore)
 	v.SetDefault("sync.default_visibility", cfg.Sync.DefaultVisibility)
+	v.SetDefault("sync.destinations", cfg.Sync.Destinations)
 
 	// Environment variables (prefixed with AIMHARDER_)
 	v.SetEnvPrefix("AIMHARDER")
@@ -128,11 +418,58 @@ func Load(configPath string) (*Config, error) {
 	v.BindEnv("strava.client_secret", "STRAVA_CLIENT_SECRET")
 	v.BindEnv("strava.access_token", "STRAVA_ACCESS_TOKEN")
 	v.BindEnv("strava.refresh_token", "STRAVA_REFRESH_TOKEN")
+	v.BindEnv("strava.redirect_uri", "STRAVA_REDIRECT_URI")
+	v.BindEnv("strava.update", "STRAVA_UPDATE")
+	v.BindEnv("intervals.api_key", "INTERVALS_API_KEY")
+	v.BindEnv("intervals.athlete_id", "INTERVALS_ATHLETE_ID")
+	v.BindEnv("intervals.base_url", "INTERVALS_BASE_URL")
+	v.BindEnv("intervals.update", "INTERVALS_UPDATE")
+	v.BindEnv("garmin.access_token", "GARMIN_ACCESS_TOKEN")
+	v.BindEnv("garmin.refresh_token", "GARMIN_REFRESH_TOKEN")
+	v.BindEnv("garmin.client_id", "GARMIN_CLIENT_ID")
+	v.BindEnv("garmin.base_url", "GARMIN_BASE_URL")
+	v.BindEnv("garmin.token_url", "GARMIN_TOKEN_URL")
+	v.BindEnv("garmin.update", "GARMIN_UPDATE")
+	v.BindEnv("sheet.file", "AIMHARDER_SHEET_FILE")
+	v.BindEnv("sheet.athlete", "AIMHARDER_SHEET_ATHLETE")
+	v.BindEnv("mqtt.broker", "MQTT_BROKER")
+	v.BindEnv("mqtt.username", "MQTT_USERNAME")
+	v.BindEnv("mqtt.password", "MQTT_PASSWORD")
+	v.BindEnv("mqtt.topic_prefix", "MQTT_TOPIC_PREFIX")
+	v.BindEnv("mqtt.discovery_prefix", "MQTT_DISCOVERY_PREFIX")
 	v.BindEnv("storage.data_dir", "AIMHARDER_STORAGE_DATA_DIR")
 	v.BindEnv("storage.tokens_file", "AIMHARDER_STORAGE_TOKENS_FILE")
 	v.BindEnv("storage.history_file", "AIMHARDER_STORAGE_HISTORY_FILE")
 	v.BindEnv("storage.tcx_dir", "AIMHARDER_STORAGE_TCX_DIR")
+	v.BindEnv("storage.cache_file", "AIMHARDER_STORAGE_CACHE_FILE")
+	v.BindEnv("storage.templates_dir", "AIMHARDER_STORAGE_TEMPLATES_DIR")
+	v.BindEnv("storage.jobs_file", "AIMHARDER_STORAGE_JOBS_FILE")
+	v.BindEnv("storage.schedule_file", "AIMHARDER_STORAGE_SCHEDULE_FILE")
+	v.BindEnv("storage.runs_file", "AIMHARDER_STORAGE_RUNS_FILE")
+	v.BindEnv("server.token", "WEBHOOK_TOKEN")
+	v.BindEnv("server.query_token", "WEBHOOK_QUERY_TOKEN")
+	v.BindEnv("server.rate_limit", "WEBHOOK_RATE_LIMIT")
+	v.BindEnv("server.tls_cert", "WEBHOOK_TLS_CERT")
+	v.BindEnv("server.tls_key", "WEBHOOK_TLS_KEY")
+	v.BindEnv("server.ingress_port", "WEBHOOK_INGRESS_PORT")
+	v.BindEnv("remote.url", "AIMHARDER_REMOTE_URL")
+	v.BindEnv("remote.token", "AIMHARDER_REMOTE_TOKEN")
+	v.BindEnv("remote.key", "AIMHARDER_REMOTE_KEY")
+	v.BindEnv("log.format", "AIMHARDER_LOG_FORMAT")
+	v.BindEnv("log.level", "AIMHARDER_LOG_LEVEL")
+	v.BindEnv("tracing.enabled", "AIMHARDER_TRACING_ENABLED")
+	v.BindEnv("tracing.endpoint", "AIMHARDER_TRACING_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT")
+	v.BindEnv("tracing.service_name", "OTEL_SERVICE_NAME")
+	v.BindEnv("schedule.mode", "AIMHARDER_SCHEDULE_MODE")
+	v.BindEnv("schedule.cron", "AIMHARDER_SCHEDULE")
+	v.BindEnv("schedule.days", "SYNC_DAYS")
+	v.BindEnv("schedule.quiet_hours", "AIMHARDER_QUIET_HOURS")
+	v.BindEnv("schedule.jitter", "AIMHARDER_SCHEDULE_JITTER")
+	v.BindEnv("schedule.fast_interval", "AIMHARDER_POLL_FAST")
+	v.BindEnv("schedule.slow_interval", "AIMHARDER_POLL_SLOW")
+	v.BindEnv("schedule.window", "AIMHARDER_POLL_WINDOW")
 	v.BindEnv("sync.default_duration", "AIMHARDER_DEFAULT_DURATION")
+	v.BindEnv("sync.destinations", "AIMHARDER_SYNC_DESTINATIONS")
 
 	// Try to read config file if it exists
 	if configPath != "" {
@@ -184,6 +521,14 @@ func (c *Config) ValidateStrava() error {
 	return nil
 }
 
+// ValidateIntervals checks intervals.icu config
+func (c *Config) ValidateIntervals() error {
+	if c.Intervals.APIKey == "" {
+		return fmt.Errorf("intervals.api_key is required (set INTERVALS_API_KEY)")
+	}
+	return nil
+}
+
 // EnsureDirectories creates necessary directories
 func (c *Config) EnsureDirectories() error {
 	dirs := []string{
@@ -0,0 +1,34 @@
+// Package dashboard is the web UI served by the webhook server at /ui/: a
+// static single-page app that shows recent workouts, sync jobs and the
+// Strava connection, and talks to the webhook API for everything else.
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// contentSecurityPolicy only allows the dashboard's own files; workouts are
+// rendered with the escaping html template, so no inline scripts are needed
+const contentSecurityPolicy = "default-src 'self'; img-src 'self' data: https:; frame-ancestors 'self'"
+
+// Handler serves the dashboard files. Mount it with the /ui prefix
+// stripped; API requests are made relative to the parent path so it also
+// works behind Home Assistant ingress.
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.FileServerFS(files)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,328 @@
+'use strict';
+
+// API paths are relative to the parent of /ui/ so the dashboard also works
+// behind Home Assistant ingress, which serves it under a session prefix
+const api = (path) => new URL('../' + path, location.href);
+
+const tokenKey = 'aimharder-sync-token';
+const refreshInterval = 30000;
+
+const $ = (id) => document.getElementById(id);
+
+// el creates an element; strings become text nodes, never HTML
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs)) {
+    if (key.startsWith('on')) {
+      node.addEventListener(key.slice(2), value);
+    } else if (value !== undefined && value !== null && value !== false) {
+      node.setAttribute(key, value === true ? '' : value);
+    }
+  }
+  node.append(...children.filter((c) => c !== undefined && c !== null));
+  return node;
+}
+
+class AuthError extends Error {}
+
+async function request(path, options = {}) {
+  const headers = new Headers(options.headers);
+  const token = localStorage.getItem(tokenKey);
+  if (token) {
+    headers.set('Authorization', 'Bearer ' + token);
+  }
+  if (options.body) {
+    headers.set('Content-Type', 'application/json');
+  }
+  const res = await fetch(api(path), { ...options, headers });
+  if (res.status === 401) {
+    showLogin();
+    throw new AuthError('Enter an API token to continue');
+  }
+  const body = (res.headers.get('Content-Type') || '').includes('application/json') ? await res.json() : null;
+  if (!res.ok) {
+    throw new Error((body && body.message) || `${res.status} ${res.statusText}`);
+  }
+  return body;
+}
+
+function showError(err) {
+  $('error').textContent = err ? '⚠️ ' + err.message : '';
+  $('error').hidden = !err || err instanceof AuthError;
+}
+
+function showLogin() {
+  $('login').hidden = false;
+  $('token').focus();
+}
+
+const formatDate = (value) => new Date(value).toLocaleDateString(undefined, { weekday: 'short', day: 'numeric', month: 'short' });
+const formatTime = (value) => new Date(value).toLocaleTimeString(undefined, { hour: '2-digit', minute: '2-digit', second: '2-digit' });
+const formatDateTime = (value) => new Date(value).toLocaleString(undefined, { dateStyle: 'medium', timeStyle: 'short' });
+
+// Status and Strava
+
+async function loadStatus() {
+  const status = await request('status');
+  const result = status.result;
+  if (!result) {
+    $('last-sync').textContent = 'No sync since the server started';
+  } else {
+    $('last-sync').replaceChildren(
+      el('span', { class: result.success ? 'ok' : 'fail' }, result.success ? '✅ ' : '❌ '),
+      `${formatDateTime(result.completed_at)} · ${result.message}`,
+    );
+  }
+  const policy = status.schedule;
+  $('schedule').textContent = policy
+    ? `Polling ${policy.polling} (every ${policy.interval}) until ${formatDateTime(policy.until)}`
+    : '';
+}
+
+async function loadStrava() {
+  const strava = await request('auth/strava');
+  const button = $('strava-authorize');
+  button.hidden = !strava.configured;
+  if (!strava.configured) {
+    $('strava-state').textContent = 'Not configured (set STRAVA_CLIENT_ID and STRAVA_CLIENT_SECRET)';
+  } else if (!strava.authenticated) {
+    $('strava-state').replaceChildren(el('span', { class: 'fail' }, '❌ Not connected'));
+    button.textContent = 'Authorize';
+  } else {
+    const parts = [el('span', { class: 'ok' }, '✅ Connected')];
+    if (strava.athlete_id) {
+      parts.push(` · athlete ${strava.athlete_id}`);
+    }
+    if (strava.expires_at) {
+      parts.push(el('br'), el('span', { class: 'hint' }, `Access token valid until ${formatDateTime(strava.expires_at)}${strava.can_refresh ? ', refreshed automatically' : ''}`));
+    }
+    $('strava-state').replaceChildren(...parts);
+    button.textContent = 'Re-authorize';
+  }
+}
+
+async function authorizeStrava() {
+  const { authorize_url: url } = await request('auth/strava', { method: 'POST' });
+  location.assign(url);
+}
+
+// Workouts
+
+async function loadWorkouts() {
+  const data = await request('workouts?days=' + $('days').value);
+  const rows = data.workouts.map(workoutRow);
+  if (rows.length === 0) {
+    rows.push(el('tr', {}, el('td', { colspan: 4, class: 'hint' }, `No workouts fetched between ${data.start} and ${data.end}. Run a sync to fetch them.`)));
+  }
+  $('workouts').tBodies[0].replaceChildren(...rows);
+}
+
+function workoutRow(workout) {
+  const badges = Object.entries(workout.platforms).map(([platform, state]) =>
+    el('span', {
+      class: 'badge ' + state.status,
+      title: state.error || (state.synced_at ? `Synced ${formatDateTime(state.synced_at)}` : state.status),
+    }, `${platform}: ${state.status}`));
+
+  const row = el('tr', {},
+    el('td', { class: 'date' }, formatDate(workout.date), workout.class_time ? el('br') : null, workout.class_time || null),
+    el('td', { class: 'name' },
+      el('button', { onclick: () => toggleDetail(row, workout.id), title: 'Show the workout' }, workout.name || workout.id),
+      el('small', {}, workout.type)),
+    el('td', {}, ...badges),
+    el('td', {}, el('button', { class: 'small', onclick: (e) => resync(e.target, workout.id), title: 'Upload this workout again' }, 'Resync')));
+  return row;
+}
+
+async function toggleDetail(row, id) {
+  const next = row.nextElementSibling;
+  if (next && next.dataset.detail === id) {
+    next.remove();
+    return;
+  }
+  const cell = el('td', { colspan: 4, class: 'detail' }, 'Loading…');
+  row.after(el('tr', { 'data-detail': id }, cell));
+  try {
+    const detail = await request('workouts/' + encodeURIComponent(id));
+    // Rendered server-side by the html template, which escapes all values
+    cell.innerHTML = detail.html;
+  } catch (err) {
+    cell.textContent = '⚠️ ' + err.message;
+  }
+}
+
+async function resync(button, id) {
+  button.disabled = true;
+  try {
+    const job = await request(`workouts/${encodeURIComponent(id)}/resync`, { method: 'POST' });
+    await followJob(job.job_id);
+  } catch (err) {
+    showError(err);
+  } finally {
+    button.disabled = false;
+  }
+}
+
+// Jobs
+
+async function startSync(dryRun) {
+  for (const id of ['sync', 'dry-run']) $(id).disabled = true;
+  try {
+    const job = await request('sync', { method: 'POST', body: JSON.stringify({ dry_run: dryRun }) });
+    await followJob(job.job_id);
+  } catch (err) {
+    showError(err);
+  } finally {
+    for (const id of ['sync', 'dry-run']) $(id).disabled = false;
+  }
+}
+
+async function loadJobs(select) {
+  const { jobs } = await request('jobs');
+  const current = select || $('jobs').value;
+  $('jobs').replaceChildren(...jobs.map((job) =>
+    el('option', { value: job.id }, `${formatDateTime(job.created_at)} · ${job.trigger} · ${job.state}`)));
+  if (jobs.length === 0) {
+    $('job-summary').textContent = 'No sync jobs yet';
+    return;
+  }
+  const id = jobs.some((job) => job.id === current) ? current : jobs[0].id;
+  $('jobs').value = id;
+  if (id !== shownJob) {
+    showJob(id).catch(showError);
+  }
+}
+
+let shownJob = null;
+let stream = null;
+
+// showJob replays a job's events into the log, following it while it runs
+async function showJob(id) {
+  shownJob = id;
+  if (stream) {
+    stream.abort();
+  }
+  stream = new AbortController();
+  const signal = stream.signal;
+
+  const job = await request('jobs/' + id);
+  $('job-summary').textContent = describeJob(job);
+  $('job-log').replaceChildren();
+  try {
+    await readEvents('jobs/' + id + '/events', signal, (event) => {
+      $('job-log').append(logLine(event));
+      $('job-log').scrollTop = $('job-log').scrollHeight;
+    });
+  } catch (err) {
+    if (signal.aborted) {
+      return;
+    }
+    throw err;
+  }
+
+  const finished = await request('jobs/' + id);
+  if (shownJob === id) {
+    $('job-summary').textContent = describeJob(finished);
+    for (const preview of (finished.result && finished.result.previews) || []) {
+      $('job-log').append(el('li', {}, `🔍 ${preview.platform}: ${preview.action} "${preview.activity ? preview.activity.name : preview.workout_id}"`));
+    }
+  }
+}
+
+async function followJob(id) {
+  await loadJobs(id);
+  refresh();
+}
+
+function describeJob(job) {
+  const what = job.workout_ids && job.workout_ids.length
+    ? `workout ${job.workout_ids.join(', ')}`
+    : job.start ? `${job.start} to ${job.end || 'today'}` : `last ${job.days} days`;
+  return `${job.dry_run ? 'Dry run' : 'Sync'} of ${what} · ${job.state}${job.message ? ' · ' + job.message : ''}`;
+}
+
+function logLine(event) {
+  const workout = event.workout ? `${event.workout.date} ${event.workout.name}` : '';
+  let text;
+  switch (event.type) {
+    case 'fetched':
+      text = `📥 Fetched ${workout}`;
+      break;
+    case 'generated':
+      text = event.error ? `❌ ${workout}: ${event.error}` : `📝 Generated ${event.workout.file}`;
+      break;
+    case 'synced':
+      text = `${event.error ? '❌' : '✅'} ${workout} → ${event.platform}: ${event.outcome}${event.error ? ' (' + event.error + ')' : ''}`;
+      break;
+    case 'state':
+      text = `● ${event.message || event.state}`;
+      break;
+    default:
+      text = event.message;
+  }
+  return el('li', { class: event.error ? 'fail' : undefined }, el('time', {}, formatTime(event.time)), text);
+}
+
+// readEvents reads a Server-Sent Events stream with fetch rather than
+// EventSource, which can't send the Authorization header
+async function readEvents(path, signal, onEvent) {
+  const headers = {};
+  const token = localStorage.getItem(tokenKey);
+  if (token) {
+    headers.Authorization = 'Bearer ' + token;
+  }
+  const res = await fetch(api(path), { headers, signal });
+  if (!res.ok) {
+    throw new Error(`${res.status} ${res.statusText}`);
+  }
+  const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+  let buffer = '';
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) {
+      return;
+    }
+    buffer += value;
+    let end;
+    while ((end = buffer.indexOf('\n\n')) >= 0) {
+      const block = buffer.slice(0, end);
+      buffer = buffer.slice(end + 2);
+      const data = block.split('\n').filter((line) => line.startsWith('data: ')).map((line) => line.slice(6)).join('\n');
+      if (data) {
+        onEvent(JSON.parse(data));
+      }
+    }
+  }
+}
+
+// Refreshing
+
+async function refresh() {
+  try {
+    await Promise.all([loadStatus(), loadStrava(), loadWorkouts(), loadJobs()]);
+    showError(null);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+$('login').addEventListener('submit', (e) => {
+  e.preventDefault();
+  localStorage.setItem(tokenKey, $('token').value.trim());
+  $('token').value = '';
+  $('login').hidden = true;
+  refresh();
+});
+$('change-token').addEventListener('click', showLogin);
+$('sync').addEventListener('click', () => startSync(false));
+$('dry-run').addEventListener('click', () => startSync(true));
+$('strava-authorize').addEventListener('click', () => authorizeStrava().catch(showError));
+$('days').addEventListener('change', () => loadWorkouts().catch(showError));
+$('jobs').addEventListener('change', (e) => showJob(e.target.value).catch(showError));
+
+refresh();
+setInterval(() => {
+  if (!document.hidden) {
+    refresh();
+  }
+}, refreshInterval);
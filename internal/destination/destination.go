@@ -0,0 +1,186 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Destination is a platform workouts are uploaded to
+type Destination interface {
+	// Name returns the platform name used in sync history (e.g. "strava")
+	Name() string
+
+	// Authenticate makes sure the destination has valid credentials
+	Authenticate(ctx context.Context) error
+
+	// ListExisting returns the activities already on the platform between
+	// start and end, used to avoid uploading duplicates
+	ListExisting(ctx context.Context, start, end time.Time) ([]Activity, error)
+
+	// Upload uploads the activity file generated for a workout
+	Upload(ctx context.Context, workout *models.Workout, file string) (*UploadResult, error)
+
+	// Update changes the name and description of an uploaded activity
+	Update(ctx context.Context, externalID string, workout *models.Workout) error
+
+	// Delete removes an uploaded activity
+	Delete(ctx context.Context, externalID string) error
+
+	// Preview describes what Upload would create, without uploading
+	Preview(workout *models.Workout, file string) *Preview
+}
+
+// Activity is an activity that already exists on a platform
+type Activity struct {
+	ID         string
+	ExternalID string // the workout ID the activity was uploaded with, if any
+	Name       string
+	StartTime  time.Time
+}
+
+// UploadResult is the outcome of a successful upload
+type UploadResult struct {
+	ID        string // platform activity ID
+	UploadID  string // platform upload ID, for platforms that process uploads asynchronously
+	Duplicate bool   // the platform already had this activity
+}
+
+// Preview describes an activity that would be uploaded
+type Preview struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	StartDate   string `json:"start_date"`
+	Description string `json:"description"`
+	ExternalID  string `json:"external_id"`
+	DataType    string `json:"data_type"`
+	File        string `json:"file,omitempty"`
+}
+
+// Filter is implemented by destinations that only accept some workouts
+type Filter interface {
+	Accepts(workout *models.Workout) bool
+}
+
+// ErrNotSupported is returned by destinations for operations their
+// platform API does not offer
+var ErrNotSupported = errors.New("operation not supported")
+
+// ErrUpdateDisabled is returned by Update when the destination is
+// configured not to overwrite activities (e.g. strava.update: false)
+var ErrUpdateDisabled = errors.New("updates are disabled")
+
+// ErrAuth is wrapped by Sync errors when a destination fails to
+// authenticate (e.g. an expired or revoked refresh token)
+var ErrAuth = errors.New("authentication failed")
+
+// Factory creates a destination from config
+type Factory func(cfg *config.Config) (Destination, error)
+
+// KindFactory creates one of several configured destinations of the same
+// kind, selected as "<kind>:<instance>" (e.g. "webhook:n8n")
+type KindFactory func(cfg *config.Config, instance string) (Destination, error)
+
+var (
+	registry = map[string]Factory{}
+	kinds    = map[string]KindFactory{}
+)
+
+// Register makes a destination available under the given name
+func Register(name string, factory Factory) {
+	registry[strings.ToLower(name)] = factory
+}
+
+// RegisterKind makes configured destinations available as "<kind>:<instance>"
+func RegisterKind(kind string, factory KindFactory) {
+	kinds[strings.ToLower(kind)] = factory
+}
+
+// New creates the destination registered under name
+func New(name string, cfg *config.Config) (Destination, error) {
+	name = strings.ToLower(name)
+	if factory, ok := registry[name]; ok {
+		return factory(cfg)
+	}
+	if kind, instance, ok := strings.Cut(name, ":"); ok && instance != "" {
+		if factory, ok := kinds[kind]; ok {
+			return factory(cfg, instance)
+		}
+	}
+	return nil, fmt.Errorf("unknown destination %q (available: %s)", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names of all registered destinations, with kinds shown
+// as "<kind>:<name>"
+func Names() []string {
+	names := make([]string, 0, len(registry)+len(kinds))
+	for name := range registry {
+		names = append(names, name)
+	}
+	for kind := range kinds {
+		names = append(names, kind+":<name>")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// known reports whether name refers to a registered destination or kind
+func known(name string) bool {
+	if _, ok := registry[name]; ok {
+		return true
+	}
+	kind, instance, ok := strings.Cut(name, ":")
+	if _, registered := kinds[kind]; ok && registered && instance != "" {
+		return true
+	}
+	return false
+}
+
+// ParseList splits a comma-separated list of destination names, dropping
+// blanks and duplicates and checking that each one is registered
+func ParseList(list []string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, item := range list {
+		for _, name := range strings.Split(item, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || seen[name] {
+				continue
+			}
+			if !known(name) {
+				return nil, fmt.Errorf("unknown destination %q (available: %s)", name, strings.Join(Names(), ", "))
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no destinations selected (available: %s)", strings.Join(Names(), ", "))
+	}
+	return names, nil
+}
+
+// FindExisting returns the existing activity that matches a workout, by
+// external ID or by falling on the same day
+func FindExisting(existing []Activity, workout *models.Workout) *Activity {
+	for i := range existing {
+		if existing[i].ExternalID != "" && existing[i].ExternalID == workout.ID {
+			return &existing[i]
+		}
+	}
+
+	day := workout.Date.Format("2006-01-02")
+	for i := range existing {
+		if existing[i].StartTime.Format("2006-01-02") == day {
+			return &existing[i]
+		}
+	}
+
+	return nil
+}
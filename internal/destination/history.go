@@ -0,0 +1,94 @@
+package destination
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Record appends a sync attempt for a workout on a platform to history
+func Record(history map[string][]models.SyncStatus, workoutID, platform, externalID string, success bool, errorMsg string) {
+	history[workoutID] = append(history[workoutID], models.SyncStatus{
+		WorkoutID:    workoutID,
+		Platform:     platform,
+		ExternalID:   externalID,
+		SyncedAt:     time.Now(),
+		Success:      success,
+		ErrorMessage: errorMsg,
+	})
+}
+
+// RecordUpload records a successful upload, keeping the platform's upload ID
+func RecordUpload(history map[string][]models.SyncStatus, workoutID, platform string, result *UploadResult, note string) {
+	Record(history, workoutID, platform, result.ID, true, note)
+	statuses := history[workoutID]
+	statuses[len(statuses)-1].UploadID = result.UploadID
+}
+
+// LastSuccess returns the most recent successful sync of a workout to a
+// platform, or nil if it was never synced there
+func LastSuccess(history map[string][]models.SyncStatus, workoutID, platform string) *models.SyncStatus {
+	statuses := history[workoutID]
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].Platform == platform && statuses[i].Success {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// LastAttempt returns the most recent sync attempt of a workout to a
+// platform, successful or not
+func LastAttempt(history map[string][]models.SyncStatus, workoutID, platform string) *models.SyncStatus {
+	statuses := history[workoutID]
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].Platform == platform {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// Forget removes a workout's sync attempts on a platform from history, so
+// the next sync treats it as new, and returns how many were removed
+func Forget(history map[string][]models.SyncStatus, workoutID, platform string) int {
+	var kept []models.SyncStatus
+	for _, status := range history[workoutID] {
+		if status.Platform != platform {
+			kept = append(kept, status)
+		}
+	}
+	removed := len(history[workoutID]) - len(kept)
+	if len(kept) == 0 {
+		delete(history, workoutID)
+	} else {
+		history[workoutID] = kept
+	}
+	return removed
+}
+
+// Checksum fingerprints the workout content sent to destinations, so
+// changes (e.g. a result logged later) can be pushed as updates
+func Checksum(workout *models.Workout) string {
+	w := *workout
+	w.Synced = false
+	w.SyncedAt = nil
+	w.ExternalID = ""
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// setChecksum stores the checksum on the latest history entry of a workout
+func setChecksum(history map[string][]models.SyncStatus, workoutID, checksum string) {
+	if statuses := history[workoutID]; len(statuses) > 0 {
+		statuses[len(statuses)-1].Checksum = checksum
+	}
+}
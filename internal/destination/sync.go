@@ -0,0 +1,248 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/tracing"
+)
+
+// SyncOptions configures Sync
+type SyncOptions struct {
+	// Force re-uploads workouts that history already marks as synced
+	Force bool
+
+	// Logger receives progress and a record per decision (default:
+	// slog.Default())
+	Logger *slog.Logger
+
+	// Delay between uploads to stay under platform rate limits
+	Delay time.Duration
+
+	// Progress, if set, is called with what was done with each workout
+	Progress func(workout *models.Workout, d Decision)
+}
+
+// Outcomes of a Decision
+const (
+	OutcomeUploaded = "uploaded"
+	OutcomeUpdated  = "updated"
+	OutcomeSkipped  = "skipped"
+	OutcomeFailed   = "failed"
+)
+
+// Reasons of a Decision
+const (
+	ReasonNew               = "new"                  // uploaded for the first time
+	ReasonForced            = "forced"               // uploaded again with Force
+	ReasonChanged           = "changed"              // updated since it was uploaded
+	ReasonAlreadySynced     = "already_synced"       // history marks it as synced
+	ReasonAlreadyExists     = "already_exists"       // a matching activity was found on the platform
+	ReasonDuplicate         = "duplicate"            // the platform rejected it as a duplicate
+	ReasonFiltered          = "filtered"             // the destination doesn't accept it
+	ReasonUpdateUnsupported = "update_not_supported" // changed, but the platform can't update
+	ReasonUpdateDisabled    = "update_disabled"      // changed, but updates are turned off
+	ReasonUploadFailed      = "upload_failed"
+	ReasonUpdateFailed      = "update_failed"
+)
+
+// Decision is what Sync did with a workout, and why
+type Decision struct {
+	Outcome    string
+	Reason     string
+	ExternalID string // the platform's activity, when known
+	Err        error
+}
+
+// outcomeIcons mark decisions in the pretty log format
+var outcomeIcons = map[string]string{
+	OutcomeUploaded: "✅",
+	OutcomeUpdated:  "✏️ ",
+	OutcomeSkipped:  "⏭️ ",
+	OutcomeFailed:   "❌",
+}
+
+// Summary counts the outcome of a Sync
+type Summary struct {
+	Platform string `json:"platform"`
+	Uploaded int    `json:"uploaded"`
+	Updated  int    `json:"updated"`
+	Skipped  int    `json:"skipped"`
+	Errors   int    `json:"errors"`
+}
+
+// Sync uploads workouts to a destination. files[i] is the activity file
+// generated for workouts[i]. Workouts already synced to this destination
+// (according to history, or found on the platform) are skipped, or updated
+// if they changed since they were uploaded. Every attempt is recorded in
+// history under the destination's name. The sync is traced as an "upload"
+// span with a child span per workout.
+func Sync(ctx context.Context, dest Destination, workouts []models.Workout, files []string, history map[string][]models.SyncStatus, opts SyncOptions) (summary *Summary, err error) {
+	name := dest.Name()
+	summary = &Summary{Platform: name}
+	log := logging.Or(opts.Logger).With("destination", name)
+
+	ctx, span := tracing.Start(ctx, "upload", tracing.String("destination", name), tracing.Int("workouts", len(workouts)))
+	defer func() {
+		span.SetAttributes(tracing.Int("uploaded", summary.Uploaded), tracing.Int("updated", summary.Updated),
+			tracing.Int("skipped", summary.Skipped), tracing.Int("errors", summary.Errors))
+		span.RecordError(err)
+		span.End()
+	}()
+
+	var workoutSpan *tracing.Span
+	progress := func(workout *models.Workout, outcome, reason, externalID string, err error) {
+		workoutSpan.SetAttributes(tracing.String("outcome", outcome), tracing.String("reason", reason))
+		if externalID != "" {
+			workoutSpan.SetAttributes(tracing.String("activity.id", externalID))
+		}
+		workoutSpan.RecordError(err)
+		workoutSpan.End()
+
+		args := []any{logging.Icon(outcomeIcons[outcome]), "workout", workout.ID,
+			"date", workout.Date.Format("2006-01-02"), "name", workout.Name, "reason", reason}
+		if externalID != "" {
+			args = append(args, "activity", externalID)
+		}
+		if err != nil {
+			log.Error("Workout "+outcome, append(args, "error", err)...)
+		} else {
+			log.Info("Workout "+outcome, args...)
+		}
+		if opts.Progress != nil {
+			opts.Progress(workout, Decision{Outcome: outcome, Reason: reason, ExternalID: externalID, Err: err})
+		}
+	}
+
+	if err := dest.Authenticate(ctx); err != nil {
+		return summary, fmt.Errorf("%s %w: %w", name, ErrAuth, err)
+	}
+
+	// Find the date range of workouts we're syncing
+	var minDate, maxDate time.Time
+	for _, w := range workouts {
+		if minDate.IsZero() || w.Date.Before(minDate) {
+			minDate = w.Date
+		}
+		if maxDate.IsZero() || w.Date.After(maxDate) {
+			maxDate = w.Date
+		}
+	}
+
+	// Fetch existing activities in this date range (with a day of buffer)
+	log.Info("Checking for existing activities", logging.Icon("🔍"))
+	listCtx, listSpan := tracing.Start(ctx, "list_existing")
+	existing, err := dest.ListExisting(listCtx, minDate.AddDate(0, 0, -1), maxDate.AddDate(0, 0, 1))
+	listSpan.SetAttributes(tracing.Int("activities", len(existing)))
+	listSpan.RecordError(err)
+	listSpan.End()
+	if err != nil {
+		log.Warn("Could not fetch existing activities; proceeding anyway (duplicates may be rejected)", "error", err)
+		existing = nil
+	} else {
+		log.Info("Found existing activities in date range", logging.Icon("🔍"), "count", len(existing))
+	}
+
+	log.Info("Uploading", logging.Icon("📤"), "workouts", min(len(workouts), len(files)))
+
+	for i := range workouts {
+		if i >= len(files) {
+			break
+		}
+		workout := &workouts[i]
+
+		select {
+		case <-ctx.Done():
+			return summary, ctx.Err()
+		default:
+		}
+
+		var workoutCtx context.Context
+		workoutCtx, workoutSpan = tracing.Start(ctx, "upload_workout", tracing.String("workout.id", workout.ID),
+			tracing.String("workout.date", workout.Date.Format("2006-01-02")))
+
+		if filter, ok := dest.(Filter); ok && !filter.Accepts(workout) {
+			summary.Skipped++
+			progress(workout, OutcomeSkipped, ReasonFiltered, "", nil)
+			continue
+		}
+
+		checksum := Checksum(workout)
+
+		if !opts.Force {
+			if last := LastSuccess(history, workout.ID, name); last != nil {
+				// Only activities we uploaded carry a checksum; those are
+				// updated when the workout changed since
+				if last.Checksum == "" || last.Checksum == checksum || last.ExternalID == "" {
+					summary.Skipped++
+					progress(workout, OutcomeSkipped, ReasonAlreadySynced, last.ExternalID, nil)
+					continue
+				}
+
+				externalID := last.ExternalID
+				if err := dest.Update(workoutCtx, externalID, workout); err != nil {
+					if errors.Is(err, ErrNotSupported) || errors.Is(err, ErrUpdateDisabled) {
+						reason := ReasonUpdateUnsupported
+						if errors.Is(err, ErrUpdateDisabled) {
+							reason = ReasonUpdateDisabled
+						}
+						summary.Skipped++
+						progress(workout, OutcomeSkipped, reason, externalID, nil)
+						continue
+					}
+					Record(history, workout.ID, name, externalID, false, err.Error())
+					summary.Errors++
+					progress(workout, OutcomeFailed, ReasonUpdateFailed, externalID, err)
+					continue
+				}
+				Record(history, workout.ID, name, externalID, true, "updated")
+				setChecksum(history, workout.ID, checksum)
+				summary.Updated++
+				progress(workout, OutcomeUpdated, ReasonChanged, externalID, nil)
+				continue
+			}
+			if match := FindExisting(existing, workout); match != nil {
+				Record(history, workout.ID, name, match.ID, true, ReasonAlreadyExists)
+				summary.Skipped++
+				progress(workout, OutcomeSkipped, ReasonAlreadyExists, match.ID, nil)
+				continue
+			}
+		}
+
+		reason := ReasonNew
+		if opts.Force && LastSuccess(history, workout.ID, name) != nil {
+			reason = ReasonForced
+		}
+
+		result, err := dest.Upload(workoutCtx, workout, files[i])
+		if err != nil {
+			Record(history, workout.ID, name, "", false, err.Error())
+			summary.Errors++
+			progress(workout, OutcomeFailed, ReasonUploadFailed, "", err)
+			continue
+		}
+
+		if result.Duplicate {
+			RecordUpload(history, workout.ID, name, result, ReasonDuplicate)
+			summary.Skipped++
+			progress(workout, OutcomeSkipped, ReasonDuplicate, result.ID, nil)
+			continue
+		}
+
+		RecordUpload(history, workout.ID, name, result, "")
+		setChecksum(history, workout.ID, checksum)
+		summary.Uploaded++
+		progress(workout, OutcomeUploaded, reason, result.ID, nil)
+
+		if opts.Delay > 0 {
+			time.Sleep(opts.Delay)
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+func init() {
+	Register("csv", func(opts Options) Exporter {
+		return &CSVExporter{}
+	})
+}
+
+var (
+	exerciseHeader = []string{
+		"date", "workout_id", "workout", "section", "exercise", "round", "reps",
+		"weight", "weight_unit", "distance", "distance_unit", "calories", "rx", "pr",
+	}
+	summaryHeader = []string{
+		"date", "workout_id", "workout", "type", "box", "sections", "exercises",
+		"time", "rounds", "reps", "weight", "score", "rx", "prs",
+	}
+)
+
+// CSVExporter writes spreadsheet-friendly CSV files: exercises.csv with one
+// row per exercise and workouts.csv with one summary row per workout
+type CSVExporter struct{}
+
+// Format returns the exporter name
+func (e *CSVExporter) Format() string {
+	return "csv"
+}
+
+// Export writes exercises.csv and workouts.csv
+func (e *CSVExporter) Export(workouts []models.Workout, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	exercisesPath := filepath.Join(dir, "exercises.csv")
+	if err := writeCSV(exercisesPath, exerciseHeader, ExerciseRows(workouts)); err != nil {
+		return nil, err
+	}
+
+	summaryPath := filepath.Join(dir, "workouts.csv")
+	if err := writeCSV(summaryPath, summaryHeader, SummaryRows(workouts)); err != nil {
+		return nil, err
+	}
+
+	return []string{exercisesPath, summaryPath}, nil
+}
+
+// ExerciseRows returns one row per exercise, matching exercises.csv
+func ExerciseRows(workouts []models.Workout) [][]string {
+	var rows [][]string
+	for _, w := range workouts {
+		date := w.Date.Format("2006-01-02")
+		for _, ex := range w.Exercises {
+			section := ""
+			rx := w.Result == nil || !w.Result.Scaled
+			if ex.SectionIndex >= 0 && ex.SectionIndex < len(w.Sections) {
+				section = w.Sections[ex.SectionIndex].Name
+				rx = w.Sections[ex.SectionIndex].RX
+			}
+
+			reps := ex.Reps
+			if ex.RepsPerRound > 0 {
+				reps = ex.RepsPerRound
+			}
+
+			rows = append(rows, []string{
+				date,
+				w.ID,
+				w.Name,
+				section,
+				ex.Name,
+				formatInt(ex.Round),
+				formatInt(reps),
+				formatFloat(ex.Weight),
+				unitIfSet(ex.Weight, ex.WeightUnit, "kg"),
+				formatFloat(ex.Distance),
+				unitIfSet(ex.Distance, ex.DistanceUnit, "m"),
+				formatInt(ex.Calories),
+				strconv.FormatBool(rx),
+				strconv.FormatBool(ex.PR),
+			})
+		}
+	}
+	return rows
+}
+
+// SummaryRows returns one row per workout, matching workouts.csv
+func SummaryRows(workouts []models.Workout) [][]string {
+	var rows [][]string
+	for _, w := range workouts {
+		prs := 0
+		for _, ex := range w.Exercises {
+			if ex.PR {
+				prs++
+			}
+		}
+
+		var timeStr, rounds, reps, weight, score string
+		rx := true
+		if w.Result != nil {
+			if w.Result.Time != nil {
+				timeStr = formatClock(*w.Result.Time)
+			}
+			rounds = formatInt(w.Result.Rounds)
+			reps = formatInt(w.Result.Reps)
+			weight = formatFloat(w.Result.Weight)
+			score = w.Result.Score
+			rx = !w.Result.Scaled
+		}
+
+		rows = append(rows, []string{
+			w.Date.Format("2006-01-02"),
+			w.ID,
+			w.Name,
+			string(w.Type),
+			w.BoxName,
+			strconv.Itoa(len(w.Sections)),
+			strconv.Itoa(len(w.Exercises)),
+			timeStr,
+			rounds,
+			reps,
+			weight,
+			score,
+			strconv.FormatBool(rx),
+			strconv.Itoa(prs),
+		})
+	}
+	return rows
+}
+
+// writeCSV writes a header and rows to path
+func writeCSV(path string, header []string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
+	"github.com/aimharder-sync/internal/tcx"
+)
+
+// Exporter writes workouts to files in a specific format
+type Exporter interface {
+	// Format returns the name used to select the exporter (e.g. "csv")
+	Format() string
+
+	// Export writes the workouts into dir and returns the created files
+	Export(workouts []models.Workout, dir string) ([]string, error)
+}
+
+// Options configures exporters
+type Options struct {
+	// DefaultDuration is used for workouts without a known duration
+	DefaultDuration time.Duration
+
+	// Renderer renders descriptions and notes; the built-in templates are
+	// used when nil
+	Renderer *render.Renderer
+}
+
+// renderer returns the configured renderer or the built-in one
+func (o Options) renderer() *render.Renderer {
+	if o.Renderer == nil {
+		return render.Default()
+	}
+	return o.Renderer
+}
+
+// generator creates a TCX generator writing to dir
+func (o Options) generator(dir string) *tcx.Generator {
+	gen := tcx.NewGenerator(dir, o.DefaultDuration)
+	gen.SetRenderer(o.Renderer)
+	return gen
+}
+
+// Factory creates an exporter from options
+type Factory func(opts Options) Exporter
+
+var registry = map[string]Factory{}
+
+// Register makes an exporter available under the given format name
+func Register(format string, factory Factory) {
+	registry[strings.ToLower(format)] = factory
+}
+
+// New creates the exporter registered for format
+func New(format string, opts Options) (Exporter, error) {
+	factory, ok := registry[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return factory(opts), nil
+}
+
+// Formats returns the names of all registered formats
+func Formats() []string {
+	formats := make([]string, 0, len(registry))
+	for name := range registry {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// formatInt formats an integer, leaving zero values empty
+func formatInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// formatFloat formats a float without trailing zeros, leaving zero values empty
+func formatFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// unitIfSet returns the unit (or its default) when a value is present
+func unitIfSet(value float64, unit, defaultUnit string) string {
+	if value == 0 {
+		return ""
+	}
+	if unit == "" {
+		return defaultUnit
+	}
+	return unit
+}
+
+// formatClock formats a duration as H:MM:SS or M:SS
+func formatClock(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/tcx"
+)
+
+func init() {
+	Register("gpx", func(opts Options) Exporter {
+		return &GPXExporter{opts: opts}
+	})
+}
+
+// GPX 1.1 structures with the Garmin TrackPointExtension for heart rate.
+// Reference: https://www.topografix.com/GPX/1/1/
+// Workouts are indoors, so track points carry time and heart rate only.
+
+// GPX is the root element
+type GPX struct {
+	XMLName        xml.Name     `xml:"gpx"`
+	Version        string       `xml:"version,attr"`
+	Creator        string       `xml:"creator,attr"`
+	NS             string       `xml:"xmlns,attr"`
+	XSI            string       `xml:"xmlns:xsi,attr"`
+	GPXTPX         string       `xml:"xmlns:gpxtpx,attr"`
+	SchemaLocation string       `xml:"xsi:schemaLocation,attr"`
+	Metadata       *GPXMetadata `xml:"metadata,omitempty"`
+	Tracks         []GPXTrack   `xml:"trk"`
+}
+
+// GPXMetadata describes the file
+type GPXMetadata struct {
+	Name string `xml:"name,omitempty"`
+	Time string `xml:"time,omitempty"`
+}
+
+// GPXTrack is a single workout
+type GPXTrack struct {
+	Name     string       `xml:"name,omitempty"`
+	Desc     string       `xml:"desc,omitempty"`
+	Type     string       `xml:"type,omitempty"`
+	Segments []GPXSegment `xml:"trkseg"`
+}
+
+// GPXSegment holds track points
+type GPXSegment struct {
+	Points []GPXPoint `xml:"trkpt"`
+}
+
+// GPXPoint is a single track point
+type GPXPoint struct {
+	Time       string         `xml:"time"`
+	Extensions *GPXExtensions `xml:"extensions,omitempty"`
+}
+
+// GPXExtensions wraps the Garmin track point extension
+type GPXExtensions struct {
+	TrackPoint *GPXTrackPointExtension `xml:"gpxtpx:TrackPointExtension"`
+}
+
+// GPXTrackPointExtension carries heart rate
+type GPXTrackPointExtension struct {
+	HR int `xml:"gpxtpx:hr,omitempty"`
+}
+
+// GPXExporter writes one GPX file per workout
+type GPXExporter struct {
+	opts Options
+}
+
+// Format returns the exporter name
+func (e *GPXExporter) Format() string {
+	return "gpx"
+}
+
+// Export writes a GPX file for each workout
+func (e *GPXExporter) Export(workouts []models.Workout, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	gen := e.opts.generator(dir)
+
+	var files []string
+	for i := range workouts {
+		w := &workouts[i]
+		doc := BuildGPX(gen.Build(w), w)
+
+		output, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return files, fmt.Errorf("failed to marshal GPX for workout %s: %w", w.ID, err)
+		}
+
+		path := filepath.Join(dir, strings.TrimSuffix(gen.Filename(w), ".tcx")+".gpx")
+		if err := os.WriteFile(path, []byte(xml.Header+string(output)), 0644); err != nil {
+			return files, fmt.Errorf("failed to write GPX file: %w", err)
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// BuildGPX converts the track of a generated TCX document to GPX
+func BuildGPX(db *tcx.TrainingCenterDatabase, workout *models.Workout) *GPX {
+	doc := &GPX{
+		Version:        "1.1",
+		Creator:        "AimHarder Sync",
+		NS:             "http://www.topografix.com/GPX/1/1",
+		XSI:            "http://www.w3.org/2001/XMLSchema-instance",
+		GPXTPX:         "http://www.garmin.com/xmlschemas/TrackPointExtension/v1",
+		SchemaLocation: "http://www.topografix.com/GPX/1/1 http://www.topografix.com/GPX/1/1/gpx.xsd http://www.garmin.com/xmlschemas/TrackPointExtension/v1 http://www.garmin.com/xmlschemas/TrackPointExtensionv1.xsd",
+	}
+
+	if db.Activities == nil {
+		return doc
+	}
+
+	for _, activity := range db.Activities.Activity {
+		doc.Metadata = &GPXMetadata{Name: workout.Name, Time: activity.ID}
+
+		track := GPXTrack{
+			Name: workout.Name,
+			Desc: workout.Description,
+			Type: string(workout.Type),
+		}
+		for _, lap := range activity.Lap {
+			var segment GPXSegment
+			if lap.Track != nil {
+				for _, tp := range lap.Track.Trackpoint {
+					point := GPXPoint{Time: tp.Time}
+					if tp.HeartRateBpm != nil {
+						point.Extensions = &GPXExtensions{
+							TrackPoint: &GPXTrackPointExtension{HR: tp.HeartRateBpm.Value},
+						}
+					}
+					segment.Points = append(segment.Points, point)
+				}
+			}
+			track.Segments = append(track.Segments, segment)
+		}
+		doc.Tracks = append(doc.Tracks, track)
+	}
+
+	return doc
+}
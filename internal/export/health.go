@@ -0,0 +1,107 @@
+package export
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/tcx"
+)
+
+// healthSession is a workout as phone health apps see it: an exercise
+// session with an activity type, start/end, energy and heart rate samples.
+// It is derived from the generated TCX document, so health exports carry
+// the same times, calories and heart rate as uploaded activities.
+type healthSession struct {
+	Workout  *models.Workout
+	Strength bool // functional strength training rather than HIIT
+	Start    time.Time
+	End      time.Time
+	Calories int
+	AvgHR    int
+	MinHR    int
+	MaxHR    int
+	Samples  []heartRateSample
+}
+
+type heartRateSample struct {
+	Time time.Time
+	BPM  int
+}
+
+// isStrength reports whether a workout type is logged as functional
+// strength training; everything else is high intensity interval training
+func isStrength(t models.WorkoutType) bool {
+	return t == models.WorkoutTypeStrength || t == models.WorkoutTypeSkill
+}
+
+// buildHealthSession converts the TCX document generated for a workout
+func buildHealthSession(db *tcx.TrainingCenterDatabase, gen *tcx.Generator, w *models.Workout) healthSession {
+	s := healthSession{
+		Workout:  w,
+		Strength: isStrength(w.Type),
+		Start:    gen.StartTime(w),
+	}
+	s.End = s.Start.Add(gen.Duration(w))
+
+	if db.Activities == nil {
+		return s
+	}
+	for _, activity := range db.Activities.Activity {
+		for _, lap := range activity.Lap {
+			s.Calories += lap.Calories
+			if lap.AverageHeartRateBpm != nil {
+				s.AvgHR = lap.AverageHeartRateBpm.Value
+			}
+			if lap.MaximumHeartRateBpm != nil {
+				s.MaxHR = lap.MaximumHeartRateBpm.Value
+			}
+			if lap.Track == nil {
+				continue
+			}
+			for _, tp := range lap.Track.Trackpoint {
+				if tp.HeartRateBpm == nil {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, tp.Time)
+				if err != nil || t.Before(s.Start) || t.After(s.End) {
+					continue
+				}
+				s.Samples = append(s.Samples, heartRateSample{Time: t.In(s.Start.Location()), BPM: tp.HeartRateBpm.Value})
+				if s.MinHR == 0 || tp.HeartRateBpm.Value < s.MinHR {
+					s.MinHR = tp.HeartRateBpm.Value
+				}
+			}
+		}
+	}
+	return s
+}
+
+// healthSessions builds the sessions for workouts, in date order
+func (o Options) healthSessions(workouts []models.Workout) []healthSession {
+	gen := o.generator("")
+	sessions := make([]healthSession, 0, len(workouts))
+	for i := range workouts {
+		w := &workouts[i]
+		sessions = append(sessions, buildHealthSession(gen.Build(w), gen, w))
+	}
+	return sessions
+}
+
+// rangeFilename names a file covering the date range of sessions, e.g.
+// "healthkit_2024-01-01_2024-01-31.xml"
+func rangeFilename(prefix, ext string, sessions []healthSession) string {
+	if len(sessions) == 0 {
+		return prefix + ext
+	}
+	first, last := sessions[0].Start, sessions[0].Start
+	for _, s := range sessions[1:] {
+		if s.Start.Before(first) {
+			first = s.Start
+		}
+		if s.Start.After(last) {
+			last = s.Start
+		}
+	}
+	return fmt.Sprintf("%s_%s_%s%s", prefix, first.Format("2006-01-02"), last.Format("2006-01-02"), ext)
+}
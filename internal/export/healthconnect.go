@@ -0,0 +1,182 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+func init() {
+	Register("healthconnect", func(opts Options) Exporter {
+		return &HealthConnectExporter{opts: opts}
+	})
+}
+
+// Android Health Connect exercise types
+// Reference: https://developer.android.com/reference/kotlin/androidx/health/connect/client/records/ExerciseSessionRecord
+const (
+	healthConnectHIIT             = 36
+	healthConnectStrengthTraining = 70
+)
+
+// HealthConnectFile holds Health Connect records grouped by record type.
+// Records carry a clientRecordId derived from the workout ID and the
+// export time as clientRecordVersion, so importing a newer export of the
+// same workouts updates records instead of duplicating them.
+type HealthConnectFile struct {
+	ExportTime     time.Time                     `json:"exportTime"`
+	DataOrigin     string                        `json:"dataOrigin"`
+	Sessions       []HealthConnectSession        `json:"ExerciseSessionRecord"`
+	ActiveCalories []HealthConnectCaloriesRecord `json:"ActiveCaloriesBurnedRecord"`
+	HeartRate      []HealthConnectHeartRate      `json:"HeartRateRecord"`
+}
+
+// HealthConnectMetadata identifies a record for deduplication
+type HealthConnectMetadata struct {
+	ClientRecordID      string `json:"clientRecordId"`
+	ClientRecordVersion int64  `json:"clientRecordVersion"`
+}
+
+// HealthConnectInterval is the time range of an interval record
+type HealthConnectInterval struct {
+	StartTime       time.Time `json:"startTime"`
+	StartZoneOffset string    `json:"startZoneOffset"`
+	EndTime         time.Time `json:"endTime"`
+	EndZoneOffset   string    `json:"endZoneOffset"`
+}
+
+// HealthConnectSession is an ExerciseSessionRecord
+type HealthConnectSession struct {
+	Metadata HealthConnectMetadata `json:"metadata"`
+	HealthConnectInterval
+	ExerciseType     int    `json:"exerciseType"`
+	ExerciseTypeName string `json:"exerciseTypeName"`
+	Title            string `json:"title"`
+	Notes            string `json:"notes,omitempty"`
+}
+
+// HealthConnectCaloriesRecord is an ActiveCaloriesBurnedRecord
+type HealthConnectCaloriesRecord struct {
+	Metadata HealthConnectMetadata `json:"metadata"`
+	HealthConnectInterval
+	Energy HealthConnectEnergy `json:"energy"`
+}
+
+// HealthConnectEnergy is an energy amount
+type HealthConnectEnergy struct {
+	Kilocalories float64 `json:"inKilocalories"`
+}
+
+// HealthConnectHeartRate is a HeartRateRecord
+type HealthConnectHeartRate struct {
+	Metadata HealthConnectMetadata `json:"metadata"`
+	HealthConnectInterval
+	Samples []HealthConnectHeartRateSample `json:"samples"`
+}
+
+// HealthConnectHeartRateSample is a single heart rate reading
+type HealthConnectHeartRateSample struct {
+	Time           time.Time `json:"time"`
+	BeatsPerMinute int       `json:"beatsPerMinute"`
+}
+
+// HealthConnectExporter writes one Health Connect JSON file for all workouts
+type HealthConnectExporter struct {
+	opts Options
+}
+
+// Format returns the exporter name
+func (e *HealthConnectExporter) Format() string {
+	return "healthconnect"
+}
+
+// Export writes healthconnect_<first>_<last>.json
+func (e *HealthConnectExporter) Export(workouts []models.Workout, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	sessions := e.opts.healthSessions(workouts)
+	doc := BuildHealthConnect(sessions, time.Now())
+
+	output, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Health Connect JSON: %w", err)
+	}
+
+	path := filepath.Join(dir, rangeFilename("healthconnect", ".json", sessions))
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write Health Connect file: %w", err)
+	}
+
+	return []string{path}, nil
+}
+
+// BuildHealthConnect converts sessions to Health Connect records
+func BuildHealthConnect(sessions []healthSession, now time.Time) *HealthConnectFile {
+	doc := &HealthConnectFile{
+		ExportTime:     now.UTC(),
+		DataOrigin:     "aimharder-sync",
+		Sessions:       []HealthConnectSession{},
+		ActiveCalories: []HealthConnectCaloriesRecord{},
+		HeartRate:      []HealthConnectHeartRate{},
+	}
+	version := now.UnixMilli()
+
+	for _, s := range sessions {
+		interval := HealthConnectInterval{
+			StartTime:       s.Start.UTC(),
+			StartZoneOffset: s.Start.Format("-07:00"),
+			EndTime:         s.End.UTC(),
+			EndZoneOffset:   s.End.Format("-07:00"),
+		}
+		metadata := func(kind string) HealthConnectMetadata {
+			return HealthConnectMetadata{
+				ClientRecordID:      fmt.Sprintf("aimharder-%s-%s", kind, s.Workout.ID),
+				ClientRecordVersion: version,
+			}
+		}
+
+		session := HealthConnectSession{
+			Metadata:              metadata("session"),
+			HealthConnectInterval: interval,
+			ExerciseType:          healthConnectHIIT,
+			ExerciseTypeName:      "EXERCISE_TYPE_HIGH_INTENSITY_INTERVAL_TRAINING",
+			Title:                 s.Workout.Name,
+			Notes:                 s.Workout.Description,
+		}
+		if s.Strength {
+			session.ExerciseType = healthConnectStrengthTraining
+			session.ExerciseTypeName = "EXERCISE_TYPE_STRENGTH_TRAINING"
+		}
+		doc.Sessions = append(doc.Sessions, session)
+
+		if s.Calories > 0 {
+			doc.ActiveCalories = append(doc.ActiveCalories, HealthConnectCaloriesRecord{
+				Metadata:              metadata("calories"),
+				HealthConnectInterval: interval,
+				Energy:                HealthConnectEnergy{Kilocalories: float64(s.Calories)},
+			})
+		}
+
+		if len(s.Samples) > 0 {
+			hr := HealthConnectHeartRate{
+				Metadata:              metadata("heartrate"),
+				HealthConnectInterval: interval,
+			}
+			for _, sample := range s.Samples {
+				hr.Samples = append(hr.Samples, HealthConnectHeartRateSample{
+					Time:           sample.Time.UTC(),
+					BeatsPerMinute: sample.BPM,
+				})
+			}
+			doc.HeartRate = append(doc.HeartRate, hr)
+		}
+	}
+
+	return doc
+}
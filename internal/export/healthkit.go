@@ -0,0 +1,190 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+func init() {
+	Register("healthkit", func(opts Options) Exporter {
+		return &HealthKitExporter{opts: opts}
+	})
+}
+
+// Apple Health export.xml structures. Files use the same layout as the
+// Health app's own "Export All Health Data" archive, which health import
+// apps read back into HealthKit.
+
+const (
+	healthKitSource     = "AimHarder Sync"
+	healthKitDateLayout = "2006-01-02 15:04:05 -0700"
+)
+
+// HealthData is the root element
+type HealthData struct {
+	XMLName    xml.Name            `xml:"HealthData"`
+	Locale     string              `xml:"locale,attr"`
+	ExportDate HealthKitExportDate `xml:"ExportDate"`
+	Records    []HealthKitRecord   `xml:"Record"`
+	Workouts   []HealthKitWorkout  `xml:"Workout"`
+}
+
+// HealthKitExportDate records when the file was written
+type HealthKitExportDate struct {
+	Value string `xml:"value,attr"`
+}
+
+// HealthKitRecord is a quantity sample such as a heart rate reading
+type HealthKitRecord struct {
+	Type         string `xml:"type,attr"`
+	SourceName   string `xml:"sourceName,attr"`
+	Unit         string `xml:"unit,attr"`
+	CreationDate string `xml:"creationDate,attr"`
+	StartDate    string `xml:"startDate,attr"`
+	EndDate      string `xml:"endDate,attr"`
+	Value        string `xml:"value,attr"`
+}
+
+// HealthKitWorkout is an HKWorkout
+type HealthKitWorkout struct {
+	ActivityType          string                `xml:"workoutActivityType,attr"`
+	Duration              string                `xml:"duration,attr"`
+	DurationUnit          string                `xml:"durationUnit,attr"`
+	TotalEnergyBurned     string                `xml:"totalEnergyBurned,attr,omitempty"`
+	TotalEnergyBurnedUnit string                `xml:"totalEnergyBurnedUnit,attr,omitempty"`
+	SourceName            string                `xml:"sourceName,attr"`
+	CreationDate          string                `xml:"creationDate,attr"`
+	StartDate             string                `xml:"startDate,attr"`
+	EndDate               string                `xml:"endDate,attr"`
+	Metadata              []HealthKitMetadata   `xml:"MetadataEntry"`
+	Statistics            []HealthKitStatistics `xml:"WorkoutStatistics"`
+}
+
+// HealthKitMetadata is a workout metadata key/value pair
+type HealthKitMetadata struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// HealthKitStatistics summarizes a quantity over a workout
+type HealthKitStatistics struct {
+	Type      string `xml:"type,attr"`
+	StartDate string `xml:"startDate,attr"`
+	EndDate   string `xml:"endDate,attr"`
+	Average   string `xml:"average,attr,omitempty"`
+	Minimum   string `xml:"minimum,attr,omitempty"`
+	Maximum   string `xml:"maximum,attr,omitempty"`
+	Sum       string `xml:"sum,attr,omitempty"`
+	Unit      string `xml:"unit,attr"`
+}
+
+// HealthKitExporter writes one Apple Health XML file for all workouts
+type HealthKitExporter struct {
+	opts Options
+}
+
+// Format returns the exporter name
+func (e *HealthKitExporter) Format() string {
+	return "healthkit"
+}
+
+// Export writes healthkit_<first>_<last>.xml
+func (e *HealthKitExporter) Export(workouts []models.Workout, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	sessions := e.opts.healthSessions(workouts)
+	doc := BuildHealthData(sessions, time.Now())
+
+	output, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Apple Health XML: %w", err)
+	}
+
+	path := filepath.Join(dir, rangeFilename("healthkit", ".xml", sessions))
+	if err := os.WriteFile(path, []byte(xml.Header+string(output)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write Apple Health file: %w", err)
+	}
+
+	return []string{path}, nil
+}
+
+// BuildHealthData converts sessions to an Apple Health document
+func BuildHealthData(sessions []healthSession, now time.Time) *HealthData {
+	created := now.Format(healthKitDateLayout)
+	doc := &HealthData{
+		Locale:     "en_US",
+		ExportDate: HealthKitExportDate{Value: created},
+	}
+
+	for _, s := range sessions {
+		start := s.Start.Format(healthKitDateLayout)
+		end := s.End.Format(healthKitDateLayout)
+
+		activityType := "HKWorkoutActivityTypeHighIntensityIntervalTraining"
+		if s.Strength {
+			activityType = "HKWorkoutActivityTypeFunctionalStrengthTraining"
+		}
+
+		workout := HealthKitWorkout{
+			ActivityType: activityType,
+			Duration:     strconv.FormatFloat(s.End.Sub(s.Start).Minutes(), 'f', -1, 64),
+			DurationUnit: "min",
+			SourceName:   healthKitSource,
+			CreationDate: created,
+			StartDate:    start,
+			EndDate:      end,
+			Metadata: []HealthKitMetadata{
+				{Key: "HKIndoorWorkout", Value: "1"},
+				{Key: "HKExternalUUID", Value: s.Workout.ID},
+				{Key: "HKWorkoutBrandName", Value: s.Workout.Name},
+			},
+		}
+		if s.Calories > 0 {
+			kcal := strconv.Itoa(s.Calories)
+			workout.TotalEnergyBurned = kcal
+			workout.TotalEnergyBurnedUnit = "kcal"
+			workout.Statistics = append(workout.Statistics, HealthKitStatistics{
+				Type:      "HKQuantityTypeIdentifierActiveEnergyBurned",
+				StartDate: start,
+				EndDate:   end,
+				Sum:       kcal,
+				Unit:      "kcal",
+			})
+		}
+		if s.AvgHR > 0 {
+			workout.Statistics = append(workout.Statistics, HealthKitStatistics{
+				Type:      "HKQuantityTypeIdentifierHeartRate",
+				StartDate: start,
+				EndDate:   end,
+				Average:   strconv.Itoa(s.AvgHR),
+				Minimum:   formatInt(s.MinHR),
+				Maximum:   formatInt(s.MaxHR),
+				Unit:      "count/min",
+			})
+		}
+		doc.Workouts = append(doc.Workouts, workout)
+
+		for _, sample := range s.Samples {
+			t := sample.Time.Format(healthKitDateLayout)
+			doc.Records = append(doc.Records, HealthKitRecord{
+				Type:         "HKQuantityTypeIdentifierHeartRate",
+				SourceName:   healthKitSource,
+				Unit:         "count/min",
+				CreationDate: created,
+				StartDate:    t,
+				EndDate:      t,
+				Value:        strconv.Itoa(sample.BPM),
+			})
+		}
+	}
+
+	return doc
+}
@@ -0,0 +1,195 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+func init() {
+	Register("ics", func(opts Options) Exporter {
+		return &ICSExporter{opts: opts}
+	})
+}
+
+// icsTimeFormat is the UTC DATE-TIME form from RFC 5545
+const icsTimeFormat = "20060102T150405Z"
+
+// ICSExporter writes an iCalendar feed with one event per workout and a
+// tentative event per upcoming booked class
+type ICSExporter struct {
+	opts Options
+
+	// Bookings are added to the calendar as tentative events
+	Bookings []models.Booking
+}
+
+// Format returns the exporter name
+func (e *ICSExporter) Format() string {
+	return "ics"
+}
+
+// Export writes calendar.ics
+func (e *ICSExporter) Export(workouts []models.Workout, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := filepath.Join(dir, "calendar.ics")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar file: %w", err)
+	}
+	defer file.Close()
+
+	if err := WriteCalendar(file, workouts, e.Bookings, e.opts); err != nil {
+		return nil, err
+	}
+
+	return []string{path}, nil
+}
+
+// WriteCalendar writes an RFC 5545 calendar to w. Workouts become confirmed
+// events using their start time, duration and description; bookings that
+// have not happened yet become tentative events.
+func WriteCalendar(w io.Writer, workouts []models.Workout, bookings []models.Booking, opts Options) error {
+	cw := &calendarWriter{w: bufio.NewWriter(w)}
+	gen := opts.generator("")
+	now := time.Now().UTC().Format(icsTimeFormat)
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//AimHarder Sync//Workouts//EN")
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	cw.line("X-WR-CALNAME:" + escapeText("AimHarder Workouts"))
+
+	for i := range workouts {
+		workout := &workouts[i]
+		start := gen.StartTime(workout)
+		end := start.Add(gen.Duration(workout))
+
+		description := workout.Description
+		if description == "" {
+			description = opts.renderer().Description(workout)
+		}
+
+		cw.line("BEGIN:VEVENT")
+		cw.line("UID:" + escapeText("workout-"+workout.ID+"@aimharder-sync"))
+		cw.line("DTSTAMP:" + now)
+		cw.line("DTSTART:" + start.UTC().Format(icsTimeFormat))
+		cw.line("DTEND:" + end.UTC().Format(icsTimeFormat))
+		cw.line("SUMMARY:" + escapeText(workoutTitle(workout)))
+		if description != "" {
+			cw.line("DESCRIPTION:" + escapeText(description))
+		}
+		if workout.BoxName != "" {
+			cw.line("LOCATION:" + escapeText(workout.BoxName))
+		}
+		cw.line("CATEGORIES:" + escapeText(string(workout.Type)))
+		cw.line("STATUS:CONFIRMED")
+		cw.line("TRANSP:OPAQUE")
+		cw.line("END:VEVENT")
+	}
+
+	for _, booking := range bookings {
+		start, end, err := booking.TimeRange(time.Local)
+		if err != nil || end.Before(time.Now()) {
+			continue
+		}
+
+		summary := booking.ClassName
+		if summary == "" {
+			summary = "CrossFit class"
+		}
+
+		cw.line("BEGIN:VEVENT")
+		cw.line("UID:" + escapeText("booking-"+booking.ID+"@aimharder-sync"))
+		cw.line("DTSTAMP:" + now)
+		cw.line("DTSTART:" + start.UTC().Format(icsTimeFormat))
+		cw.line("DTEND:" + end.UTC().Format(icsTimeFormat))
+		cw.line("SUMMARY:" + escapeText(summary))
+		if booking.BoxName != "" {
+			cw.line("LOCATION:" + escapeText(booking.BoxName))
+		}
+		cw.line("STATUS:TENTATIVE")
+		cw.line("TRANSP:OPAQUE")
+		cw.line("END:VEVENT")
+	}
+
+	cw.line("END:VCALENDAR")
+
+	if cw.err != nil {
+		return fmt.Errorf("failed to write calendar: %w", cw.err)
+	}
+	if err := cw.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return nil
+}
+
+// workoutTitle returns the event summary for a workout
+func workoutTitle(workout *models.Workout) string {
+	if workout.Name != "" {
+		return workout.Name
+	}
+	return fmt.Sprintf("CrossFit WOD - %s", workout.Date.Format("2006-01-02"))
+}
+
+// calendarWriter writes CRLF-terminated content lines folded at 75 octets
+type calendarWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (cw *calendarWriter) line(content string) {
+	if cw.err != nil {
+		return
+	}
+
+	const limit = 75
+	first := true
+	for len(content) > 0 {
+		max := limit
+		if !first {
+			max = limit - 1 // continuation lines start with a space
+		}
+		cut := len(content)
+		if cut > max {
+			cut = max
+			// Don't split a multi-byte UTF-8 sequence
+			for cut > 0 && content[cut]&0xC0 == 0x80 {
+				cut--
+			}
+		}
+
+		prefix := ""
+		if !first {
+			prefix = " "
+		}
+		if _, err := cw.w.WriteString(prefix + content[:cut] + "\r\n"); err != nil {
+			cw.err = err
+			return
+		}
+		content = content[cut:]
+		first = false
+	}
+}
+
+// escapeText escapes a TEXT value as described in RFC 5545 section 3.3.11
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+func init() {
+	Register("jsonl", func(opts Options) Exporter {
+		return &JSONLExporter{}
+	})
+}
+
+// JSONLExporter writes workouts as newline-delimited JSON, one
+// models.Workout per line
+type JSONLExporter struct{}
+
+// Format returns the exporter name
+func (e *JSONLExporter) Format() string {
+	return "jsonl"
+}
+
+// Export streams the workouts to workouts.jsonl
+func (e *JSONLExporter) Export(workouts []models.Workout, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := filepath.Join(dir, "workouts.jsonl")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSONL file: %w", err)
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	for i := range workouts {
+		if err := encoder.Encode(&workouts[i]); err != nil {
+			return nil, fmt.Errorf("failed to encode workout %s: %w", workouts[i].ID, err)
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write JSONL file: %w", err)
+	}
+
+	return []string{path}, nil
+}
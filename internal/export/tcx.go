@@ -0,0 +1,33 @@
+package export
+
+import (
+	"log/slog"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+func init() {
+	Register("tcx", func(opts Options) Exporter {
+		return &TCXExporter{opts: opts}
+	})
+}
+
+// TCXExporter writes one TCX file per workout
+type TCXExporter struct {
+	opts Options
+}
+
+// Format returns the exporter name
+func (e *TCXExporter) Format() string {
+	return "tcx"
+}
+
+// Export writes a TCX file for each workout, skipping the ones whose file
+// can't be generated
+func (e *TCXExporter) Export(workouts []models.Workout, dir string) ([]string, error) {
+	_, files, failures := e.opts.generator(dir).GenerateAll(workouts)
+	for _, f := range failures {
+		slog.Warn("Failed to generate TCX", "workout", f.Workout.ID, "error", f.Err)
+	}
+	return files, nil
+}
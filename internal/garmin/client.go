@@ -0,0 +1,427 @@
+package garmin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Client handles communication with the Garmin Connect API used by the
+// Garmin Connect web and mobile apps
+type Client struct {
+	config     *config.Config
+	httpClient *http.Client
+	baseURL    string
+	tokens     *models.GarminTokens
+	tokenFile  string
+}
+
+// NewClient creates a new Garmin Connect client
+func NewClient(cfg *config.Config) (*Client, error) {
+	baseURL := strings.TrimRight(cfg.Garmin.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://connectapi.garmin.com"
+	}
+
+	client := &Client{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    baseURL,
+		tokenFile:  cfg.Storage.TokensFile,
+	}
+
+	if err := client.loadTokens(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Activity is a Garmin Connect activity summary
+type Activity struct {
+	ActivityID     int64  `json:"activityId"`
+	ActivityName   string `json:"activityName"`
+	StartTimeLocal string `json:"startTimeLocal"`
+	ActivityType   struct {
+		TypeKey string `json:"typeKey"`
+	} `json:"activityType"`
+}
+
+// StartTime parses the activity's local start time
+func (a *Activity) StartTime() time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", a.StartTimeLocal, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ImportResult is the response of the upload service
+type ImportResult struct {
+	DetailedImportResult struct {
+		UploadID  int64          `json:"uploadId"`
+		Successes []ImportStatus `json:"successes"`
+		Failures  []ImportStatus `json:"failures"`
+	} `json:"detailedImportResult"`
+}
+
+// ImportStatus describes one activity of an upload
+type ImportStatus struct {
+	InternalID int64 `json:"internalId"`
+	Messages   []struct {
+		Code    int    `json:"code"`
+		Content string `json:"content"`
+	} `json:"messages"`
+}
+
+// duplicateCode is the message code Garmin uses for duplicate activities
+const duplicateCode = 202
+
+// IsDuplicate reports whether the upload was rejected as a duplicate
+func (r *ImportResult) IsDuplicate() bool {
+	for _, f := range r.DetailedImportResult.Failures {
+		for _, m := range f.Messages {
+			if m.Code == duplicateCode || strings.Contains(strings.ToLower(m.Content), "duplicate") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActivityID returns the ID of the created (or, for duplicates, existing)
+// activity, or 0 if Garmin hasn't assigned one yet
+func (r *ImportResult) ActivityID() int64 {
+	for _, s := range r.DetailedImportResult.Successes {
+		if s.InternalID != 0 {
+			return s.InternalID
+		}
+	}
+	for _, f := range r.DetailedImportResult.Failures {
+		if f.InternalID != 0 {
+			return f.InternalID
+		}
+	}
+	return 0
+}
+
+// Error returns the failure messages of a rejected upload
+func (r *ImportResult) Error() string {
+	var messages []string
+	for _, f := range r.DetailedImportResult.Failures {
+		for _, m := range f.Messages {
+			messages = append(messages, m.Content)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// IsAuthenticated returns true if we have tokens
+func (c *Client) IsAuthenticated() bool {
+	return c.tokens != nil && (c.tokens.AccessToken != "" || c.tokens.RefreshToken != "")
+}
+
+// NeedsRefresh returns true if the access token is missing or about to expire
+func (c *Client) NeedsRefresh() bool {
+	if c.tokens == nil || c.tokens.AccessToken == "" {
+		return true
+	}
+	// Tokens from the environment have no known expiry
+	if c.tokens.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Until(c.tokens.ExpiresAt) < 5*time.Minute
+}
+
+// EnsureValidToken refreshes the access token when needed
+func (c *Client) EnsureValidToken(ctx context.Context) error {
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("not authenticated with Garmin Connect - set GARMIN_ACCESS_TOKEN or GARMIN_REFRESH_TOKEN")
+	}
+
+	if c.NeedsRefresh() {
+		if c.tokens.RefreshToken == "" {
+			return fmt.Errorf("Garmin access token expired and no refresh token is available")
+		}
+		slog.Info("Refreshing access token", logging.Icon("🔄"), "destination", "garmin")
+		if err := c.RefreshTokens(ctx); err != nil {
+			return fmt.Errorf("failed to refresh Garmin token: %w", err)
+		}
+		slog.Debug("Token refreshed", "destination", "garmin")
+	}
+
+	return nil
+}
+
+// RefreshTokens exchanges the refresh token for a new access token
+func (c *Client) RefreshTokens(ctx context.Context) error {
+	if c.tokens == nil || c.tokens.RefreshToken == "" {
+		return fmt.Errorf("no refresh token available")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", c.tokens.RefreshToken)
+	if c.config.Garmin.ClientID != "" {
+		form.Set("client_id", c.config.Garmin.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.Garmin.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token refresh failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("token response has no access token")
+	}
+
+	c.tokens.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		c.tokens.RefreshToken = token.RefreshToken
+	}
+	c.tokens.ExpiresAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		c.tokens.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return c.saveTokens()
+}
+
+// UploadActivity uploads a FIT, TCX or GPX file
+func (c *Client) UploadActivity(ctx context.Context, path string) (*ImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activity file: %w", err)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+	writer.Close()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	status, respBody, err := c.do(ctx, http.MethodPost, "/upload-service/upload/"+ext, body.Bytes(), writer.FormDataContentType())
+	if err != nil {
+		return nil, err
+	}
+
+	var result ImportResult
+	if len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse upload response: %w", err)
+		}
+	}
+
+	// Duplicates are reported as 409 Conflict together with the import result
+	if status == http.StatusConflict && !result.IsDuplicate() {
+		return nil, fmt.Errorf("upload failed with status %d: %s", status, string(respBody))
+	}
+	if status != http.StatusConflict && (status < 200 || status > 299) {
+		return nil, fmt.Errorf("upload failed with status %d: %s", status, string(respBody))
+	}
+
+	return &result, nil
+}
+
+// GetActivities returns activities that started between start and end
+func (c *Client) GetActivities(ctx context.Context, start, end time.Time) ([]Activity, error) {
+	query := url.Values{}
+	query.Set("startDate", start.Format("2006-01-02"))
+	query.Set("endDate", end.Format("2006-01-02"))
+	query.Set("limit", "200")
+
+	status, body, err := c.do(ctx, http.MethodGet, "/activitylist-service/activities/search/activities?"+query.Encode(), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to get activities: %d - %s", status, string(body))
+	}
+
+	var activities []Activity
+	if err := json.Unmarshal(body, &activities); err != nil {
+		return nil, fmt.Errorf("failed to parse activities: %w", err)
+	}
+	return activities, nil
+}
+
+// UpdateActivity sets the name, description and type of an activity
+func (c *Client) UpdateActivity(ctx context.Context, activityID int64, name, description, typeKey string) error {
+	update := map[string]interface{}{
+		"activityId":   activityID,
+		"activityName": name,
+		"description":  description,
+	}
+	if typeKey != "" {
+		update["activityTypeDTO"] = map[string]string{"typeKey": typeKey}
+	}
+
+	data, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update: %w", err)
+	}
+
+	path := "/activity-service/activity/" + strconv.FormatInt(activityID, 10)
+	status, body, err := c.do(ctx, http.MethodPut, path, data, "application/json")
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("update failed with status %d: %s", status, string(body))
+	}
+	return nil
+}
+
+// DeleteActivity deletes an activity
+func (c *Client) DeleteActivity(ctx context.Context, activityID int64) error {
+	path := "/activity-service/activity/" + strconv.FormatInt(activityID, 10)
+	status, body, err := c.do(ctx, http.MethodDelete, path, nil, "")
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("delete failed with status %d: %s", status, string(body))
+	}
+	return nil
+}
+
+// do sends an authenticated request, refreshing the token once on 401, and
+// returns the status code and body
+func (c *Client) do(ctx context.Context, method, path string, body []byte, contentType string) (int, []byte, error) {
+	if err := c.EnsureValidToken(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	status, respBody, err := c.send(ctx, method, path, body, contentType)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if status == http.StatusUnauthorized && c.tokens.RefreshToken != "" {
+		slog.Warn("Access token expired, refreshing", "destination", "garmin")
+		if err := c.RefreshTokens(ctx); err != nil {
+			return 0, nil, fmt.Errorf("request failed and token refresh failed: %w", err)
+		}
+		return c.send(ctx, method, path, body, contentType)
+	}
+
+	return status, respBody, nil
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, contentType string) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.tokens.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("NK", "NT") // required by Garmin Connect for API requests
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("garmin request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, nil
+}
+
+// loadTokens loads tokens from the tokens file or falls back to config
+func (c *Client) loadTokens() error {
+	if data, err := os.ReadFile(c.tokenFile); err == nil {
+		var allTokens struct {
+			Garmin *models.GarminTokens `json:"garmin"`
+		}
+		if err := json.Unmarshal(data, &allTokens); err == nil && allTokens.Garmin != nil {
+			c.tokens = allTokens.Garmin
+			return nil
+		}
+	}
+
+	if c.config.Garmin.AccessToken == "" && c.config.Garmin.RefreshToken == "" {
+		return fmt.Errorf("no Garmin tokens found (set GARMIN_ACCESS_TOKEN or GARMIN_REFRESH_TOKEN)")
+	}
+
+	c.tokens = &models.GarminTokens{
+		AccessToken:  c.config.Garmin.AccessToken,
+		RefreshToken: c.config.Garmin.RefreshToken,
+	}
+	return nil
+}
+
+// saveTokens stores the tokens in the garmin slot of the tokens file,
+// keeping the other platforms' tokens
+func (c *Client) saveTokens() error {
+	if err := os.MkdirAll(filepath.Dir(c.tokenFile), 0700); err != nil {
+		return err
+	}
+
+	allTokens := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(c.tokenFile); err == nil {
+		json.Unmarshal(data, &allTokens)
+	}
+
+	garmin, err := json.Marshal(c.tokens)
+	if err != nil {
+		return err
+	}
+	allTokens["garmin"] = garmin
+
+	data, err := json.MarshalIndent(allTokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.tokenFile, data, 0600)
+}
@@ -0,0 +1,148 @@
+package garmin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Platform is the destination name used in sync history
+const Platform = "garmin"
+
+func init() {
+	destination.Register(Platform, func(cfg *config.Config) (destination.Destination, error) {
+		client, err := NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &Destination{client: client, update: cfg.Garmin.Update}, nil
+	})
+}
+
+// Destination uploads workouts to Garmin Connect
+type Destination struct {
+	client *Client
+	update bool // overwrite activities whose workout changed
+}
+
+// Name returns the platform name
+func (d *Destination) Name() string {
+	return Platform
+}
+
+// Authenticate refreshes the access token if needed
+func (d *Destination) Authenticate(ctx context.Context) error {
+	return d.client.EnsureValidToken(ctx)
+}
+
+// ListExisting returns the activities between start and end
+func (d *Destination) ListExisting(ctx context.Context, start, end time.Time) ([]destination.Activity, error) {
+	activities, err := d.client.GetActivities(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make([]destination.Activity, len(activities))
+	for i := range activities {
+		existing[i] = destination.Activity{
+			ID:        strconv.FormatInt(activities[i].ActivityID, 10),
+			Name:      activities[i].ActivityName,
+			StartTime: activities[i].StartTime(),
+		}
+	}
+	return existing, nil
+}
+
+// Upload uploads the activity file, then sets the name, description and
+// activity type, which Garmin doesn't take from TCX files
+func (d *Destination) Upload(ctx context.Context, workout *models.Workout, file string) (*destination.UploadResult, error) {
+	result, err := d.client.UploadActivity(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &destination.UploadResult{
+		UploadID: strconv.FormatInt(result.DetailedImportResult.UploadID, 10),
+	}
+	if id := result.ActivityID(); id != 0 {
+		upload.ID = strconv.FormatInt(id, 10)
+	}
+
+	if result.IsDuplicate() {
+		upload.Duplicate = true
+		return upload, nil
+	}
+	if len(result.DetailedImportResult.Failures) > 0 {
+		return nil, fmt.Errorf("upload rejected: %s", result.Error())
+	}
+
+	if id := result.ActivityID(); id != 0 {
+		if err := d.client.UpdateActivity(ctx, id, ActivityName(workout), workout.Description, ActivityType(workout.Type)); err != nil {
+			slog.Warn("Could not update the activity details", "destination", "garmin", "activity", id, "error", err)
+		}
+	}
+
+	return upload, nil
+}
+
+// Update sets the name, description and type of an uploaded activity
+func (d *Destination) Update(ctx context.Context, externalID string, workout *models.Workout) error {
+	if !d.update {
+		return fmt.Errorf("garmin: %w", destination.ErrUpdateDisabled)
+	}
+	id, err := strconv.ParseInt(externalID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Garmin activity ID %q", externalID)
+	}
+	return d.client.UpdateActivity(ctx, id, ActivityName(workout), workout.Description, ActivityType(workout.Type))
+}
+
+// Delete removes an uploaded activity
+func (d *Destination) Delete(ctx context.Context, externalID string) error {
+	id, err := strconv.ParseInt(externalID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Garmin activity ID %q", externalID)
+	}
+	return d.client.DeleteActivity(ctx, id)
+}
+
+// Preview describes the activity Upload would create
+func (d *Destination) Preview(workout *models.Workout, file string) *destination.Preview {
+	return &destination.Preview{
+		Name:        ActivityName(workout),
+		Type:        ActivityType(workout.Type),
+		StartDate:   workout.Date.Format("2006-01-02 15:04:05"),
+		Description: workout.Description,
+		ExternalID:  workout.ID,
+		DataType:    "tcx",
+		File:        file,
+	}
+}
+
+// ActivityName returns the activity name for a workout
+func ActivityName(workout *models.Workout) string {
+	if workout.Name != "" {
+		return workout.Name
+	}
+	return fmt.Sprintf("CrossFit WOD - %s", workout.Date.Format("2006-01-02"))
+}
+
+// ActivityType maps a workout type to a Garmin Connect activity type key
+func ActivityType(workoutType models.WorkoutType) string {
+	switch workoutType {
+	case models.WorkoutTypeStrength, models.WorkoutTypeSkill:
+		return "strength_training"
+	case models.WorkoutTypeAMRAP, models.WorkoutTypeForTime, models.WorkoutTypeEMOM,
+		models.WorkoutTypeTabata, models.WorkoutTypeWOD, models.WorkoutTypeHero,
+		models.WorkoutTypeGirl, models.WorkoutTypeOpen:
+		return "hiit"
+	default:
+		return "fitness_equipment"
+	}
+}
@@ -0,0 +1,397 @@
+// Package homeassistant publishes sync state to Home Assistant over MQTT,
+// using MQTT discovery so sensors, a sync button and a new workout event
+// appear on their own under one "AimHarder Sync" device.
+package homeassistant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/mqtt"
+	"github.com/aimharder-sync/internal/render"
+)
+
+// Payloads on the availability topic and the sync button's command topic
+const (
+	PayloadOnline  = "online"
+	PayloadOffline = "offline"
+	PayloadPress   = "PRESS"
+)
+
+// EventNewWorkout is the event type fired for each newly synced workout
+const EventNewWorkout = "new_workout"
+
+// Topics are the MQTT topics used under the configured topic prefix
+type Topics struct {
+	Availability string // online/offline, maintained by the webhook server
+	LastSync     string // SyncReport
+	LastWorkout  string // WorkoutInfo
+	Week         string // WeekStats
+	Event        string // WorkoutEvent per new workout (not retained)
+	SyncCommand  string // sync button presses
+}
+
+// NewTopics returns the topics under prefix
+func NewTopics(prefix string) Topics {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return Topics{
+		Availability: prefix + "/status",
+		LastSync:     prefix + "/last_sync",
+		LastWorkout:  prefix + "/last_workout",
+		Week:         prefix + "/week",
+		Event:        prefix + "/event/workout",
+		SyncCommand:  prefix + "/sync/press",
+	}
+}
+
+// SyncReport is the state of the last sync sensors
+type SyncReport struct {
+	Result      string    `json:"result"` // "success" or "failed"
+	Message     string    `json:"message"`
+	Uploaded    int       `json:"uploaded"`
+	Updated     int       `json:"updated"`
+	Skipped     int       `json:"skipped"`
+	Errors      int       `json:"errors"`
+	NewWorkouts int       `json:"new_workouts"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// WorkoutInfo describes a workout in sensor attributes and events
+type WorkoutInfo struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Date  string `json:"date"`
+	Type  string `json:"type"`
+	Score string `json:"score"`
+	Rx    string `json:"rx,omitempty"`
+	PRs   int    `json:"prs"`
+}
+
+// WorkoutEvent is published on the event topic
+type WorkoutEvent struct {
+	EventType string `json:"event_type"`
+	WorkoutInfo
+}
+
+// WeekStats is the state of the weekly sensors. Weeks start on Monday.
+type WeekStats struct {
+	WeekStart string `json:"week_start"`
+	Workouts  int    `json:"workouts"`
+	PRs       int    `json:"prs"`
+}
+
+// Publisher publishes discovery configs and state over an MQTT connection
+type Publisher struct {
+	client *mqtt.Client
+	cfg    config.MQTTConfig
+	topics Topics
+}
+
+// Dial connects to the configured broker for one-off publishing (e.g.
+// after a sync from the command line). clientSuffix keeps the client ID
+// distinct from the webhook server's long-lived connection.
+func Dial(ctx context.Context, cfg config.MQTTConfig, clientSuffix string) (*Publisher, error) {
+	client, err := mqtt.Dial(ctx, options(cfg, clientSuffix))
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{client: client, cfg: cfg, topics: NewTopics(cfg.TopicPrefix)}, nil
+}
+
+func options(cfg config.MQTTConfig, clientSuffix string) mqtt.Options {
+	clientID := cfg.ClientID
+	if clientSuffix != "" {
+		clientID += "-" + clientSuffix
+	}
+	return mqtt.Options{
+		Broker:   cfg.Broker,
+		ClientID: clientID,
+		Username: cfg.Username,
+		Password: cfg.Password,
+	}
+}
+
+// Close disconnects from the broker
+func (p *Publisher) Close() error {
+	return p.client.Close()
+}
+
+// Discovery publishes the retained discovery configs for all entities
+func (p *Publisher) Discovery(ctx context.Context) error {
+	for _, e := range entities(p.cfg, p.topics) {
+		payload, err := json.Marshal(e.config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s discovery config: %w", e.objectID, err)
+		}
+		if err := p.publish(ctx, e.topic, payload, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncResult publishes the outcome of a sync
+func (p *Publisher) SyncResult(ctx context.Context, report SyncReport) error {
+	return p.publishJSON(ctx, p.topics.LastSync, report, true)
+}
+
+// Stats publishes the last workout and this week's totals, computed from
+// all known workouts
+func (p *Publisher) Stats(ctx context.Context, workouts []models.Workout, now time.Time) error {
+	if last := LastWorkout(workouts); last != nil {
+		if err := p.publishJSON(ctx, p.topics.LastWorkout, Info(last), true); err != nil {
+			return err
+		}
+	}
+	return p.publishJSON(ctx, p.topics.Week, Week(workouts, now), true)
+}
+
+// NewWorkout fires the new workout event
+func (p *Publisher) NewWorkout(ctx context.Context, w *models.Workout) error {
+	return p.publishJSON(ctx, p.topics.Event, WorkoutEvent{EventType: EventNewWorkout, WorkoutInfo: Info(w)}, false)
+}
+
+func (p *Publisher) publishJSON(ctx context.Context, topic string, v interface{}, retain bool) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", topic, err)
+	}
+	return p.publish(ctx, topic, payload, retain)
+}
+
+func (p *Publisher) publish(ctx context.Context, topic string, payload []byte, retain bool) error {
+	err := p.client.Publish(ctx, mqtt.Message{Topic: topic, Payload: payload, Retain: retain, QoS: 1})
+	if err != nil {
+		return fmt.Errorf("failed to publish %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Serve keeps a connection to the broker until ctx is done: it publishes
+// discovery and availability, and calls onSync when the sync button is
+// pressed. Lost connections are retried with backoff; the broker marks
+// the button unavailable while disconnected.
+func Serve(ctx context.Context, cfg config.MQTTConfig, onSync func()) error {
+	topics := NewTopics(cfg.TopicPrefix)
+	opts := options(cfg, "")
+	opts.Will = &mqtt.Message{Topic: topics.Availability, Payload: []byte(PayloadOffline), Retain: true}
+
+	backoff := time.Second
+	for {
+		connected, err := serveOnce(ctx, cfg, opts, topics, onSync)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = time.Second
+		}
+		logging.Component("mqtt").Warn("Disconnected", "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Minute)
+	}
+}
+
+// serveOnce runs a single connection, reporting whether it got as far as
+// announcing itself online
+func serveOnce(ctx context.Context, cfg config.MQTTConfig, opts mqtt.Options, topics Topics, onSync func()) (bool, error) {
+	client, err := mqtt.Dial(ctx, opts)
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	p := &Publisher{client: client, cfg: cfg, topics: topics}
+	if err := p.Discovery(ctx); err != nil {
+		return false, err
+	}
+
+	err = client.Subscribe(ctx, topics.SyncCommand, 0, func(m mqtt.Message) {
+		if string(m.Payload) == PayloadPress {
+			go onSync()
+		}
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to subscribe to %s: %w", topics.SyncCommand, err)
+	}
+
+	if err := p.publish(ctx, topics.Availability, []byte(PayloadOnline), true); err != nil {
+		return false, err
+	}
+	logging.Component("mqtt").Info("Connected", logging.Icon("🏠"), "broker", cfg.Broker, "discovery_prefix", cfg.DiscoveryPrefix)
+
+	select {
+	case <-ctx.Done():
+		// Clean disconnects discard the will, so mark the button
+		// unavailable ourselves
+		offlineCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		p.publish(offlineCtx, topics.Availability, []byte(PayloadOffline), true)
+		return true, nil
+	case <-client.Done():
+		return true, client.Err()
+	}
+}
+
+// LastWorkout returns the most recent workout, or nil if there are none
+func LastWorkout(workouts []models.Workout) *models.Workout {
+	var last *models.Workout
+	for i := range workouts {
+		if last == nil || workouts[i].Date.After(last.Date) {
+			last = &workouts[i]
+		}
+	}
+	return last
+}
+
+// Info summarizes a workout
+func Info(w *models.Workout) WorkoutInfo {
+	info := WorkoutInfo{
+		ID:    w.ID,
+		Name:  w.Name,
+		Date:  w.Date.Format(time.RFC3339),
+		Type:  string(w.Type),
+		Score: render.FormatScore(w.Result),
+		PRs:   countPRs(w),
+	}
+	if r := w.Result; r != nil {
+		switch {
+		case r.RxPlus:
+			info.Rx = "Rx+"
+		case r.Scaled:
+			info.Rx = "Scaled"
+		default:
+			info.Rx = "Rx"
+		}
+	}
+	return info
+}
+
+// Week counts workouts and PRs in the week (Monday to Sunday) of now
+func Week(workouts []models.Workout, now time.Time) WeekStats {
+	offset := (int(now.Weekday()) + 6) % 7 // days since Monday
+	start := time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 0, 7)
+
+	stats := WeekStats{WeekStart: start.Format("2006-01-02")}
+	for i := range workouts {
+		w := &workouts[i]
+		if w.Date.Before(start) || !w.Date.Before(end) {
+			continue
+		}
+		stats.Workouts++
+		stats.PRs += countPRs(w)
+	}
+	return stats
+}
+
+func countPRs(w *models.Workout) int {
+	n := 0
+	for _, ex := range w.Exercises {
+		if ex.PR {
+			n++
+		}
+	}
+	return n
+}
+
+// entity is a discovery config and the topic it is published on
+type entity struct {
+	objectID string
+	topic    string
+	config   map[string]interface{}
+}
+
+var nonIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// entities returns the discovery configs for all entities
+func entities(cfg config.MQTTConfig, topics Topics) []entity {
+	node := nonIDChars.ReplaceAllString(cfg.TopicPrefix, "_")
+	device := map[string]interface{}{
+		"identifiers":  []string{node},
+		"name":         "AimHarder Sync",
+		"manufacturer": "aimharder-sync",
+		"model":        "AimHarder workout sync",
+	}
+
+	var list []entity
+	add := func(component, objectID string, config map[string]interface{}) {
+		config["unique_id"] = node + "_" + objectID
+		config["device"] = device
+		list = append(list, entity{
+			objectID: objectID,
+			topic:    fmt.Sprintf("%s/%s/%s/%s/config", cfg.DiscoveryPrefix, component, node, objectID),
+			config:   config,
+		})
+	}
+
+	add("sensor", "last_sync", map[string]interface{}{
+		"name":                  "Last sync",
+		"icon":                  "mdi:sync",
+		"state_topic":           topics.LastSync,
+		"value_template":        "{{ value_json.result }}",
+		"json_attributes_topic": topics.LastSync,
+	})
+	add("sensor", "last_sync_time", map[string]interface{}{
+		"name":           "Last sync time",
+		"device_class":   "timestamp",
+		"state_topic":    topics.LastSync,
+		"value_template": "{{ value_json.completed_at }}",
+	})
+	add("sensor", "last_workout", map[string]interface{}{
+		"name":                  "Last workout",
+		"icon":                  "mdi:weight-lifter",
+		"state_topic":           topics.LastWorkout,
+		"value_template":        "{{ value_json.name }}",
+		"json_attributes_topic": topics.LastWorkout,
+	})
+	add("sensor", "last_workout_score", map[string]interface{}{
+		"name":           "Last workout score",
+		"icon":           "mdi:scoreboard-outline",
+		"state_topic":    topics.LastWorkout,
+		"value_template": "{{ value_json.score }}",
+	})
+	add("sensor", "workouts_this_week", map[string]interface{}{
+		"name":                  "Workouts this week",
+		"icon":                  "mdi:calendar-week",
+		"state_topic":           topics.Week,
+		"value_template":        "{{ value_json.workouts }}",
+		"json_attributes_topic": topics.Week,
+		"state_class":           "measurement",
+		"unit_of_measurement":   "workouts",
+	})
+	add("sensor", "prs_this_week", map[string]interface{}{
+		"name":                "PRs this week",
+		"icon":                "mdi:medal-outline",
+		"state_topic":         topics.Week,
+		"value_template":      "{{ value_json.prs }}",
+		"state_class":         "measurement",
+		"unit_of_measurement": "PRs",
+	})
+	add("button", "sync", map[string]interface{}{
+		"name":               "Sync now",
+		"icon":               "mdi:cloud-sync",
+		"command_topic":      topics.SyncCommand,
+		"payload_press":      PayloadPress,
+		"availability_topic": topics.Availability,
+	})
+	add("event", "new_workout", map[string]interface{}{
+		"name":        "New workout",
+		"icon":        "mdi:dumbbell",
+		"state_topic": topics.Event,
+		"event_types": []string{EventNewWorkout},
+	})
+
+	return list
+}
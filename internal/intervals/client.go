@@ -0,0 +1,209 @@
+package intervals
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Client handles communication with the intervals.icu API.
+// Reference: https://intervals.icu/api-docs.html
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	athleteID  string
+}
+
+// NewClient creates a new intervals.icu client
+func NewClient(cfg *config.Config) (*Client, error) {
+	if err := cfg.ValidateIntervals(); err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimRight(cfg.Intervals.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://intervals.icu"
+	}
+	athleteID := cfg.Intervals.AthleteID
+	if athleteID == "" {
+		athleteID = "0"
+	}
+
+	return &Client{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    baseURL,
+		apiKey:     cfg.Intervals.APIKey,
+		athleteID:  athleteID,
+	}, nil
+}
+
+// Activity is an intervals.icu activity
+type Activity struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	StartDateLocal string `json:"start_date_local"`
+	ExternalID     string `json:"external_id"`
+	Description    string `json:"description"`
+}
+
+// StartTime parses the activity's local start time
+func (a *Activity) StartTime() time.Time {
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", a.StartDateLocal, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// UploadResponse is returned when an activity file is uploaded
+type UploadResponse struct {
+	ID         string `json:"id"`
+	Activities []struct {
+		ID string `json:"id"`
+	} `json:"activities"`
+}
+
+// APIError is a non-2xx response from the API
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("intervals.icu returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// CheckAuth verifies the API key by fetching the athlete profile
+func (c *Client) CheckAuth(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, c.athletePath(""), nil, "", nil)
+}
+
+// ListActivities returns the athlete's activities between oldest and newest
+func (c *Client) ListActivities(ctx context.Context, oldest, newest time.Time) ([]Activity, error) {
+	query := url.Values{}
+	query.Set("oldest", oldest.Format("2006-01-02"))
+	query.Set("newest", newest.Format("2006-01-02"))
+
+	var activities []Activity
+	if err := c.do(ctx, http.MethodGet, c.athletePath("/activities?"+query.Encode()), nil, "", &activities); err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// UploadActivity uploads an activity file (TCX, FIT, GPX) with the workout's
+// name. Descriptions can be too long for a URL; set them with UpdateActivity.
+func (c *Client) UploadActivity(ctx context.Context, path string, workout *models.Workout) (*UploadResponse, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open activity file: %w", err)
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+	writer.Close()
+
+	query := url.Values{}
+	query.Set("name", ActivityName(workout))
+	query.Set("external_id", workout.ID)
+
+	var resp UploadResponse
+	if err := c.do(ctx, http.MethodPost, c.athletePath("/activities?"+query.Encode()), body, writer.FormDataContentType(), &resp); err != nil {
+		return nil, err
+	}
+	if resp.ID == "" && len(resp.Activities) > 0 {
+		resp.ID = resp.Activities[0].ID
+	}
+	return &resp, nil
+}
+
+// UpdateActivity changes fields of an activity
+func (c *Client) UpdateActivity(ctx context.Context, activityID string, updates map[string]interface{}) error {
+	data, err := json.Marshal(updates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update: %w", err)
+	}
+	return c.do(ctx, http.MethodPut, "/api/v1/activity/"+url.PathEscape(activityID), bytes.NewReader(data), "application/json", nil)
+}
+
+// DeleteActivity deletes an activity
+func (c *Client) DeleteActivity(ctx context.Context, activityID string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/activity/"+url.PathEscape(activityID), nil, "", nil)
+}
+
+// athletePath returns an API path below the configured athlete
+func (c *Client) athletePath(suffix string) string {
+	return "/api/v1/athlete/" + url.PathEscape(c.athleteID) + suffix
+}
+
+// do sends a request authenticated with the API key and decodes the JSON
+// response into out (if not nil)
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, contentType string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// intervals.icu uses basic auth with the literal user name API_KEY
+	req.SetBasicAuth("API_KEY", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("intervals.icu request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to parse intervals.icu response: %w", err)
+		}
+	}
+	return nil
+}
+
+// ActivityName returns the activity name for a workout
+func ActivityName(workout *models.Workout) string {
+	if workout.Name != "" {
+		return workout.Name
+	}
+	return fmt.Sprintf("CrossFit WOD - %s", workout.Date.Format("2006-01-02"))
+}
+
+// ActivityType maps a workout type to an intervals.icu activity type
+func ActivityType(workoutType models.WorkoutType) string {
+	if workoutType == models.WorkoutTypeStrength {
+		return "WeightTraining"
+	}
+	return "Crossfit"
+}
@@ -0,0 +1,123 @@
+package intervals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Platform is the destination name used in sync history
+const Platform = "intervals"
+
+func init() {
+	destination.Register(Platform, func(cfg *config.Config) (destination.Destination, error) {
+		client, err := NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &Destination{client: client, update: cfg.Intervals.Update}, nil
+	})
+}
+
+// Destination uploads workouts to intervals.icu
+type Destination struct {
+	client *Client
+	update bool // overwrite activities whose workout changed
+}
+
+// Name returns the platform name
+func (d *Destination) Name() string {
+	return Platform
+}
+
+// Authenticate checks that the API key is accepted
+func (d *Destination) Authenticate(ctx context.Context) error {
+	if err := d.client.CheckAuth(ctx); err != nil {
+		return fmt.Errorf("%w (check INTERVALS_API_KEY and INTERVALS_ATHLETE_ID)", err)
+	}
+	return nil
+}
+
+// ListExisting returns the athlete's activities between start and end
+func (d *Destination) ListExisting(ctx context.Context, start, end time.Time) ([]destination.Activity, error) {
+	activities, err := d.client.ListActivities(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make([]destination.Activity, len(activities))
+	for i := range activities {
+		existing[i] = destination.Activity{
+			ID:         activities[i].ID,
+			ExternalID: activities[i].ExternalID,
+			Name:       activities[i].Name,
+			StartTime:  activities[i].StartTime(),
+		}
+	}
+	return existing, nil
+}
+
+// Upload uploads the activity file and sets the activity type and
+// description
+func (d *Destination) Upload(ctx context.Context, workout *models.Workout, file string) (*destination.UploadResult, error) {
+	resp, err := d.client.UploadActivity(ctx, file, workout)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusConflict || strings.Contains(strings.ToLower(apiErr.Body), "duplicate")) {
+			return &destination.UploadResult{Duplicate: true}, nil
+		}
+		return nil, err
+	}
+
+	// TCX files only carry a generic sport, so set the type explicitly. The
+	// description goes in the body, as it can be too long for the query.
+	if resp.ID != "" {
+		updates := map[string]interface{}{"type": ActivityType(workout.Type)}
+		if workout.Description != "" {
+			updates["description"] = workout.Description
+		}
+		if err := d.client.UpdateActivity(ctx, resp.ID, updates); err != nil {
+			slog.Warn("Could not set the activity type and description", "destination", "intervals", "activity", resp.ID, "error", err)
+		}
+	}
+
+	return &destination.UploadResult{ID: resp.ID}, nil
+}
+
+// Update sets the name, description and type of an uploaded activity
+func (d *Destination) Update(ctx context.Context, externalID string, workout *models.Workout) error {
+	if !d.update {
+		return fmt.Errorf("intervals: %w", destination.ErrUpdateDisabled)
+	}
+	return d.client.UpdateActivity(ctx, externalID, map[string]interface{}{
+		"name":        ActivityName(workout),
+		"description": workout.Description,
+		"type":        ActivityType(workout.Type),
+	})
+}
+
+// Delete removes an uploaded activity
+func (d *Destination) Delete(ctx context.Context, externalID string) error {
+	return d.client.DeleteActivity(ctx, externalID)
+}
+
+// Preview describes the activity Upload would create
+func (d *Destination) Preview(workout *models.Workout, file string) *destination.Preview {
+	return &destination.Preview{
+		Name:        ActivityName(workout),
+		Type:        ActivityType(workout.Type),
+		StartDate:   workout.Date.Format("2006-01-02T15:04:05"),
+		Description: workout.Description,
+		ExternalID:  workout.ID,
+		DataType:    "tcx",
+		File:        file,
+	}
+}
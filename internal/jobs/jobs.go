@@ -0,0 +1,509 @@
+// Package jobs runs syncs requested over HTTP in the background. Jobs are
+// queued and run one at a time; their state, per-workout progress and
+// events are kept for the most recent jobs, across restarts.
+package jobs
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Job states
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+// Event types
+const (
+	EventState     = "state"     // the job was queued, started or finished
+	EventFetched   = "fetched"   // a workout was fetched from Aimharder
+	EventGenerated = "generated" // an activity file was generated for a workout
+	EventSynced    = "synced"    // a workout was sent to a destination
+	EventLog       = "log"       // other progress messages
+)
+
+// maxEvents caps the events kept per job
+const maxEvents = 2000
+
+// ErrQueueFull is returned when too many jobs are waiting
+var ErrQueueFull = errors.New("too many sync jobs queued")
+
+// Workout is the progress of one workout within a job
+type Workout struct {
+	ID    string `json:"id"`
+	Date  string `json:"date"`
+	Name  string `json:"name"`
+	Stage string `json:"stage"` // fetched, generated or synced
+	File  string `json:"file,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	// Destinations maps platforms to the outcome (uploaded, updated,
+	// skipped or failed)
+	Destinations map[string]string `json:"destinations,omitempty"`
+}
+
+// Event is a progress update, streamed to clients as Server-Sent Events
+type Event struct {
+	ID       int       `json:"id"`
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	State    string    `json:"state,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Workout  *Workout  `json:"workout,omitempty"`
+	Platform string    `json:"platform,omitempty"`
+	Outcome  string    `json:"outcome,omitempty"`
+	Reason   string    `json:"reason,omitempty"` // why, e.g. already_exists
+	Error    string    `json:"error,omitempty"`
+}
+
+// Request describes what a job syncs
+type Request struct {
+	Days         int      `json:"days"`
+	Start        string   `json:"start,omitempty"` // YYYY-MM-DD, overrides Days
+	End          string   `json:"end,omitempty"`   // YYYY-MM-DD (default: today)
+	DryRun       bool     `json:"dry_run,omitempty"`
+	Force        bool     `json:"force,omitempty"`        // re-upload workouts already synced
+	Destinations []string `json:"destinations,omitempty"` // default: sync.destinations
+	WorkoutIDs   []string `json:"workout_ids,omitempty"`  // only sync these workouts
+}
+
+// Describe summarizes the request, e.g. "Sync of the last 7 days"
+func (r Request) Describe() string {
+	var s string
+	switch {
+	case len(r.WorkoutIDs) > 0:
+		s = "Sync of workout " + strings.Join(r.WorkoutIDs, ", ")
+	case r.Start != "":
+		s = fmt.Sprintf("Sync from %s to %s", r.Start, cmp.Or(r.End, "today"))
+	default:
+		s = fmt.Sprintf("Sync of the last %d days", r.Days)
+	}
+	if r.DryRun {
+		s = "Dry run: " + strings.ToLower(s[:1]) + s[1:]
+	}
+	return s
+}
+
+// Job is a sync requested over HTTP or from Home Assistant
+type Job struct {
+	ID         string          `json:"id"`
+	Trigger    string          `json:"trigger"` // webhook, mqtt or schedule
+	State      string          `json:"state"`
+	Message    string          `json:"message,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Workouts   []Workout       `json:"workouts"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Events     []Event         `json:"events,omitempty"`
+
+	// Request's fields (days, start, ...) appear inline in the JSON
+	Request
+
+	m    *Manager
+	subs []chan Event
+}
+
+// Finished reports whether the job is done
+func (j *Job) Finished() bool {
+	return j.State == StateSucceeded || j.State == StateFailed
+}
+
+// Manager queues jobs and keeps the most recent ones
+type Manager struct {
+	path string
+	keep int
+
+	mu    sync.Mutex
+	jobs  []*Job // oldest first
+	queue chan *Job
+}
+
+// NewManager loads the jobs retained in path. Jobs interrupted by a
+// restart are marked as failed.
+func NewManager(path string, keep int) *Manager {
+	if keep < 1 {
+		keep = 1
+	}
+	m := &Manager{path: path, keep: keep, queue: make(chan *Job, 16)}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &m.jobs); err != nil {
+			slog.Warn("Ignoring unreadable jobs file", "path", path, "error", err)
+			m.jobs = nil
+		}
+	}
+	now := time.Now()
+	for _, j := range m.jobs {
+		j.m = m
+		if !j.Finished() {
+			j.State = StateFailed
+			j.Message = "interrupted by a restart"
+			j.FinishedAt = &now
+		}
+	}
+	return m
+}
+
+// Enqueue adds a job to the queue
+func (m *Manager) Enqueue(trigger string, req Request) (*Job, error) {
+	id := make([]byte, 8)
+	rand.Read(id)
+	j := &Job{
+		ID:        hex.EncodeToString(id),
+		Trigger:   trigger,
+		Request:   req,
+		State:     StateQueued,
+		CreatedAt: time.Now(),
+		Workouts:  []Workout{},
+		m:         m,
+	}
+
+	m.mu.Lock()
+	select {
+	case m.queue <- j:
+	default:
+		m.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	m.jobs = append(m.jobs, j)
+	j.emit(Event{Type: EventState, State: StateQueued, Message: req.Describe() + " queued"})
+	m.saveLocked()
+	m.mu.Unlock()
+	return j, nil
+}
+
+// Run executes queued jobs one at a time until done is closed. run
+// performs the sync and returns its result, which is stored as JSON.
+func (m *Manager) Run(done <-chan struct{}, run func(j *Job) (result any, success bool, message string)) {
+	for {
+		select {
+		case <-done:
+			return
+		case j := <-m.queue:
+			m.start(j)
+			result, success, message := run(j)
+			m.finish(j, result, success, message)
+		}
+	}
+}
+
+func (m *Manager) start(j *Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	j.State = StateRunning
+	j.StartedAt = &now
+	j.emit(Event{Type: EventState, State: StateRunning, Message: "Sync started"})
+	m.saveLocked()
+}
+
+func (m *Manager) finish(j *Job, result any, success bool, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	j.State = StateFailed
+	if success {
+		j.State = StateSucceeded
+	}
+	j.Message = message
+	j.FinishedAt = &now
+	if data, err := json.Marshal(result); err == nil {
+		j.Result = data
+	}
+	j.emit(Event{Type: EventState, State: j.State, Message: message})
+	for _, ch := range j.subs {
+		close(ch)
+	}
+	j.subs = nil
+	m.saveLocked()
+}
+
+// Busy reports whether a job is queued or running
+func (m *Manager) Busy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		if !j.Finished() {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait blocks until a job finishes and returns it
+func (m *Manager) Wait(ctx context.Context, id string) (*Job, error) {
+	for {
+		_, events, cancel, ok := m.Subscribe(id, math.MaxInt)
+		if !ok {
+			return nil, fmt.Errorf("job %s not found", id)
+		}
+		if events == nil {
+			if job, ok := m.Get(id); ok {
+				return job, nil
+			}
+			return nil, fmt.Errorf("job %s is no longer retained", id)
+		}
+		// The channel closes when the job finishes (or, rarely, when we
+		// fall behind, in which case we subscribe again)
+		for open := true; open; {
+			select {
+			case _, open = <-events:
+			case <-ctx.Done():
+				cancel()
+				return nil, ctx.Err()
+			}
+		}
+		cancel()
+	}
+}
+
+// Get returns a copy of a job
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j := m.find(id); j != nil {
+		return j.clone(), true
+	}
+	return nil, false
+}
+
+// List returns copies of the retained jobs, newest first, without events
+func (m *Manager) List() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]*Job, 0, len(m.jobs))
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		c := m.jobs[i].clone()
+		c.Events = nil
+		list = append(list, c)
+	}
+	return list
+}
+
+// Subscribe returns the events of a job after the given event ID and, if
+// the job is still running, a channel delivering further events. The
+// channel is closed when the job finishes or the subscriber falls behind;
+// cancel releases it.
+func (m *Manager) Subscribe(id string, after int) (past []Event, events <-chan Event, cancel func(), ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j := m.find(id)
+	if j == nil {
+		return nil, nil, nil, false
+	}
+	for _, ev := range j.Events {
+		if ev.ID > after {
+			past = append(past, ev)
+		}
+	}
+	if j.Finished() {
+		return past, nil, func() {}, true
+	}
+
+	ch := make(chan Event, 64)
+	j.subs = append(j.subs, ch)
+	cancel = func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, sub := range j.subs {
+			if sub == ch {
+				j.subs = append(j.subs[:i], j.subs[i+1:]...)
+				close(ch)
+				return
+			}
+		}
+	}
+	return past, ch, cancel, true
+}
+
+func (m *Manager) find(id string) *Job {
+	for _, j := range m.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// saveLocked drops the oldest finished jobs beyond the retention limit and
+// writes the rest to disk. The caller holds m.mu.
+func (m *Manager) saveLocked() {
+	for excess := len(m.jobs) - m.keep; excess > 0; excess-- {
+		dropped := false
+		for i, j := range m.jobs {
+			if j.Finished() {
+				m.jobs = append(m.jobs[:i], m.jobs[i+1:]...)
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			break
+		}
+	}
+
+	if m.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(m.jobs, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(m.path), 0700); err == nil {
+			err = os.WriteFile(m.path, data, 0600)
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to save jobs", "error", err)
+	}
+}
+
+// emit appends an event and sends it to subscribers. The caller holds m.mu.
+func (j *Job) emit(ev Event) {
+	ev.ID = 1
+	if n := len(j.Events); n > 0 {
+		ev.ID = j.Events[n-1].ID + 1
+	}
+	ev.Time = time.Now()
+	if len(j.Events) >= maxEvents {
+		j.Events = j.Events[1:]
+	}
+	j.Events = append(j.Events, ev)
+
+	kept := j.subs[:0]
+	for _, ch := range j.subs {
+		select {
+		case ch <- ev:
+			kept = append(kept, ch)
+		default:
+			// Too slow; the client reconnects with Last-Event-ID
+			close(ch)
+		}
+	}
+	j.subs = kept
+}
+
+func (j *Job) clone() *Job {
+	c := *j
+	c.m, c.subs = nil, nil
+	c.Workouts = make([]Workout, len(j.Workouts))
+	for i, w := range j.Workouts {
+		c.Workouts[i] = w.clone()
+	}
+	c.Events = append([]Event(nil), j.Events...)
+	return &c
+}
+
+func (w Workout) clone() Workout {
+	if w.Destinations != nil {
+		dests := make(map[string]string, len(w.Destinations))
+		for k, v := range w.Destinations {
+			dests[k] = v
+		}
+		w.Destinations = dests
+	}
+	return w
+}
+
+// workout returns the progress entry of a workout, adding it if needed.
+// The caller holds m.mu.
+func (j *Job) workout(w *models.Workout) *Workout {
+	for i := range j.Workouts {
+		if j.Workouts[i].ID == w.ID {
+			return &j.Workouts[i]
+		}
+	}
+	j.Workouts = append(j.Workouts, Workout{ID: w.ID, Date: w.Date.Format("2006-01-02"), Name: w.Name})
+	return &j.Workouts[len(j.Workouts)-1]
+}
+
+// The progress methods below may be called on a nil job (syncs not started
+// through the queue), in which case they do nothing.
+
+// Logf records a progress message
+func (j *Job) Logf(format string, args ...interface{}) {
+	if j == nil {
+		return
+	}
+	j.m.mu.Lock()
+	defer j.m.mu.Unlock()
+	j.emit(Event{Type: EventLog, Message: fmt.Sprintf(format, args...)})
+}
+
+// Fetched records the workouts fetched from Aimharder
+func (j *Job) Fetched(workouts []models.Workout) {
+	if j == nil {
+		return
+	}
+	j.m.mu.Lock()
+	defer j.m.mu.Unlock()
+	for i := range workouts {
+		p := j.workout(&workouts[i])
+		p.Stage = "fetched"
+		snapshot := p.clone()
+		j.emit(Event{Type: EventFetched, Workout: &snapshot})
+	}
+}
+
+// Generated records the activity file generated for a workout
+func (j *Job) Generated(w *models.Workout, file string, err error) {
+	if j == nil {
+		return
+	}
+	j.m.mu.Lock()
+	defer j.m.mu.Unlock()
+	p := j.workout(w)
+	ev := Event{Type: EventGenerated}
+	if err != nil {
+		p.Error = err.Error()
+		ev.Error = p.Error
+	} else {
+		p.Stage = "generated"
+		p.File = filepath.Base(file)
+	}
+	snapshot := p.clone()
+	ev.Workout = &snapshot
+	j.emit(ev)
+}
+
+// Synced returns a destination progress callback recording each workout's
+// outcome on platform, or nil for a nil job
+func (j *Job) Synced(platform string) func(w *models.Workout, d destination.Decision) {
+	if j == nil {
+		return nil
+	}
+	return func(w *models.Workout, d destination.Decision) {
+		j.m.mu.Lock()
+		defer j.m.mu.Unlock()
+		p := j.workout(w)
+		p.Stage = "synced"
+		if p.Destinations == nil {
+			p.Destinations = make(map[string]string)
+		}
+		p.Destinations[platform] = d.Outcome
+		ev := Event{Type: EventSynced, Platform: platform, Outcome: d.Outcome, Reason: d.Reason}
+		if d.Err != nil {
+			ev.Error = d.Err.Error()
+		}
+		snapshot := p.clone()
+		ev.Workout = &snapshot
+		j.emit(ev)
+	}
+}
@@ -0,0 +1,97 @@
+// Package logging sets up the log/slog logger every package writes to. In a
+// terminal records are rendered for people, one line each with an icon;
+// otherwise they are logfmt text or JSON lines for log collectors. In every
+// format, cookies, tokens, passwords and email addresses are redacted.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Formats
+const (
+	FormatAuto   = "auto"   // pretty in a terminal, text otherwise
+	FormatPretty = "pretty" // one line with an icon per record
+	FormatText   = "text"   // logfmt
+	FormatJSON   = "json"   // JSON lines
+)
+
+// Formats lists the accepted formats
+var Formats = []string{FormatAuto, FormatPretty, FormatText, FormatJSON}
+
+// Attribute keys the pretty format shows as part of the line rather than as
+// key=value; text and JSON keep component and drop icon
+const (
+	ComponentKey = "component"
+	IconKey      = "icon"
+)
+
+// Icon is the emoji shown before the message in the pretty format
+func Icon(icon string) slog.Attr {
+	return slog.String(IconKey, icon)
+}
+
+// Component returns a logger whose records belong to a part of the program,
+// e.g. "webhook" or "scheduler"
+func Component(name string) *slog.Logger {
+	return slog.Default().With(ComponentKey, name)
+}
+
+// Or returns logger, or the default logger when it's nil
+func Or(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+// NewHandler returns a redacting handler writing records at level or above
+// to w in format
+func NewHandler(w io.Writer, format, level string) (slog.Handler, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q (expected debug, info, warn or error)", level)
+	}
+
+	if format == FormatAuto || format == "" {
+		format = FormatText
+		if IsTerminal(w) {
+			format = FormatPretty
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case FormatPretty:
+		return &redactHandler{next: newPrettyHandler(w, lvl), keepIcons: true}, nil
+	case FormatText:
+		return &redactHandler{next: slog.NewTextHandler(w, opts)}, nil
+	case FormatJSON:
+		return &redactHandler{next: slog.NewJSONHandler(w, opts)}, nil
+	}
+	return nil, fmt.Errorf("invalid log format %q (expected %s)", format, strings.Join(Formats, ", "))
+}
+
+// Setup makes the default logger write to stderr in format at level
+func Setup(format, level string) error {
+	handler, err := NewHandler(os.Stderr, format, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// IsTerminal reports whether w is an interactive terminal
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
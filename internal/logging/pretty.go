@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// levelIcons are shown for records without an icon
+var levelIcons = map[slog.Level]string{
+	slog.LevelDebug: "  →",
+	slog.LevelInfo:  "ℹ️ ",
+	slog.LevelWarn:  "⚠️ ",
+	slog.LevelError: "❌",
+}
+
+// prettyHandler writes a line per record for people: the component in
+// brackets, an icon, the message and the remaining attributes as key=value
+type prettyHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Level
+	attrs  []slog.Attr
+	groups string // prefix of attribute keys, e.g. "request."
+}
+
+func newPrettyHandler(w io.Writer, level slog.Level) *prettyHandler {
+	return &prettyHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	var component, icon string
+	var fields strings.Builder
+	add := func(prefix string, a slog.Attr) {
+		switch a.Key {
+		case ComponentKey:
+			component = a.Value.String()
+		case IconKey:
+			icon = a.Value.String()
+		default:
+			writeAttr(&fields, prefix, a)
+		}
+	}
+	// Attributes from WithAttrs already carry their groups
+	for _, a := range h.attrs {
+		add("", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		add(h.groups, a)
+		return true
+	})
+
+	if icon == "" {
+		icon = levelIcons[r.Level]
+		if icon == "" {
+			icon = levelIcons[slog.LevelInfo]
+		}
+	}
+
+	var line strings.Builder
+	if component != "" {
+		line.WriteString("[" + component + "] ")
+	}
+	line.WriteString(icon + " " + r.Message)
+	line.WriteString(fields.String())
+	line.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line.String())
+	return err
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(clone.attrs[:len(clone.attrs):len(clone.attrs)], qualify(h.groups, attrs)...)
+	return &clone
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.groups += name + "."
+	return &clone
+}
+
+// qualify prefixes attribute keys with the open groups, so attributes added
+// before a group was opened keep their own keys
+func qualify(groups string, attrs []slog.Attr) []slog.Attr {
+	if groups == "" {
+		return attrs
+	}
+	qualified := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		if a.Key != ComponentKey && a.Key != IconKey {
+			a.Key = groups + a.Key
+		}
+		qualified[i] = a
+	}
+	return qualified
+}
+
+// writeAttr writes " key=value", flattening groups into dotted keys
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, attr := range a.Value.Group() {
+			writeAttr(b, prefix, attr)
+		}
+		return
+	}
+	value := fmt.Sprint(a.Value.Any())
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
+}
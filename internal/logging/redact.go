@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces secrets and personal data in log output
+const Redacted = "[REDACTED]"
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+
+	// secretPattern matches key=value and "key: value" pairs of secrets, as
+	// in URLs, form bodies, cookies and headers
+	secretPattern = regexp.MustCompile(`(?i)\b([a-z_\-]*(?:token|secret|password|passwd)|pw|api_?key|amhrdrauth|cookie|set-cookie|authorization|x-auth-signature)(=|:\s*)("?)[^\s&;",]+`)
+
+	// codePattern matches OAuth codes in URLs; "status code: 404" is no secret
+	codePattern = regexp.MustCompile(`([?&]code=)[^\s&"]+`)
+)
+
+// sensitiveKeys are attribute key parts whose values are always redacted
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "cookie", "authorization", "api_key", "apikey", "signature"}
+
+// Redact removes secrets and email addresses from s
+func Redact(s string) string {
+	s = emailPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = codePattern.ReplaceAllString(s, "${1}"+Redacted)
+	return secretPattern.ReplaceAllString(s, "$1$2$3"+Redacted)
+}
+
+// sensitiveKey reports whether an attribute holds a secret by its key
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "pw" || key == "key" || key == "auth" {
+		return true
+	}
+	return slices.ContainsFunc(sensitiveKeys, func(part string) bool { return strings.Contains(key, part) })
+}
+
+// redactAttr redacts an attribute's value, recursing into groups
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if sensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Redact(a.Value.String()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			redacted[i] = redactAttr(attr)
+		}
+		a.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+// redactHandler redacts records before passing them on. Icons are only
+// passed on to the pretty handler.
+type redactHandler struct {
+	next      slog.Handler
+	keepIcons bool
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key != IconKey || h.keepIcons {
+			redacted.AddAttrs(redactAttr(a))
+		}
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var redacted []slog.Attr
+	for _, a := range attrs {
+		if a.Key != IconKey || h.keepIcons {
+			redacted = append(redacted, redactAttr(a))
+		}
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), keepIcons: h.keepIcons}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), keepIcons: h.keepIcons}
+}
@@ -0,0 +1,298 @@
+// Package metrics keeps counters, gauges and histograms in memory and
+// exposes them in the Prometheus text format, for the webhook server's
+// /metrics endpoint.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are histogram buckets in seconds, suited to HTTP requests
+var DefBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]collector)
+)
+
+func register(name string, c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry[name] = c
+}
+
+// family holds the series of one metric, keyed by label values
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labels  []string
+	value   float64
+	buckets []uint64 // histograms only
+	count   uint64
+}
+
+func newFamily(name, help, kind string, labels []string) *family {
+	return &family{name: name, help: help, kind: kind, labels: labels, series: make(map[string]*series)}
+}
+
+// get returns the series for the label values, creating it if needed.
+// The caller holds f.mu.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: append([]string(nil), values...)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by label values, for stable output.
+// The caller holds f.mu.
+func (f *family) sorted() []*series {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := make([]*series, len(keys))
+	for i, key := range keys {
+		list[i] = f.series[key]
+	}
+	return list
+}
+
+func (f *family) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// Counter is a monotonically increasing value, partitioned by labels
+type Counter struct{ f *family }
+
+// NewCounter registers a counter
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{newFamily(name, help, "counter", labels)}
+	register(name, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v (which must not be negative) to the series
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	c.f.mu.Lock()
+	c.f.get(values).value += v
+	c.f.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.header(w)
+	for _, s := range c.f.sorted() {
+		writeSample(w, c.f.name, c.f.labels, s.labels, "", "", s.value)
+	}
+}
+
+// Gauge is a value that can go up and down, partitioned by labels
+type Gauge struct{ f *family }
+
+// NewGauge registers a gauge
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newFamily(name, help, "gauge", labels)}
+	register(name, g)
+	return g
+}
+
+// Set sets the series with the given label values
+func (g *Gauge) Set(v float64, values ...string) {
+	g.f.mu.Lock()
+	g.f.get(values).value = v
+	g.f.mu.Unlock()
+}
+
+// Value returns the current value of a series (0 if never set)
+func (g *Gauge) Value(values ...string) float64 {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	return g.f.get(values).value
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	g.f.header(w)
+	for _, s := range g.f.sorted() {
+		writeSample(w, g.f.name, g.f.labels, s.labels, "", "", s.value)
+	}
+}
+
+// GaugeFunc is a gauge computed when metrics are scraped
+type GaugeFunc struct {
+	f     *family
+	value func() (float64, bool)
+}
+
+// NewGaugeFunc registers a gauge whose value is computed on every scrape.
+// value returns false to omit the sample (e.g. before the first sync).
+func NewGaugeFunc(name, help string, value func() (float64, bool)) *GaugeFunc {
+	g := &GaugeFunc{f: newFamily(name, help, "gauge", nil), value: value}
+	register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.f.header(w)
+	if v, ok := g.value(); ok {
+		writeSample(w, g.f.name, nil, nil, "", "", v)
+	}
+}
+
+// Histogram counts observations in buckets, partitioned by labels
+type Histogram struct {
+	f       *family
+	buckets []float64
+}
+
+// NewHistogram registers a histogram with the given upper bounds
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{f: newFamily(name, help, "histogram", labels), buckets: buckets}
+	register(name, h)
+	return h
+}
+
+// Observe records a value in the series with the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(values)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(h.buckets))
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	h.f.header(w)
+	for _, s := range h.f.sorted() {
+		for i, upper := range h.buckets {
+			writeSample(w, h.f.name+"_bucket", h.f.labels, s.labels, "le", formatFloat(upper), float64(s.buckets[i]))
+		}
+		writeSample(w, h.f.name+"_bucket", h.f.labels, s.labels, "le", "+Inf", float64(s.count))
+		writeSample(w, h.f.name+"_sum", h.f.labels, s.labels, "", "", s.value)
+		writeSample(w, h.f.name+"_count", h.f.labels, s.labels, "", "", float64(s.count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// Write writes all registered metrics in the Prometheus text format
+func Write(out io.Writer) error {
+	registryMu.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	collectors := make([]collector, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		collectors[i] = registry[name]
+	}
+	registryMu.Unlock()
+
+	w := bufio.NewWriter(out)
+	for _, c := range collectors {
+		c.write(w)
+	}
+	return w.Flush()
+}
+
+// Handler serves the registered metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sync metrics
+var (
+	SyncsTotal = NewCounter("aimharder_sync_syncs_total",
+		"Syncs run, by result (success or failure).", "result")
+	SyncDuration = NewHistogram("aimharder_sync_sync_duration_seconds",
+		"Duration of syncs.", []float64{1, 5, 10, 30, 60, 120, 300, 600})
+	WorkoutsFetched = NewCounter("aimharder_sync_workouts_fetched_total",
+		"Workouts fetched from sources.", "source")
+	Uploads = NewCounter("aimharder_sync_uploads_total",
+		"Workouts sent to destinations, by platform and result (uploaded, updated, skipped or failed).", "platform", "result")
+	LoginAttempts = NewCounter("aimharder_sync_login_attempts_total",
+		"Logins to Aimharder and Strava token refreshes, by service and result.", "service", "result")
+	LastSuccess = NewGauge("aimharder_sync_last_success_timestamp_seconds",
+		"Unix time of the last successful sync.")
+)
+
+func init() {
+	NewGaugeFunc("aimharder_sync_last_success_age_seconds",
+		"Seconds since the last successful sync; absent until one succeeded.",
+		func() (float64, bool) {
+			last := LastSuccess.Value()
+			if last == 0 {
+				return 0, false
+			}
+			return float64(time.Now().Unix()) - last, true
+		})
+}
+
+// HTTP client metrics
+var (
+	HTTPRequests = NewCounter("aimharder_sync_http_requests_total",
+		"Requests to external services, by service and status code (\"error\" if no response).", "service", "code")
+	HTTPDuration = NewHistogram("aimharder_sync_http_request_duration_seconds",
+		"Latency of requests to external services.", DefBuckets, "service")
+	StravaRateLimit = NewGauge("aimharder_sync_strava_rate_limit",
+		"Strava API request limit, by window (15m or daily).", "window")
+	StravaRateLimitUsage = NewGauge("aimharder_sync_strava_rate_limit_usage",
+		"Strava API requests used in the current window (15m or daily).", "window")
+)
+
+// RecordSync records a finished sync
+func RecordSync(success bool, duration time.Duration, finished time.Time) {
+	if success {
+		SyncsTotal.Inc("success")
+		SetLastSuccess(finished)
+	} else {
+		SyncsTotal.Inc("failure")
+	}
+	SyncDuration.Observe(duration.Seconds())
+}
+
+var lastSuccessMu sync.Mutex
+
+// SetLastSuccess records a successful sync, unless a later one is known
+func SetLastSuccess(t time.Time) {
+	lastSuccessMu.Lock()
+	defer lastSuccessMu.Unlock()
+	if v := float64(t.Unix()); v > LastSuccess.Value() {
+		LastSuccess.Set(v)
+	}
+}
+
+// RecordLogin records a login attempt to a service
+func RecordLogin(service string, err error) {
+	if err != nil {
+		LoginAttempts.Inc(service, "failure")
+	} else {
+		LoginAttempts.Inc(service, "success")
+	}
+}
+
+// Transport instruments requests to an external service with latency and
+// status code metrics. base defaults to http.DefaultTransport.
+func Transport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{service: service, base: base}
+}
+
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	HTTPDuration.Observe(time.Since(start).Seconds(), t.service)
+	if err != nil {
+		HTTPRequests.Inc(t.service, "error")
+		return resp, err
+	}
+	HTTPRequests.Inc(t.service, strconv.Itoa(resp.StatusCode))
+	return resp, nil
+}
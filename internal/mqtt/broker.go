@@ -0,0 +1,283 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Broker is a minimal in-process MQTT broker for trying the Home Assistant
+// integration without Mosquitto. It accepts any client, keeps retained
+// messages in memory, delivers at QoS 0 and publishes last wills. It is
+// not meant to replace a real broker.
+type Broker struct {
+	// Logf, if set, is called for connections and every published message
+	Logf func(format string, args ...interface{})
+
+	mu       sync.Mutex
+	conns    map[*brokerConn]struct{}
+	retained map[string]Message
+}
+
+type brokerConn struct {
+	conn     net.Conn
+	clientID string
+	will     *Message
+
+	writeMu sync.Mutex
+
+	mu   sync.Mutex
+	subs []string
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{
+		conns:    make(map[*brokerConn]struct{}),
+		retained: make(map[string]Message),
+	}
+}
+
+// ListenAndServe accepts clients on addr (e.g. ":1883") until ctx is done
+func (b *Broker) ListenAndServe(ctx context.Context, addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	err = b.Serve(l)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// Serve accepts clients on l until it is closed
+func (b *Broker) Serve(l net.Listener) error {
+	defer b.closeAll()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go b.handle(conn)
+	}
+}
+
+// Retained returns the retained message on a topic
+func (b *Broker) Retained(topic string) (Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m, ok := b.retained[topic]
+	return m, ok
+}
+
+func (b *Broker) logf(format string, args ...interface{}) {
+	if b.Logf != nil {
+		b.Logf(format, args...)
+	}
+}
+
+func (b *Broker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	p, err := readPacket(r)
+	if err != nil || p.kind != packetConnect {
+		return
+	}
+	c, keepAlive, err := decodeConnect(p)
+	if err != nil {
+		return
+	}
+	c.conn = conn
+	c.send(encode(packetConnack, 0, []byte{0, 0}))
+
+	b.mu.Lock()
+	b.conns[c] = struct{}{}
+	b.mu.Unlock()
+	b.logf("🔌 %s connected from %s\n", c.clientID, conn.RemoteAddr())
+
+	clean := false
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, c)
+		b.mu.Unlock()
+		if !clean && c.will != nil {
+			b.publish(*c.will)
+		}
+		b.logf("🔌 %s disconnected\n", c.clientID)
+	}()
+
+	for {
+		if keepAlive > 0 {
+			conn.SetReadDeadline(time.Now().Add(keepAlive * 3 / 2))
+		} else {
+			conn.SetReadDeadline(time.Time{})
+		}
+		p, err := readPacket(r)
+		if err != nil {
+			return
+		}
+
+		switch p.kind {
+		case packetPublish:
+			m, id, err := decodePublish(p)
+			if err != nil {
+				return
+			}
+			if m.QoS == 1 {
+				c.send(encode(packetPuback, 0, binary.BigEndian.AppendUint16(nil, id)))
+			}
+			b.publish(m)
+		case packetSubscribe:
+			rd := &reader{buf: p.body}
+			id := rd.uint16()
+			ack := binary.BigEndian.AppendUint16(nil, id)
+			var filters []string
+			for rd.err == nil && len(rd.buf) > 0 {
+				filter := rd.string()
+				rd.byte()
+				filters = append(filters, filter)
+				ack = append(ack, 0) // granted QoS 0
+			}
+			if rd.err != nil {
+				return
+			}
+			c.mu.Lock()
+			c.subs = append(c.subs, filters...)
+			c.mu.Unlock()
+			c.send(encode(packetSuback, 0, ack))
+			b.sendRetained(c, filters)
+		case packetUnsubscribe:
+			rd := &reader{buf: p.body}
+			id := rd.uint16()
+			for rd.err == nil && len(rd.buf) > 0 {
+				c.unsubscribe(rd.string())
+			}
+			c.send(encode(packetUnsuback, 0, binary.BigEndian.AppendUint16(nil, id)))
+		case packetPingreq:
+			c.send(encode(packetPingresp, 0, nil))
+		case packetDisconnect:
+			clean = true
+			return
+		}
+	}
+}
+
+// decodeConnect parses a CONNECT packet
+func decodeConnect(p *packet) (*brokerConn, time.Duration, error) {
+	r := &reader{buf: p.body}
+	r.string() // protocol name
+	r.byte()   // protocol level
+	flags := r.byte()
+	keepAlive := time.Duration(r.uint16()) * time.Second
+
+	c := &brokerConn{clientID: r.string()}
+	if flags&0x04 != 0 {
+		c.will = &Message{
+			Topic:   r.string(),
+			Payload: append([]byte(nil), r.bytes()...),
+			Retain:  flags&0x20 != 0,
+		}
+	}
+	return c, keepAlive, r.err
+}
+
+// publish stores retained messages and delivers m to matching subscribers
+func (b *Broker) publish(m Message) {
+	retained := ""
+	if m.Retain {
+		retained = " (retained)"
+	}
+	b.logf("📨 %s%s: %s\n", m.Topic, retained, m.Payload)
+
+	b.mu.Lock()
+	if m.Retain {
+		if len(m.Payload) == 0 {
+			delete(b.retained, m.Topic)
+		} else {
+			b.retained[m.Topic] = m
+		}
+	}
+	conns := make([]*brokerConn, 0, len(b.conns))
+	for c := range b.conns {
+		conns = append(conns, c)
+	}
+	b.mu.Unlock()
+
+	// Live deliveries clear the retain flag, as retained messages are only
+	// flagged when sent on subscription
+	delivery := Message{Topic: m.Topic, Payload: m.Payload}
+	for _, c := range conns {
+		if c.subscribed(m.Topic) {
+			c.send(encodePublish(delivery, 0))
+		}
+	}
+}
+
+func (b *Broker) sendRetained(c *brokerConn, filters []string) {
+	b.mu.Lock()
+	var messages []Message
+	for topic, m := range b.retained {
+		for _, f := range filters {
+			if Match(f, topic) {
+				messages = append(messages, m)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for _, m := range messages {
+		c.send(encodePublish(Message{Topic: m.Topic, Payload: m.Payload, Retain: true}, 0))
+	}
+}
+
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.conns {
+		c.conn.Close()
+	}
+}
+
+func (c *brokerConn) send(p []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	c.conn.Write(p)
+}
+
+func (c *brokerConn) subscribed(topic string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range c.subs {
+		if Match(f, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *brokerConn) unsubscribe(filter string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, f := range c.subs {
+		if f == filter {
+			c.subs = append(c.subs[:i], c.subs[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// Package mqtt is a small MQTT 3.1.1 client (and a broker for local
+// testing) covering what Home Assistant integration needs: retained
+// QoS 0/1 publishes, subscriptions, keep-alive and a last will.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Options configures a client connection
+type Options struct {
+	// Broker is the broker URL: tcp://host:1883 (or mqtt://), or
+	// ssl://host:8883 (or mqtts://, tls://) for TLS
+	Broker    string
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration // default 30s
+
+	// Will is published by the broker if the connection drops
+	Will *Message
+
+	// TLSConfig overrides the TLS settings for ssl:// brokers
+	TLSConfig *tls.Config
+}
+
+// Handler is called for messages received on a subscription. Handlers
+// run on the connection's read loop and should return quickly.
+type Handler func(Message)
+
+type subscription struct {
+	filter  string
+	handler Handler
+}
+
+// Client is a connection to an MQTT broker
+type Client struct {
+	conn      net.Conn
+	keepAlive time.Duration
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	nextID   uint16
+	pending  map[uint16]chan *packet
+	subs     []subscription
+	err      error
+	done     chan struct{}
+	closeOne sync.Once
+}
+
+// Dial connects to the broker and completes the MQTT handshake
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	u, err := url.Parse(opts.Broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid broker URL %q (expected e.g. tcp://localhost:1883)", opts.Broker)
+	}
+
+	useTLS := false
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS = true
+		port = "8883"
+	default:
+		return nil, fmt.Errorf("unsupported broker scheme %q (use tcp:// or ssl://)", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	if useTLS {
+		tlsConfig := opts.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: u.Hostname()}
+		}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	c := &Client{
+		conn:      conn,
+		keepAlive: opts.KeepAlive,
+		pending:   make(map[uint16]chan *packet),
+		done:      make(chan struct{}),
+	}
+
+	if u.User != nil && opts.Username == "" {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+
+	r := bufio.NewReader(conn)
+	if err := c.connect(ctx, r, opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go c.readLoop(r)
+	go c.pingLoop()
+	return c, nil
+}
+
+// connect sends CONNECT and waits for the broker's CONNACK
+func (c *Client) connect(ctx context.Context, r *bufio.Reader, opts Options) error {
+	var flags byte = 0x02 // clean session
+	if opts.Will != nil {
+		flags |= 0x04 | opts.Will.QoS<<3
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags) // protocol level 4 = 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(c.keepAlive/time.Second))
+	body = appendString(body, opts.ClientID)
+	if opts.Will != nil {
+		body = appendString(body, opts.Will.Topic)
+		body = appendBytes(body, opts.Will.Payload)
+	}
+	if opts.Username != "" {
+		body = appendString(body, opts.Username)
+		if opts.Password != "" {
+			body = appendString(body, opts.Password)
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+	defer c.conn.SetDeadline(time.Time{})
+
+	if _, err := c.conn.Write(encode(packetConnect, 0, body)); err != nil {
+		return fmt.Errorf("failed to send CONNECT: %w", err)
+	}
+	p, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("failed to read CONNACK: %w", err)
+	}
+	if p.kind != packetConnack || len(p.body) < 2 {
+		return fmt.Errorf("unexpected packet type %d (expected CONNACK)", p.kind)
+	}
+	if code := p.body[1]; code != 0 {
+		msg, ok := connackErrors[code]
+		if !ok {
+			msg = fmt.Sprintf("return code %d", code)
+		}
+		return fmt.Errorf("broker refused connection: %s", msg)
+	}
+	return nil
+}
+
+// Publish sends a message. QoS 1 publishes wait for the broker's PUBACK.
+func (c *Client) Publish(ctx context.Context, m Message) error {
+	if m.QoS > 1 {
+		return errors.New("QoS 2 is not supported")
+	}
+	if m.QoS == 0 {
+		return c.write(encodePublish(m, 0))
+	}
+	id, ack := c.register()
+	defer c.unregister(id)
+	if err := c.write(encodePublish(m, id)); err != nil {
+		return err
+	}
+	_, err := c.wait(ctx, ack)
+	return err
+}
+
+// Subscribe registers handler for messages matching filter and waits for
+// the broker to acknowledge the subscription
+func (c *Client) Subscribe(ctx context.Context, filter string, qos byte, handler Handler) error {
+	c.mu.Lock()
+	c.subs = append(c.subs, subscription{filter: filter, handler: handler})
+	c.mu.Unlock()
+
+	id, ack := c.register()
+	defer c.unregister(id)
+
+	body := binary.BigEndian.AppendUint16(nil, id)
+	body = appendString(body, filter)
+	body = append(body, qos)
+	if err := c.write(encode(packetSubscribe, 0x02, body)); err != nil {
+		return err
+	}
+	p, err := c.wait(ctx, ack)
+	if err != nil {
+		return err
+	}
+	if len(p.body) < 3 || p.body[2] == 0x80 {
+		return fmt.Errorf("broker rejected subscription to %s", filter)
+	}
+	return nil
+}
+
+// Done is closed when the connection is lost or closed
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection was lost, or nil after Close
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close disconnects cleanly; the broker discards the last will
+func (c *Client) Close() error {
+	c.write(encode(packetDisconnect, 0, nil))
+	c.shutdown(nil)
+	return nil
+}
+
+func (c *Client) write(b []byte) error {
+	select {
+	case <-c.done:
+		if err := c.Err(); err != nil {
+			return err
+		}
+		return errors.New("connection closed")
+	default:
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(b); err != nil {
+		c.shutdown(fmt.Errorf("failed to write to MQTT broker: %w", err))
+		return err
+	}
+	return nil
+}
+
+// register allocates a packet identifier for an acknowledged request
+func (c *Client) register() (uint16, chan *packet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		c.nextID++
+		if c.nextID == 0 {
+			continue
+		}
+		if _, taken := c.pending[c.nextID]; !taken {
+			break
+		}
+	}
+	ch := make(chan *packet, 1)
+	c.pending[c.nextID] = ch
+	return c.nextID, ch
+}
+
+func (c *Client) unregister(id uint16) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *Client) wait(ctx context.Context, ack chan *packet) (*packet, error) {
+	select {
+	case p := <-ack:
+		return p, nil
+	case <-c.done:
+		if err := c.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("connection closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Client) readLoop(r *bufio.Reader) {
+	for {
+		// The broker answers pings, so silence beyond 1.5x the keep-alive
+		// means the connection is dead
+		c.conn.SetReadDeadline(time.Now().Add(c.keepAlive * 3 / 2))
+		p, err := readPacket(r)
+		if err != nil {
+			c.shutdown(fmt.Errorf("MQTT connection lost: %w", err))
+			return
+		}
+
+		switch p.kind {
+		case packetPublish:
+			m, id, err := decodePublish(p)
+			if err != nil {
+				c.shutdown(fmt.Errorf("malformed PUBLISH: %w", err))
+				return
+			}
+			if m.QoS == 1 {
+				c.write(encode(packetPuback, 0, binary.BigEndian.AppendUint16(nil, id)))
+			}
+			c.dispatch(m)
+		case packetPuback, packetSuback, packetUnsuback:
+			if len(p.body) < 2 {
+				continue
+			}
+			id := binary.BigEndian.Uint16(p.body)
+			c.mu.Lock()
+			ch := c.pending[id]
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- p
+			}
+		}
+	}
+}
+
+func (c *Client) dispatch(m Message) {
+	c.mu.Lock()
+	var handlers []Handler
+	for _, s := range c.subs {
+		if Match(s.filter, m.Topic) {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, h := range handlers {
+		h(m)
+	}
+}
+
+func (c *Client) pingLoop() {
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.write(encode(packetPingreq, 0, nil))
+		}
+	}
+}
+
+func (c *Client) shutdown(err error) {
+	c.closeOne.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+		c.conn.Close()
+	})
+}
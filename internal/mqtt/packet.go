@@ -0,0 +1,219 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// maxPacketSize bounds the remaining length accepted from peers
+const maxPacketSize = 1 << 20
+
+// connackErrors describes CONNACK return codes
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Message is an application message
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+	QoS     byte
+}
+
+// packet is a decoded control packet: the fixed header plus the raw
+// variable header and payload
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+func readPacket(r *bufio.Reader) (*packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	// Remaining length is a variable byte integer of up to 4 bytes
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("packet too large (%d bytes)", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &packet{kind: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+// encode builds a packet with the given type, flags and body
+func encode(kind, flags byte, body []byte) []byte {
+	buf := []byte{kind<<4 | flags}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(buf, body...)
+}
+
+func appendString(buf []byte, s string) []byte {
+	return appendBytes(buf, []byte(s))
+}
+
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(b)))
+	return append(buf, b...)
+}
+
+// reader consumes the fields of a packet body
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) uint16() uint16 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.buf) < 2 {
+		r.err = errors.New("packet truncated")
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.buf)
+	r.buf = r.buf[2:]
+	return v
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.buf) < 1 {
+		r.err = errors.New("packet truncated")
+		return 0
+	}
+	v := r.buf[0]
+	r.buf = r.buf[1:]
+	return v
+}
+
+func (r *reader) bytes() []byte {
+	n := int(r.uint16())
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = errors.New("packet truncated")
+		return nil
+	}
+	v := r.buf[:n]
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *reader) string() string {
+	return string(r.bytes())
+}
+
+// encodePublish builds a PUBLISH packet; id is only written for QoS > 0
+func encodePublish(m Message, id uint16) []byte {
+	flags := m.QoS << 1
+	if m.Retain {
+		flags |= 0x01
+	}
+	body := appendString(nil, m.Topic)
+	if m.QoS > 0 {
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	return encode(packetPublish, flags, append(body, m.Payload...))
+}
+
+// decodePublish parses a PUBLISH packet, returning the message and its
+// packet identifier (0 for QoS 0)
+func decodePublish(p *packet) (Message, uint16, error) {
+	r := &reader{buf: p.body}
+	m := Message{
+		Topic:  r.string(),
+		QoS:    (p.flags >> 1) & 0x03,
+		Retain: p.flags&0x01 != 0,
+	}
+	var id uint16
+	if m.QoS > 0 {
+		id = r.uint16()
+	}
+	if r.err != nil {
+		return m, 0, r.err
+	}
+	m.Payload = append([]byte(nil), r.buf...)
+	return m, id, nil
+}
+
+// Match reports whether a topic matches a subscription filter, which may
+// use the "+" (one level) and "#" (all remaining levels) wildcards
+func Match(filter, topic string) bool {
+	// Wildcards don't match topics starting with "$" (e.g. $SYS)
+	if strings.HasPrefix(topic, "$") && !strings.HasPrefix(filter, "$") {
+		return false
+	}
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) {
+			return false
+		}
+		if level != "+" && level != t[i] {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
@@ -0,0 +1,351 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
+)
+
+// Outcome summarizes a finished sync
+type Outcome struct {
+	Success  bool
+	Message  string
+	Failures []Failure
+
+	// Authenticated lists the sources and destinations that logged in
+	// fine, which resets their authentication error streaks
+	Authenticated []string
+
+	// NewWorkouts were uploaded for the first time, to Platforms
+	NewWorkouts []models.Workout
+	Platforms   []string
+}
+
+type channel struct {
+	name     string
+	notifier Notifier
+	events   map[string]bool // nil means all events
+	template string
+}
+
+// Dispatcher renders events and sends them to the configured channels,
+// skipping messages already sent within the de-duplication window
+type Dispatcher struct {
+	// Logger receives delivery progress (default: slog.Default())
+	Logger *slog.Logger
+
+	cfg      config.NotifyConfig
+	renderer *render.Renderer
+	events   map[string]bool
+	channels []channel
+}
+
+// NewDispatcher creates the notifiers for all configured channels
+func NewDispatcher(cfg config.NotifyConfig, renderer *render.Renderer) (*Dispatcher, error) {
+	events, err := eventSet(cfg.Events)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dispatcher{cfg: cfg, renderer: renderer, events: events}
+
+	for _, ch := range cfg.Channels {
+		notifier, err := New(ch)
+		if err != nil {
+			return nil, err
+		}
+		chEvents, err := eventSet(ch.Events)
+		if err != nil {
+			return nil, fmt.Errorf("notification channel %q: %w", channelName(ch), err)
+		}
+		tmpl := ch.Template
+		if tmpl == "" {
+			tmpl = render.Notify
+		}
+		if !renderer.Has(tmpl) {
+			return nil, fmt.Errorf("notification channel %q uses unknown template %q", channelName(ch), tmpl)
+		}
+		d.channels = append(d.channels, channel{name: channelName(ch), notifier: notifier, events: chEvents, template: tmpl})
+	}
+	return d, nil
+}
+
+func eventSet(names []string) (map[string]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	set := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		valid := false
+		for _, e := range Events {
+			valid = valid || e == name
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown notification event %q (available: %s)", name, strings.Join(Events, ", "))
+		}
+		set[name] = true
+	}
+	return set, nil
+}
+
+// Enabled reports whether any channel is configured
+func (d *Dispatcher) Enabled() bool {
+	return len(d.channels) > 0
+}
+
+// Sync sends the notifications for a finished sync
+func (d *Dispatcher) Sync(ctx context.Context, o Outcome) error {
+	now := time.Now()
+	state := loadState(d.cfg.StateFile)
+	state.prune(now, d.cfg.DedupeWindow)
+
+	var errs []error
+	for _, p := range d.plan(o, state, now) {
+		if err := d.deliver(ctx, p.event, p.key, p.urgent, state, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := state.save(d.cfg.StateFile); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+type planned struct {
+	event  Event
+	key    string
+	urgent bool
+}
+
+// plan turns an outcome into events, updating authentication error
+// streaks in state
+func (d *Dispatcher) plan(o Outcome, state *state, now time.Time) []planned {
+	var list []planned
+
+	for _, source := range o.Authenticated {
+		delete(state.AuthFailures, source)
+	}
+
+	threshold := max(d.cfg.AuthFailureThreshold, 1)
+	var failures []Failure
+	for _, f := range o.Failures {
+		if !f.Auth {
+			failures = append(failures, f)
+			continue
+		}
+		state.AuthFailures[f.Source]++
+		if count := state.AuthFailures[f.Source]; count >= threshold {
+			list = append(list, planned{
+				event:  Event{Kind: EventAuthFailed, Time: now, Message: o.Message, Source: f.Source, Error: f.Error, Count: count},
+				key:    EventAuthFailed + ":" + f.Source,
+				urgent: true,
+			})
+		}
+	}
+
+	if !o.Success && len(o.Failures) == 0 {
+		failures = append(failures, Failure{Source: "sync", Error: o.Message})
+	}
+	if len(failures) > 0 {
+		list = append(list, planned{
+			event:  Event{Kind: EventSyncFailed, Time: now, Message: o.Message, Failures: failures},
+			key:    EventSyncFailed + ":" + failureHash(failures),
+			urgent: true,
+		})
+	}
+
+	for i := range o.NewWorkouts {
+		w := &o.NewWorkouts[i]
+		list = append(list, planned{
+			event: Event{Kind: EventNewActivity, Time: now, Message: o.Message, Workout: w, Platforms: o.Platforms},
+			key:   EventNewActivity + ":" + w.ID,
+		})
+
+		var prs []models.Exercise
+		for _, ex := range w.Exercises {
+			if ex.PR {
+				prs = append(prs, ex)
+			}
+		}
+		if len(prs) > 0 {
+			list = append(list, planned{
+				event: Event{Kind: EventPR, Time: now, Message: o.Message, Workout: w, PRs: prs, Platforms: o.Platforms},
+				key:   EventPR + ":" + w.ID,
+			})
+		}
+	}
+
+	return list
+}
+
+// failureHash identifies a set of failures, so the same failure repeating
+// on every scheduled sync is only reported once per window
+func failureHash(failures []Failure) string {
+	lines := make([]string, len(failures))
+	for i, f := range failures {
+		lines[i] = f.Source + ": " + f.Error
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// deliver renders and sends an event to every channel that wants it
+func (d *Dispatcher) deliver(ctx context.Context, ev Event, key string, urgent bool, state *state, now time.Time) error {
+	if d.events != nil && !d.events[ev.Kind] {
+		return nil
+	}
+	if sent, ok := state.Sent[key]; ok && now.Sub(sent) < d.cfg.DedupeWindow {
+		logging.Or(d.Logger).Info("Notification already sent", logging.Icon("⏭️ "), "key", key, "sent_at", sent.Format("2006-01-02 15:04"))
+		return nil
+	}
+
+	var errs []error
+	delivered := false
+	for _, ch := range d.channels {
+		if ch.events != nil && !ch.events[ev.Kind] {
+			continue
+		}
+		msg, err := d.render(ch.template, ev, key, urgent, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := ch.notifier.Send(ctx, msg); err != nil {
+			logging.Or(d.Logger).Error("Notification failed", "event", ev.Kind, "channel", ch.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", ch.name, err))
+			continue
+		}
+		logging.Or(d.Logger).Info("Sent notification", logging.Icon("🔔"), "event", ev.Kind, "channel", ch.name)
+		delivered = true
+	}
+
+	if delivered {
+		state.Sent[key] = now
+	}
+	return errors.Join(errs...)
+}
+
+// render executes a notification template. The first line of the output
+// is the title and the rest the message body.
+func (d *Dispatcher) render(tmpl string, ev Event, key string, urgent bool, now time.Time) (Message, error) {
+	out, err := d.renderer.Render(tmpl, ev)
+	if err != nil {
+		return Message{}, err
+	}
+	title, body, _ := strings.Cut(out, "\n")
+	body = strings.TrimSpace(body)
+	if body == "" {
+		body = title
+	}
+	return Message{
+		Event:   ev.Kind,
+		Key:     key,
+		Title:   strings.TrimSpace(title),
+		Body:    body,
+		Urgent:  urgent,
+		Workout: ev.Workout,
+		SentAt:  now,
+	}, nil
+}
+
+// Test sends a sample event of the given kind to every channel, ignoring
+// event filters and de-duplication
+func (d *Dispatcher) Test(ctx context.Context, kind string, workout *models.Workout) error {
+	now := time.Now()
+	ev := Event{Kind: kind, Time: now, Message: "Test notification from aimharder-sync", Platforms: []string{"strava"}}
+	switch kind {
+	case EventNewActivity:
+		ev.Workout = workout
+	case EventSyncFailed:
+		ev.Failures = []Failure{{Source: "strava", Error: "upload failed: 503 Service Unavailable"}}
+	case EventAuthFailed:
+		ev.Source, ev.Error, ev.Count = "strava", "token refresh failed: invalid_grant", max(d.cfg.AuthFailureThreshold, 1)
+	case EventPR:
+		if workout == nil {
+			break
+		}
+		ev.Workout = workout
+		for _, ex := range workout.Exercises {
+			if ex.PR {
+				ev.PRs = append(ev.PRs, ex)
+			}
+		}
+	}
+
+	var errs []error
+	for _, ch := range d.channels {
+		msg, err := d.render(ch.template, ev, "test:"+kind, kind == EventSyncFailed || kind == EventAuthFailed, now)
+		if err == nil {
+			err = ch.notifier.Send(ctx, msg)
+		}
+		if err != nil {
+			logging.Or(d.Logger).Error("Test notification failed", "channel", ch.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", ch.name, err))
+			continue
+		}
+		logging.Or(d.Logger).Info("Sent test notification", logging.Icon("✅"), "channel", ch.name, "title", msg.Title)
+	}
+	return errors.Join(errs...)
+}
+
+// state is persisted between runs, as scheduled syncs are separate processes
+type state struct {
+	Sent         map[string]time.Time `json:"sent"`          // de-duplication key -> last sent
+	AuthFailures map[string]int       `json:"auth_failures"` // source -> consecutive auth errors
+}
+
+func loadState(path string) *state {
+	s := &state{}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, s)
+	}
+	if s.Sent == nil {
+		s.Sent = make(map[string]time.Time)
+	}
+	if s.AuthFailures == nil {
+		s.AuthFailures = make(map[string]int)
+	}
+	return s
+}
+
+// prune forgets messages sent before the de-duplication window
+func (s *state) prune(now time.Time, window time.Duration) {
+	for key, sent := range s.Sent {
+		if now.Sub(sent) >= window {
+			delete(s.Sent, key)
+		}
+	}
+}
+
+func (s *state) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create notification state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save notification state: %w", err)
+	}
+	return nil
+}
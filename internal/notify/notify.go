@@ -0,0 +1,101 @@
+// Package notify sends sync outcomes (failures, repeated authentication
+// errors, new activities and PRs) to notification channels such as ntfy,
+// Telegram, email and webhooks.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Events that trigger notifications
+const (
+	EventSyncFailed  = "sync_failed"  // a sync failed for reasons other than authentication
+	EventAuthFailed  = "auth_failed"  // a source or destination failed to authenticate repeatedly
+	EventNewActivity = "new_activity" // a workout was uploaded for the first time
+	EventPR          = "pr"           // a newly uploaded workout contains PRs
+)
+
+// Events lists all events
+var Events = []string{EventSyncFailed, EventAuthFailed, EventNewActivity, EventPR}
+
+// Failure is something that went wrong during a sync
+type Failure struct {
+	Source string `json:"source"` // "aimharder" or a destination name
+	Error  string `json:"error"`
+	Auth   bool   `json:"auth"` // the source or destination failed to authenticate
+}
+
+// Event is the data notification templates are rendered with
+type Event struct {
+	Kind      string
+	Time      time.Time
+	Message   string          // overall sync message
+	Failures  []Failure       // sync_failed
+	Source    string          // auth_failed
+	Error     string          // auth_failed
+	Count     int             // auth_failed: consecutive failures
+	Workout   *models.Workout // new_activity, pr
+	PRs       []models.Exercise
+	Platforms []string // new_activity: where the workout was uploaded
+}
+
+// Message is a rendered notification
+type Message struct {
+	Event   string          `json:"event"`
+	Key     string          `json:"key"` // de-duplication key
+	Title   string          `json:"title"`
+	Body    string          `json:"message"`
+	Urgent  bool            `json:"urgent"` // failures, shown with high priority where supported
+	Workout *models.Workout `json:"workout,omitempty"`
+	SentAt  time.Time       `json:"sent_at"`
+}
+
+// Notifier delivers messages to one channel
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Factory creates a notifier from its channel config
+type Factory func(ch config.NotifierConfig) (Notifier, error)
+
+var registry = make(map[string]Factory)
+
+// Register makes a channel type available; called from init functions
+func Register(kind string, factory Factory) {
+	registry[kind] = factory
+}
+
+// Types returns the registered channel types
+func Types() []string {
+	types := make([]string, 0, len(registry))
+	for kind := range registry {
+		types = append(types, kind)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New creates the notifier for a channel
+func New(ch config.NotifierConfig) (Notifier, error) {
+	factory, ok := registry[strings.ToLower(ch.Type)]
+	if !ok {
+		return nil, fmt.Errorf("notification channel %q has unknown type %q (available: %s)", ch.Name, ch.Type, strings.Join(Types(), ", "))
+	}
+	return factory(ch)
+}
+
+// channelName names a channel in logs, falling back to its type
+func channelName(ch config.NotifierConfig) string {
+	if ch.Name != "" {
+		return ch.Name
+	}
+	return ch.Type
+}
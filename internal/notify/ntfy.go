@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+)
+
+func init() {
+	Register("ntfy", func(ch config.NotifierConfig) (Notifier, error) {
+		if ch.Topic == "" {
+			return nil, fmt.Errorf("ntfy channel %q has no topic", channelName(ch))
+		}
+		url := ch.URL
+		if url == "" {
+			url = "https://ntfy.sh"
+		}
+		return &Ntfy{
+			name:       channelName(ch),
+			url:        strings.TrimSuffix(url, "/"),
+			topic:      ch.Topic,
+			token:      ch.Token,
+			username:   ch.Username,
+			password:   ch.Password,
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	})
+}
+
+// Ntfy publishes to an ntfy topic (https://ntfy.sh or a self-hosted server)
+type Ntfy struct {
+	name       string
+	url        string
+	topic      string
+	token      string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// Name returns the channel name
+func (n *Ntfy) Name() string {
+	return n.name
+}
+
+// Send publishes the message as JSON to the server root
+func (n *Ntfy) Send(ctx context.Context, msg Message) error {
+	payload := map[string]interface{}{
+		"topic":    n.topic,
+		"title":    msg.Title,
+		"message":  msg.Body,
+		"priority": 3,
+		"tags":     []string{"weight_lifter"},
+	}
+	if msg.Urgent {
+		payload["priority"] = 4
+		payload["tags"] = []string{"warning"}
+	} else if msg.Event == EventPR {
+		payload["tags"] = []string{"trophy"}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ntfy message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	} else if n.username != "" {
+		req.SetBasicAuth(n.username, n.password)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ntfy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
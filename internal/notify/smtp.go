@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+)
+
+func init() {
+	Register("smtp", func(ch config.NotifierConfig) (Notifier, error) {
+		if ch.Host == "" || ch.From == "" || len(ch.To) == 0 {
+			return nil, fmt.Errorf("smtp channel %q needs host, from and to", channelName(ch))
+		}
+		if _, err := mail.ParseAddress(ch.From); err != nil {
+			return nil, fmt.Errorf("smtp channel %q: invalid from address: %w", channelName(ch), err)
+		}
+		port := ch.Port
+		if port == 0 {
+			port = 587
+		}
+		return &SMTP{
+			name:     channelName(ch),
+			host:     ch.Host,
+			port:     port,
+			username: ch.Username,
+			password: ch.Password,
+			from:     ch.From,
+			to:       ch.To,
+		}, nil
+	})
+}
+
+// SMTP sends plain text emails. Port 465 uses implicit TLS; other ports
+// upgrade with STARTTLS when the server offers it, so local test servers
+// without TLS (e.g. MailHog on port 1025) work as well.
+type SMTP struct {
+	name     string
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// Name returns the channel name
+func (s *SMTP) Name() string {
+	return s.name
+}
+
+// Send delivers the message to all recipients
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if s.port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && s.port != 465 {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if s.username != "" {
+		// PlainAuth refuses to send credentials over unencrypted
+		// connections, except to localhost
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(s.from)
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, rcpt := range s.to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", rcpt, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(s.compose(msg, from.Address)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return c.Quit()
+}
+
+// compose builds the RFC 5322 message
+func (s *SMTP) compose(msg Message, fromAddr string) []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	domain := "aimharder-sync"
+	if _, d, ok := strings.Cut(fromAddr, "@"); ok {
+		domain = d
+	}
+	id := make([]byte, 12)
+	rand.Read(id)
+
+	header("From", s.from)
+	header("To", strings.Join(s.to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Title))
+	header("Date", msg.SentAt.Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	if msg.Urgent {
+		header("X-Priority", "2")
+	}
+	header("X-AimHarder-Event", msg.Event)
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+	qp.Close()
+	return buf.Bytes()
+}
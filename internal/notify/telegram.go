@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+)
+
+func init() {
+	Register("telegram", func(ch config.NotifierConfig) (Notifier, error) {
+		if ch.Token == "" || ch.ChatID == "" {
+			return nil, fmt.Errorf("telegram channel %q needs a bot token and chat_id", channelName(ch))
+		}
+		url := ch.URL
+		if url == "" {
+			url = "https://api.telegram.org"
+		}
+		return &Telegram{
+			name:       channelName(ch),
+			url:        strings.TrimSuffix(url, "/"),
+			token:      ch.Token,
+			chatID:     ch.ChatID,
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	})
+}
+
+// Telegram sends messages through the Telegram Bot API
+type Telegram struct {
+	name       string
+	url        string
+	token      string
+	chatID     string
+	httpClient *http.Client
+}
+
+// Name returns the channel name
+func (t *Telegram) Name() string {
+	return t.name
+}
+
+// Send calls sendMessage. Messages are sent as plain text, so workout
+// names need no escaping.
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	text := msg.Title
+	if msg.Body != msg.Title {
+		text += "\n\n" + msg.Body
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id": t.chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", t.url, t.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		// The URL contains the bot token; don't leak it into logs
+		return fmt.Errorf("request to Telegram failed: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("Telegram returned status %d", resp.StatusCode)
+	}
+	if !result.OK {
+		return fmt.Errorf("Telegram returned status %d: %s", resp.StatusCode, result.Description)
+	}
+	return nil
+}
+
+// unwrapURLError drops the request URL from HTTP client errors
+func unwrapURLError(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
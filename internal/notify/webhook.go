@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/webhook"
+)
+
+func init() {
+	Register("webhook", func(ch config.NotifierConfig) (Notifier, error) {
+		if ch.URL == "" {
+			return nil, fmt.Errorf("webhook channel %q has no url", channelName(ch))
+		}
+		return &Webhook{
+			name:       channelName(ch),
+			url:        ch.URL,
+			secret:     ch.Secret,
+			headers:    ch.Headers,
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	})
+}
+
+// Webhook POSTs messages as JSON, signed like outbound workout webhooks
+type Webhook struct {
+	name       string
+	url        string
+	secret     string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// Name returns the channel name
+func (h *Webhook) Name() string {
+	return h.name
+}
+
+// Send POSTs the message
+func (h *Webhook) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aimharder-sync")
+	req.Header.Set(webhook.HeaderEvent, "notification."+msg.Event)
+	req.Header.Set(webhook.HeaderTimestamp, timestamp)
+	if h.secret != "" {
+		req.Header.Set(webhook.HeaderSignature, webhook.Sign(h.secret, timestamp, body))
+	}
+	for key, value := range h.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
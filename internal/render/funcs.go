@@ -0,0 +1,223 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+// funcs are available to all templates in addition to the text/template
+// built-ins (printf, html, urlquery, ...)
+var funcs = template.FuncMap{
+	"clean":            CleanHTMLEntities,
+	"placeholder":      IsPlaceholderExercise,
+	"sectionExercises": sectionExercises,
+	"looseExercises":   looseExercises,
+	"exercise":         ExerciseLine,
+	"weight":           formatWeight,
+	"distance":         formatDistance,
+	"clock":            FormatClock,
+	"score":            FormatScore,
+	"elapsed":          FormatElapsed,
+	"date":             formatDate,
+	"lines":            lines,
+	"indent":           indent,
+	"repeat":           strings.Repeat,
+	"upper":            strings.ToUpper,
+	"lower":            strings.ToLower,
+	"contains":         strings.Contains,
+	"join":             strings.Join,
+	"add":              func(a, b int) int { return a + b },
+}
+
+// sectionExercises returns the exercises that belong to section i
+func sectionExercises(w *models.Workout, i int) []models.Exercise {
+	var result []models.Exercise
+	for _, ex := range w.Exercises {
+		if ex.SectionIndex == i {
+			result = append(result, ex)
+		}
+	}
+	return result
+}
+
+// looseExercises returns exercises that are not shown under a section:
+// all of them when the workout has no sections, otherwise those whose
+// section index is out of range
+func looseExercises(w *models.Workout) []models.Exercise {
+	if len(w.Sections) == 0 {
+		return w.Exercises
+	}
+	var result []models.Exercise
+	for _, ex := range w.Exercises {
+		if ex.SectionIndex < 0 || ex.SectionIndex >= len(w.Sections) {
+			result = append(result, ex)
+		}
+	}
+	return result
+}
+
+// ExerciseLine formats an exercise as "21 Thruster @ 43kg", putting
+// distance or reps first and calories last
+func ExerciseLine(ex models.Exercise) string {
+	var parts []string
+
+	if ex.Distance > 0 {
+		parts = append(parts, formatDistance(ex.Distance, ex.DistanceUnit))
+	} else if ex.RepsPerRound > 0 {
+		parts = append(parts, fmt.Sprintf("%d", ex.RepsPerRound))
+	} else if ex.Reps > 0 {
+		parts = append(parts, fmt.Sprintf("%d", ex.Reps))
+	}
+
+	parts = append(parts, ex.Name)
+
+	if ex.Weight > 0 {
+		parts = append(parts, "@ "+formatWeight(ex.Weight, ex.WeightUnit))
+	}
+
+	if ex.Calories > 0 {
+		parts = append(parts, fmt.Sprintf("%d cal", ex.Calories))
+	}
+
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// CleanHTMLEntities replaces BR tags and common HTML entities with their
+// characters and normalizes typographic quotes
+func CleanHTMLEntities(s string) string {
+	// Replace BR tags with newlines
+	s = strings.ReplaceAll(s, "<br />", "\n")
+	s = strings.ReplaceAll(s, "<br/>", "\n")
+	s = strings.ReplaceAll(s, "<br>", "\n")
+
+	// Replace HTML entities
+	s = strings.ReplaceAll(s, "&quot;", "\"")
+	s = strings.ReplaceAll(s, "&#39;", "'")
+	s = strings.ReplaceAll(s, "&amp;", "&")
+	s = strings.ReplaceAll(s, "&lt;", "<")
+	s = strings.ReplaceAll(s, "&gt;", ">")
+	s = strings.ReplaceAll(s, "&nbsp;", " ")
+
+	// Replace Unicode quotes
+	s = strings.ReplaceAll(s, "\u2019", "'")
+	s = strings.ReplaceAll(s, "\u2018", "'")
+	s = strings.ReplaceAll(s, "\u201c", "\"")
+	s = strings.ReplaceAll(s, "\u201d", "\"")
+
+	// Clean up multiple newlines
+	for strings.Contains(s, "\n\n\n") {
+		s = strings.ReplaceAll(s, "\n\n\n", "\n\n")
+	}
+
+	return strings.TrimSpace(s)
+}
+
+// IsPlaceholderExercise returns true for placeholder exercises that should be filtered out
+func IsPlaceholderExercise(name string) bool {
+	placeholders := []string{
+		"Descanso Rest",
+		"Rest",
+		"Descanso",
+	}
+	nameLower := strings.ToLower(name)
+	for _, p := range placeholders {
+		if strings.ToLower(p) == nameLower {
+			return true
+		}
+	}
+	return false
+}
+
+func formatWeight(weight float64, unit string) string {
+	if unit == "" {
+		unit = "kg"
+	}
+	return fmt.Sprintf("%.0f%s", weight, unit)
+}
+
+func formatDistance(dist float64, unit string) string {
+	if unit == "" {
+		unit = "m"
+	}
+	return fmt.Sprintf("%.0f%s", dist, unit)
+}
+
+// FormatClock formats a duration as H:MM:SS or M:SS
+func FormatClock(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// FormatScore formats a workout result as a single score, e.g. "12:34",
+// "5+12" or "100 kg"
+func FormatScore(r *models.WorkoutResult) string {
+	switch {
+	case r == nil:
+		return ""
+	case r.Score != "":
+		return r.Score
+	case r.Time != nil:
+		return FormatClock(*r.Time)
+	case r.Rounds > 0:
+		return fmt.Sprintf("%d+%d", r.Rounds, r.Reps)
+	case r.Weight > 0:
+		return strconv.FormatFloat(r.Weight, 'f', -1, 64) + " kg"
+	}
+	return ""
+}
+
+// FormatElapsed formats a duration as "1h 2m 3s"
+func FormatElapsed(d time.Duration) string {
+	if d == 0 {
+		return "Not specified"
+	}
+
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+
+	if h > 0 {
+		return fmt.Sprintf("%dh %dm %ds", h, m, s)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm %ds", m, s)
+	}
+	return fmt.Sprintf("%ds", s)
+}
+
+func formatDate(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// lines splits text into non-empty lines
+func lines(s string) []string {
+	var result []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// indent prefixes every line of s with prefix
+func indent(prefix, s string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
@@ -0,0 +1,204 @@
+package render
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Built-in template names
+const (
+	Plain    = "plain"    // terminal listing used by 'fetch'
+	Markdown = "markdown" // Markdown document
+	HTML     = "html"     // HTML fragment
+	Strava   = "strava"   // activity description uploaded to platforms
+	Notes    = "notes"    // Notes element of generated TCX files
+	Preview  = "preview"  // dry-run activity preview
+	Notify   = "notify"   // notification title (first line) and message
+)
+
+// templateExt is the file extension of template files
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var builtinFS embed.FS
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// Renderer renders workouts with text/template templates. Built-in templates
+// can be overridden (or new ones added) by placing <name>.tmpl files in an
+// override directory. The HTML template is rendered with html/template, so
+// values are escaped even when an override leaves out {{html}}.
+type Renderer struct {
+	templates *template.Template
+	html      *htmltemplate.Template
+}
+
+// New creates a renderer from the built-in templates and any *.tmpl files
+// in overrideDir. A missing overrideDir is not an error.
+func New(overrideDir string) (*Renderer, error) {
+	root := template.New("").Funcs(funcs)
+	htmlRoot := htmltemplate.New("").Funcs(htmltemplate.FuncMap(funcs))
+
+	entries, err := builtinFS.ReadDir("templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in templates: %w", err)
+	}
+	for _, entry := range entries {
+		data, err := builtinFS.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in template %s: %w", entry.Name(), err)
+		}
+		if err := parse(root, htmlRoot, entry.Name(), string(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	if overrideDir != "" {
+		files, err := filepath.Glob(filepath.Join(overrideDir, "*"+templateExt))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list templates in %s: %w", overrideDir, err)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read template %s: %w", file, err)
+			}
+			if err := parse(root, htmlRoot, filepath.Base(file), string(data)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Renderer{templates: root, html: htmlRoot}, nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRenderer *Renderer
+)
+
+// Default returns a shared renderer with only the built-in templates
+func Default() *Renderer {
+	defaultOnce.Do(func() {
+		r, err := New("")
+		if err != nil {
+			panic(err)
+		}
+		defaultRenderer = r
+	})
+	return defaultRenderer
+}
+
+// parse adds a template named after its file (without extension) to root
+// and htmlRoot, replacing any existing template with that name
+func parse(root *template.Template, htmlRoot *htmltemplate.Template, filename, text string) error {
+	name := strings.TrimSuffix(filename, templateExt)
+	if _, err := root.New(name).Parse(text); err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", filename, err)
+	}
+	if _, err := htmlRoot.New(name).Parse(text); err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", filename, err)
+	}
+	return nil
+}
+
+// Names returns the names of all available templates
+func (r *Renderer) Names() []string {
+	var names []string
+	for _, t := range r.templates.Templates() {
+		if t.Name() != "" && !strings.HasPrefix(t.Name(), "_") {
+			names = append(names, t.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether a template with the given name exists
+func (r *Renderer) Has(name string) bool {
+	return r.templates.Lookup(name) != nil
+}
+
+// Render executes the named template with data. Runs of blank lines are
+// collapsed to one and surrounding whitespace is trimmed, so templates can
+// be laid out for readability.
+func (r *Renderer) Render(name string, data interface{}) (string, error) {
+	t := r.templates.Lookup(name)
+	if t == nil {
+		return "", fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(r.Names(), ", "))
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if name == HTML {
+		err = r.html.ExecuteTemplate(&buf, name, data)
+	} else {
+		err = t.Execute(&buf, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	out := strings.ReplaceAll(buf.String(), "\r\n", "\n")
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	out = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(out), nil
+}
+
+// Workout renders a workout with the named template
+func (r *Renderer) Workout(name string, workout *models.Workout) (string, error) {
+	return r.Render(name, workout)
+}
+
+// Description renders the activity description uploaded to platforms.
+// Rendering errors (e.g. a broken override) yield an empty description
+// rather than failing a sync.
+func (r *Renderer) Description(workout *models.Workout) string {
+	out, err := r.Render(Strava, workout)
+	if err != nil {
+		slog.Warn("Failed to render the description", "error", err)
+		return ""
+	}
+	return out
+}
+
+// Notes renders the Notes element of a generated TCX file
+func (r *Renderer) Notes(workout *models.Workout) string {
+	out, err := r.Render(Notes, workout)
+	if err != nil {
+		slog.Warn("Failed to render the notes", "error", err)
+		return ""
+	}
+	return out
+}
+
+// PreviewData is the data passed to the preview template
+type PreviewData struct {
+	Index       int
+	Total       int
+	Workout     *models.Workout
+	Platform    string
+	Name        string
+	Type        string
+	StartDate   string
+	ExternalID  string
+	DataType    string
+	File        string
+	ElapsedTime string
+}
@@ -0,0 +1,329 @@
+// Package runlog keeps a persistent log of sync runs, from the CLI, the
+// webhook server and the scheduler alike: what was asked for, how long it
+// took, and what was decided for every workout on every platform, and why.
+// Runs are appended to a JSON Lines file and pruned to a count and an age.
+package runlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Triggers besides the job triggers (webhook, mqtt and schedule)
+const TriggerCLI = "cli"
+
+// Outcome of workouts a dry run would upload; the ones it would skip are
+// destination.OutcomeSkipped
+const OutcomeWouldUpload = "would_upload"
+
+// ReasonGenerateFailed marks workouts whose activity file couldn't be
+// generated; they have no platform
+const ReasonGenerateFailed = "generate_failed"
+
+// Run is one sync
+type Run struct {
+	ID         string    `json:"id"`
+	Trigger    string    `json:"trigger"` // cli, webhook, mqtt or schedule
+	JobID      string    `json:"job_id,omitempty"`
+	TraceID    string    `json:"trace_id,omitempty"` // when tracing is enabled
+	Params     Params    `json:"params"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Duration   string    `json:"duration"`
+	Success    bool      `json:"success"`
+	Message    string    `json:"message,omitempty"`
+
+	Fetched  int `json:"fetched"`
+	Uploaded int `json:"uploaded"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+	Errors   int `json:"errors"`
+
+	// DestinationErrors holds the errors of destinations that failed as a
+	// whole (e.g. on login), by platform
+	DestinationErrors map[string]string `json:"destination_errors,omitempty"`
+
+	// Workouts holds a decision per workout and platform, in order
+	Workouts []Entry `json:"workouts,omitempty"`
+}
+
+// Params are what a run was asked to do
+type Params struct {
+	Start        string   `json:"start"` // YYYY-MM-DD, resolved from days
+	End          string   `json:"end"`
+	Destinations []string `json:"destinations"`
+	Source       string   `json:"source,omitempty"` // aimharder, or an imported export
+	Force        bool     `json:"force,omitempty"`
+	DryRun       bool     `json:"dry_run,omitempty"`
+	WorkoutIDs   []string `json:"workout_ids,omitempty"` // resyncs of single workouts
+}
+
+// Entry is what a run did with a workout on a platform
+type Entry struct {
+	WorkoutID  string `json:"workout_id"`
+	Date       string `json:"date"`
+	Name       string `json:"name"`
+	Platform   string `json:"platform,omitempty"`
+	Outcome    string `json:"outcome"` // see destination.Outcome*
+	Reason     string `json:"reason,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// New starts a run
+func New(trigger string, params Params) *Run {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &Run{ID: hex.EncodeToString(id), Trigger: trigger, Params: params, StartedAt: time.Now()}
+}
+
+// Add records what was done with a workout
+func (r *Run) Add(w *models.Workout, platform, outcome, reason, externalID string, err error) {
+	entry := Entry{
+		WorkoutID:  w.ID,
+		Date:       w.Date.Format("2006-01-02"),
+		Name:       w.Name,
+		Platform:   platform,
+		Outcome:    outcome,
+		Reason:     reason,
+		ExternalID: externalID,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.Workouts = append(r.Workouts, entry)
+}
+
+// DestinationFailed records a destination that failed as a whole
+func (r *Run) DestinationFailed(platform string, err error) {
+	if r.DestinationErrors == nil {
+		r.DestinationErrors = map[string]string{}
+	}
+	r.DestinationErrors[platform] = err.Error()
+}
+
+// Synced returns a destination progress callback recording each decision
+// on platform
+func (r *Run) Synced(platform string) func(w *models.Workout, d destination.Decision) {
+	return func(w *models.Workout, d destination.Decision) {
+		r.Add(w, platform, d.Outcome, d.Reason, d.ExternalID, d.Err)
+	}
+}
+
+// Finish records the end of the run
+func (r *Run) Finish(success bool, message string) {
+	r.FinishedAt = time.Now()
+	r.Duration = r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond).String()
+	r.Success = success
+	r.Message = message
+}
+
+// Filter selects runs
+type Filter struct {
+	Trigger string
+	Failed  bool      // only runs that failed
+	Since   time.Time // runs started at or after
+	Workout string    // runs with an entry for this workout
+	Limit   int       // newest runs to return (0: all)
+}
+
+func (f Filter) match(r *Run) bool {
+	if f.Trigger != "" && r.Trigger != f.Trigger {
+		return false
+	}
+	if f.Failed && r.Success {
+		return false
+	}
+	if r.StartedAt.Before(f.Since) {
+		return false
+	}
+	if f.Workout != "" && !slices.ContainsFunc(r.Workouts, func(e Entry) bool { return e.WorkoutID == f.Workout }) {
+		return false
+	}
+	return true
+}
+
+// ErrNotFound is returned by Get for unknown or pruned runs
+var ErrNotFound = errors.New("run not found")
+
+// Store is the run log file. Runs are appended, so processes (e.g. the CLI
+// and the daemon) can share it; writers take a lock on <path>.lock.
+type Store struct {
+	path   string
+	keep   int
+	maxAge time.Duration
+}
+
+// NewStore opens the run log at path, keeping the last keep runs that are
+// younger than maxAge (0: no limit)
+func NewStore(path string, keep int, maxAge time.Duration) *Store {
+	return &Store{path: path, keep: max(keep, 1), maxAge: maxAge}
+}
+
+// Add appends a finished run, then prunes runs beyond the limits
+func (s *Store) Add(run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write run log: %w", err)
+	}
+	return s.prune()
+}
+
+// List returns the matching runs, newest first, without their workouts
+func (s *Store) List(f Filter) ([]Run, error) {
+	runs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	list := []Run{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if !f.match(&runs[i]) {
+			continue
+		}
+		run := runs[i]
+		run.Workouts = nil
+		list = append(list, run)
+		if f.Limit > 0 && len(list) == f.Limit {
+			break
+		}
+	}
+	return list, nil
+}
+
+// Get returns a run with its workouts
+func (s *Store) Get(id string) (*Run, error) {
+	runs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		if runs[i].ID == id {
+			return &runs[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// lock takes the writers' lock, so an append can't land between a prune's
+// read and its rename
+func (s *Store) lock() (unlock func(), err error) {
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run log lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock run log: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// load reads all runs, oldest first, skipping lines that don't parse
+// (e.g. one cut short by a crash)
+func (s *Store) load() ([]Run, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []Run
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var run Run
+			if json.Unmarshal(line, &run) == nil {
+				runs = append(runs, run)
+			}
+		}
+		if err == io.EOF {
+			return runs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read run log: %w", err)
+		}
+	}
+}
+
+// prune rewrites the log without the runs beyond the limits. The caller
+// holds the lock.
+func (s *Store) prune() error {
+	runs, err := s.load()
+	if err != nil {
+		return err
+	}
+	first := max(len(runs)-s.keep, 0)
+	if s.maxAge > 0 {
+		cutoff := time.Now().Add(-s.maxAge)
+		for first < len(runs) && runs[first].StartedAt.Before(cutoff) {
+			first++
+		}
+	}
+	if first == 0 {
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := first; i < len(runs) && err == nil; i++ {
+		err = enc.Encode(&runs[i])
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to prune run log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window is a period of fast polling, usually the time after a class ends
+// when athletes log their results
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Class string    `json:"class,omitempty"`
+}
+
+// String formats the window as "2006-01-02 15:04-16:04 (class)"
+func (w Window) String() string {
+	s := w.Start.Format("2006-01-02 15:04") + "-" + w.End.Format("15:04")
+	if w.Class != "" {
+		s += " (" + w.Class + ")"
+	}
+	return s
+}
+
+// WindowsAfter returns a window of the given length starting when each class
+// ends. Overlapping windows are merged.
+func WindowsAfter(classes []Window, length time.Duration) []Window {
+	windows := make([]Window, 0, len(classes))
+	for _, class := range classes {
+		windows = append(windows, Window{Start: class.End, End: class.End.Add(length), Class: class.Class})
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+
+	var merged []Window
+	for _, w := range windows {
+		if n := len(merged); n > 0 && !w.Start.After(merged[n-1].End) {
+			last := &merged[n-1]
+			if w.End.After(last.End) {
+				last.End = w.End
+			}
+			if w.Class != "" && !strings.Contains(last.Class, w.Class) {
+				last.Class = strings.TrimPrefix(last.Class+", "+w.Class, ", ")
+			}
+			continue
+		}
+		merged = append(merged, w)
+	}
+	return merged
+}
+
+// Timetable is a weekly list of class times, used when no classes are booked
+type Timetable []timetableEntry
+
+type timetableEntry struct {
+	days       uint64 // weekday bit set, as in cron
+	start, end time.Duration
+	label      string
+}
+
+// ParseTimetable parses entries such as "mon-fri 07:00-08:00",
+// "sat 10:00-11:30" or "18:30-19:30" (every day)
+func ParseTimetable(entries []string) (Timetable, error) {
+	var t Timetable
+	for _, entry := range entries {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		e := timetableEntry{days: 0x7f, label: strings.TrimSpace(entry)}
+		clock := fields[len(fields)-1]
+		if len(fields) == 2 {
+			days, err := parseField(fields[0], 0, 7, dayNames)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timetable entry %q: %w", entry, err)
+			}
+			if days&(1<<7) != 0 {
+				days |= 1
+			}
+			e.days = days
+		} else if len(fields) > 2 {
+			return nil, fmt.Errorf("invalid timetable entry %q (expected [days] HH:MM-HH:MM)", entry)
+		}
+
+		start, end, ok := strings.Cut(clock, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid timetable entry %q (expected [days] HH:MM-HH:MM)", entry)
+		}
+		var err error
+		if e.start, err = parseClock(start); err != nil {
+			return nil, fmt.Errorf("invalid timetable entry %q: %w", entry, err)
+		}
+		if e.end, err = parseClock(end); err != nil {
+			return nil, fmt.Errorf("invalid timetable entry %q: %w", entry, err)
+		}
+		if e.end <= e.start {
+			e.end += 24 * time.Hour
+		}
+		t = append(t, e)
+	}
+	return t, nil
+}
+
+// Classes returns the classes in the timetable on the given number of days,
+// starting with the day of from
+func (t Timetable) Classes(from time.Time, days int) []Window {
+	var classes []Window
+	midnight := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for i := 0; i < days; i++ {
+		day := midnight.AddDate(0, 0, i)
+		for _, e := range t {
+			if e.days&(1<<uint(day.Weekday())) == 0 {
+				continue
+			}
+			classes = append(classes, Window{
+				Start: day.Add(e.start),
+				End:   day.Add(e.end),
+				Class: e.label,
+			})
+		}
+	}
+	return classes
+}
+
+// State is a snapshot of the class-aligned polling policy. The daemon saves
+// it so that `status` can show what the scheduler is doing.
+type State struct {
+	Fast      time.Duration `json:"fast_interval"`
+	Slow      time.Duration `json:"slow_interval"`
+	Source    string        `json:"source"` // "bookings", "timetable" or "none"
+	UpdatedAt time.Time     `json:"updated_at"`
+	Windows   []Window      `json:"windows"`
+}
+
+// Policy describes how often the scheduler polls at a point in time
+type Policy struct {
+	Polling    string    `json:"polling"` // "fast" or "slow"
+	Interval   string    `json:"interval"`
+	Until      time.Time `json:"until"`
+	Source     string    `json:"source"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Current    *Window   `json:"current_window,omitempty"`
+	NextWindow *Window   `json:"next_window,omitempty"`
+}
+
+// active returns the window containing t, if any
+func (s *State) active(t time.Time) *Window {
+	for i := range s.Windows {
+		if !t.Before(s.Windows[i].Start) && t.Before(s.Windows[i].End) {
+			return &s.Windows[i]
+		}
+	}
+	return nil
+}
+
+// upcoming returns the first window starting after t, if any
+func (s *State) upcoming(t time.Time) *Window {
+	var next *Window
+	for i := range s.Windows {
+		if s.Windows[i].Start.After(t) && (next == nil || s.Windows[i].Start.Before(next.Start)) {
+			next = &s.Windows[i]
+		}
+	}
+	return next
+}
+
+// Next polls every Fast inside a window and every Slow otherwise, waking
+// up early when a window opens
+func (s *State) Next(t time.Time) time.Time {
+	if s.active(t) != nil {
+		return t.Add(s.Fast)
+	}
+	next := t.Add(s.Slow)
+	if w := s.upcoming(t); w != nil && w.Start.Before(next) {
+		return w.Start
+	}
+	return next
+}
+
+// Policy returns the polling policy at now
+func (s *State) Policy(now time.Time) Policy {
+	p := Policy{Polling: "slow", Interval: s.Slow.String(), Source: s.Source, UpdatedAt: s.UpdatedAt}
+	if w := s.active(now); w != nil {
+		current := *w
+		p.Polling, p.Interval, p.Current, p.Until = "fast", s.Fast.String(), &current, w.End
+	}
+	if w := s.upcoming(now); w != nil {
+		next := *w
+		p.NextWindow = &next
+		if p.Current == nil {
+			p.Until = w.Start
+		}
+	}
+	return p
+}
+
+// Classes is a Schedule that polls often in the windows after classes and
+// rarely otherwise. Windows are replaced as bookings are refreshed.
+type Classes struct {
+	mu    sync.Mutex
+	state State
+}
+
+// NewClasses creates a class-aligned schedule without any windows yet
+func NewClasses(fast, slow time.Duration) *Classes {
+	return &Classes{state: State{Fast: fast, Slow: slow, Source: "none"}}
+}
+
+// SetWindows replaces the polling windows; source says where they came from
+func (c *Classes) SetWindows(windows []Window, source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.Windows = windows
+	c.state.Source = source
+	c.state.UpdatedAt = time.Now()
+}
+
+// Next implements Schedule
+func (c *Classes) Next(t time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Next(t)
+}
+
+// State returns a copy of the current policy state
+func (c *Classes) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.state
+	s.Windows = append([]Window(nil), s.Windows...)
+	return s
+}
+
+// SaveState writes the policy state to path
+func SaveState(path string, s State) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadState reads a policy state saved by the daemon
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule state: %w", err)
+	}
+	return &s, nil
+}
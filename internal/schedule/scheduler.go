@@ -0,0 +1,180 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/logging"
+)
+
+// ErrBusy is returned by a run function that skipped because another sync
+// was already in progress; it doesn't count as a failure
+var ErrBusy = errors.New("a sync is already in progress")
+
+// QuietHours is a daily window without scheduled syncs, e.g. 23:00-07:00
+type QuietHours struct {
+	Start, End time.Duration // offsets from midnight
+}
+
+// ParseQuietHours parses "HH:MM-HH:MM" (or "H-H"); empty means none
+func ParseQuietHours(s string) (*QuietHours, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid quiet hours %q (expected HH:MM-HH:MM)", s)
+	}
+	q := &QuietHours{}
+	var err error
+	if q.Start, err = parseClock(start); err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: %w", s, err)
+	}
+	if q.End, err = parseClock(end); err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: %w", s, err)
+	}
+	if q.Start == q.End {
+		return nil, nil
+	}
+	return q, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	hh, mm, _ := strings.Cut(strings.TrimSpace(s), ":")
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	m := 0
+	if mm != "" {
+		if m, err = strconv.Atoi(mm); err != nil || m < 0 || m > 59 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Contains reports whether t falls within the quiet hours
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if q.Start < q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	// Wraps past midnight
+	return offset >= q.Start || offset < q.End
+}
+
+// String formats the window as HH:MM-HH:MM
+func (q *QuietHours) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(q.Start) + "-" + clock(q.End)
+}
+
+// Options configures Run
+type Options struct {
+	Schedule   Schedule
+	Quiet      *QuietHours
+	Jitter     time.Duration // random delay added to each run
+	RunOnStart bool          // run once immediately (outside quiet hours)
+
+	// After BackoffAfter consecutive failures, runs are delayed by 5
+	// minutes, doubling with each further failure up to MaxBackoff
+	BackoffAfter int
+	MaxBackoff   time.Duration
+
+	// Logger receives progress (default: slog.Default())
+	Logger *slog.Logger
+}
+
+// Run calls run on the schedule until ctx is cancelled
+func Run(ctx context.Context, opts Options, run func(ctx context.Context) error) {
+	log := logging.Or(opts.Logger)
+
+	failures := 0
+	var lastFailure time.Time
+
+	attempt := func() {
+		err := run(ctx)
+		switch {
+		case errors.Is(err, ErrBusy):
+			log.Info("Scheduled sync skipped", logging.Icon("⏭️ "), "reason", err)
+		case err != nil:
+			failures++
+			lastFailure = time.Now()
+			log.Warn("Scheduled sync failed", "failures", failures, "error", err)
+		default:
+			if failures >= opts.BackoffAfter && opts.BackoffAfter > 0 {
+				log.Info("Recovered after failed syncs", logging.Icon("✅"), "failures", failures)
+			}
+			failures = 0
+		}
+	}
+
+	if opts.RunOnStart {
+		if opts.Quiet.Contains(time.Now()) {
+			log.Info("Quiet hours - skipping initial sync", logging.Icon("😴"))
+		} else {
+			log.Info("Running initial sync", logging.Icon("📥"))
+			attempt()
+		}
+	}
+
+	for {
+		next := nextRun(time.Now(), opts, failures, lastFailure)
+		if next.IsZero() {
+			log.Warn("Schedule never fires again; scheduler stopped")
+			return
+		}
+		if opts.Jitter > 0 {
+			next = next.Add(rand.N(opts.Jitter))
+		}
+		log.Info("Next sync scheduled", logging.Icon("⏰"), "at", next.Format("2006-01-02 15:04:05"))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		attempt()
+	}
+}
+
+// nextRun returns the next scheduled time after now outside quiet hours
+// and, after repeated failures, the backoff delay
+func nextRun(now time.Time, opts Options, failures int, lastFailure time.Time) time.Time {
+	notBefore := now
+	if opts.BackoffAfter > 0 && failures >= opts.BackoffAfter {
+		delay := 5 * time.Minute << min(failures-opts.BackoffAfter, 16)
+		if opts.MaxBackoff > 0 && delay > opts.MaxBackoff {
+			delay = opts.MaxBackoff
+		}
+		if until := lastFailure.Add(delay); until.After(notBefore) {
+			notBefore = until
+		}
+	}
+
+	next := opts.Schedule.Next(now)
+	// Bounded so a schedule that only fires during quiet hours ends
+	for i := 0; i < 100000 && !next.IsZero(); i++ {
+		if !next.Before(notBefore) && !opts.Quiet.Contains(next) {
+			return next
+		}
+		next = opts.Schedule.Next(next)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,219 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
+)
+
+// Platform is the destination name used in sync history
+const Platform = "sheet"
+
+// Header is the layout of training log sheets: one row per exercise set
+// (or per section, for sections without exercises), keyed by workout ID
+var Header = []string{
+	"Workout ID", "Date", "Time", "Workout", "Type", "Score", "Rx",
+	"Section", "Exercise", "Round", "Reps", "Weight", "Weight Unit",
+	"Distance", "Distance Unit", "Calories", "Exercise Time", "PR", "Notes",
+}
+
+func init() {
+	destination.Register(Platform, func(cfg *config.Config) (destination.Destination, error) {
+		return New(cfg.Sheet.File, Athlete(cfg)), nil
+	})
+}
+
+// Athlete returns the sheet name workouts are logged under
+func Athlete(cfg *config.Config) string {
+	if cfg.Sheet.Athlete != "" {
+		return cfg.Sheet.Athlete
+	}
+	if user, _, ok := strings.Cut(cfg.Aimharder.Email, "@"); ok && user != "" {
+		return user
+	}
+	return "Workouts"
+}
+
+// Destination keeps a training log in a local XLSX or ODS workbook
+type Destination struct {
+	path    string
+	athlete string
+}
+
+// New creates a destination writing to the athlete's sheet in path
+func New(path, athlete string) *Destination {
+	return &Destination{path: path, athlete: SheetName(athlete)}
+}
+
+// Name returns the platform name
+func (d *Destination) Name() string {
+	return Platform
+}
+
+// Authenticate checks that the workbook can be read
+func (d *Destination) Authenticate(ctx context.Context) error {
+	if d.path == "" {
+		return fmt.Errorf("sheet.file is required (set AIMHARDER_SHEET_FILE)")
+	}
+	_, err := Open(d.path)
+	return err
+}
+
+// ListExisting returns nothing: rows are upserted by workout ID, so
+// writing a workout twice never duplicates it
+func (d *Destination) ListExisting(ctx context.Context, start, end time.Time) ([]destination.Activity, error) {
+	return nil, nil
+}
+
+// Upload adds the workout's rows to the athlete's sheet
+func (d *Destination) Upload(ctx context.Context, workout *models.Workout, file string) (*destination.UploadResult, error) {
+	if err := d.upsert(workout); err != nil {
+		return nil, err
+	}
+	return &destination.UploadResult{ID: workout.ID}, nil
+}
+
+// Update replaces the workout's rows, e.g. after a corrected score
+func (d *Destination) Update(ctx context.Context, externalID string, workout *models.Workout) error {
+	return d.upsert(workout)
+}
+
+// Delete removes the workout's rows
+func (d *Destination) Delete(ctx context.Context, externalID string) error {
+	wb, err := Open(d.path)
+	if err != nil {
+		return err
+	}
+	if !wb.Sheet(d.athlete).Remove(externalID) {
+		return nil
+	}
+	return wb.Save(d.path)
+}
+
+// Preview describes the rows Upload would write
+func (d *Destination) Preview(workout *models.Workout, file string) *destination.Preview {
+	return &destination.Preview{
+		Name:        workout.Name,
+		Type:        string(workout.Type),
+		StartDate:   workout.Date.Format("2006-01-02 15:04"),
+		Description: workout.Description,
+		ExternalID:  workout.ID,
+		DataType:    fmt.Sprintf("%d %s row(s)", len(Rows(workout)), strings.TrimPrefix(filepath.Ext(d.path), ".")),
+		File:        fmt.Sprintf("%s [%s]", d.path, d.athlete),
+	}
+}
+
+func (d *Destination) upsert(workout *models.Workout) error {
+	wb, err := Open(d.path)
+	if err != nil {
+		return err
+	}
+	wb.Sheet(d.athlete).Upsert(workout.ID, Rows(workout), Header)
+	return wb.Save(d.path)
+}
+
+// Rows returns the training log rows for a workout, laid out as Header
+func Rows(w *models.Workout) [][]string {
+	base := []string{
+		w.ID,
+		w.Date.Format("2006-01-02"),
+		w.Date.Format("15:04"),
+		w.Name,
+		string(w.Type),
+		render.FormatScore(w.Result),
+		rx(w.Result),
+	}
+	row := func(section string, ex *models.Exercise, notes string) []string {
+		r := append(append([]string(nil), base...), section)
+		if ex == nil {
+			return append(r, "", "", "", "", "", "", "", "", "", "", notes)
+		}
+		pr := ""
+		if ex.PR {
+			pr = "PR"
+		}
+		return append(r,
+			ex.Name,
+			formatInt(ex.Round),
+			formatInt(ex.Reps),
+			formatFloat(ex.Weight),
+			unitIfSet(ex.Weight, ex.WeightUnit),
+			formatFloat(ex.Distance),
+			unitIfSet(ex.Distance, ex.DistanceUnit),
+			formatInt(ex.Calories),
+			ex.Time,
+			pr,
+			notes,
+		)
+	}
+
+	var rows [][]string
+	for i, s := range w.Sections {
+		found := false
+		for j := range w.Exercises {
+			ex := &w.Exercises[j]
+			if ex.SectionIndex == i && !render.IsPlaceholderExercise(ex.Name) {
+				rows = append(rows, row(s.Name, ex, ex.Notes))
+				found = true
+			}
+		}
+		if !found {
+			rows = append(rows, row(s.Name, nil, s.Notes))
+		}
+	}
+	for j := range w.Exercises {
+		ex := &w.Exercises[j]
+		if (ex.SectionIndex < 0 || ex.SectionIndex >= len(w.Sections)) && !render.IsPlaceholderExercise(ex.Name) {
+			rows = append(rows, row("", ex, ex.Notes))
+		}
+	}
+	if len(rows) == 0 {
+		notes := ""
+		if w.Result != nil {
+			notes = w.Result.Notes
+		}
+		rows = append(rows, row("", nil, notes))
+	}
+	return rows
+}
+
+func rx(r *models.WorkoutResult) string {
+	switch {
+	case r == nil:
+		return ""
+	case r.RxPlus:
+		return "Rx+"
+	case r.Scaled:
+		return "Scaled"
+	}
+	return "Rx"
+}
+
+func formatInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func formatFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func unitIfSet(value float64, unit string) string {
+	if value == 0 {
+		return ""
+	}
+	return unit
+}
@@ -0,0 +1,205 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	odsMimeType = "application/vnd.oasis.opendocument.spreadsheet"
+	odsManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="` + odsMimeType + `"/>
+<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>`
+
+	nsTable  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	nsOffice = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	nsText   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// writeODS encodes a workbook as an OpenDocument spreadsheet
+func writeODS(wb *Workbook) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// The mimetype must be the first entry and stored uncompressed
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add mimetype: %w", err)
+	}
+	io.WriteString(w, odsMimeType)
+
+	sheets := wb.Sheets
+	if len(sheets) == 0 {
+		sheets = []*Sheet{{Name: "Workouts"}}
+	}
+
+	var content strings.Builder
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	content.WriteString(`<office:document-content xmlns:office="` + nsOffice + `" xmlns:table="` + nsTable + `" xmlns:text="` + nsText + `" office:version="1.2"><office:body><office:spreadsheet>`)
+	for _, s := range sheets {
+		fmt.Fprintf(&content, `<table:table table:name="%s">`, xmlEscape(s.Name))
+		for r, row := range s.Rows {
+			content.WriteString(`<table:table-row>`)
+			for c, value := range row {
+				switch {
+				case value == "":
+					content.WriteString(`<table:table-cell/>`)
+				case r > 0 && c > 0 && numberRegex.MatchString(value):
+					fmt.Fprintf(&content, `<table:table-cell office:value-type="float" office:value="%s"><text:p>%s</text:p></table:table-cell>`, value, value)
+				default:
+					content.WriteString(`<table:table-cell office:value-type="string">`)
+					for _, line := range strings.Split(value, "\n") {
+						fmt.Fprintf(&content, `<text:p>%s</text:p>`, xmlEscape(line))
+					}
+					content.WriteString(`</table:table-cell>`)
+				}
+			}
+			content.WriteString(`</table:table-row>`)
+		}
+		content.WriteString(`</table:table>`)
+	}
+	content.WriteString(`</office:spreadsheet></office:body></office:document-content>`)
+
+	parts := []struct{ name, content string }{
+		{"META-INF/manifest.xml", odsManifest},
+		{"content.xml", content.String()},
+	}
+	for _, p := range parts {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", p.name, err)
+		}
+		if _, err := io.WriteString(w, p.content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", p.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize workbook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type odsCell struct {
+	Repeat     int            `xml:"urn:oasis:names:tc:opendocument:xmlns:table:1.0 number-columns-repeated,attr"`
+	ValueType  string         `xml:"urn:oasis:names:tc:opendocument:xmlns:office:1.0 value-type,attr"`
+	Value      string         `xml:"urn:oasis:names:tc:opendocument:xmlns:office:1.0 value,attr"`
+	Paragraphs []odsParagraph `xml:"urn:oasis:names:tc:opendocument:xmlns:text:1.0 p"`
+}
+
+func (c odsCell) String() string {
+	if c.ValueType == "float" || c.ValueType == "percentage" || c.ValueType == "currency" {
+		if c.Value != "" {
+			return c.Value
+		}
+	}
+	lines := make([]string, len(c.Paragraphs))
+	for i, p := range c.Paragraphs {
+		lines[i] = string(p)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// odsParagraph collects the text of a <text:p>, including nested spans
+type odsParagraph string
+
+func (p *odsParagraph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var b strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			if t.Name.Local == "s" { // <text:s text:c="n"/> is n spaces
+				n := 1
+				for _, a := range t.Attr {
+					if a.Name.Local == "c" {
+						fmt.Sscan(a.Value, &n)
+					}
+				}
+				b.WriteString(strings.Repeat(" ", max(n, 1)))
+			}
+		case xml.EndElement:
+			if t.Name == start.Name {
+				*p = odsParagraph(b.String())
+				return nil
+			}
+		}
+	}
+}
+
+// readODS decodes the sheets of an OpenDocument spreadsheet. Runs of
+// repeated empty cells and rows (which LibreOffice writes to pad sheets
+// to their full size) are only expanded when followed by content.
+func readODS(data []byte) (*Workbook, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an ODS file: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var doc struct {
+		Tables []struct {
+			Name string `xml:"urn:oasis:names:tc:opendocument:xmlns:table:1.0 name,attr"`
+			Rows []struct {
+				Repeat int       `xml:"urn:oasis:names:tc:opendocument:xmlns:table:1.0 number-rows-repeated,attr"`
+				Cells  []odsCell `xml:"urn:oasis:names:tc:opendocument:xmlns:table:1.0 table-cell"`
+			} `xml:"urn:oasis:names:tc:opendocument:xmlns:table:1.0 table-row"`
+		} `xml:"body>spreadsheet>table"`
+	}
+	if err := decodePart(files, "content.xml", &doc); err != nil {
+		return nil, err
+	}
+
+	wb := &Workbook{}
+	for _, t := range doc.Tables {
+		s := &Sheet{Name: t.Name}
+		pendingRows := 0
+		for _, row := range t.Rows {
+			var values []string
+			pendingCells := 0
+			for _, c := range row.Cells {
+				repeat := max(c.Repeat, 1)
+				value := c.String()
+				if value == "" {
+					pendingCells += repeat
+					continue
+				}
+				for ; pendingCells > 0; pendingCells-- {
+					values = append(values, "")
+				}
+				for i := 0; i < repeat; i++ {
+					values = append(values, value)
+				}
+			}
+
+			repeat := max(row.Repeat, 1)
+			if len(values) == 0 {
+				pendingRows += repeat
+				continue
+			}
+			for ; pendingRows > 0; pendingRows-- {
+				s.Rows = append(s.Rows, nil)
+			}
+			for i := 0; i < repeat; i++ {
+				s.Rows = append(s.Rows, values)
+			}
+		}
+		wb.Sheets = append(wb.Sheets, s)
+	}
+
+	return wb, nil
+}
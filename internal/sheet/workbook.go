@@ -0,0 +1,260 @@
+package sheet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Workbook is a spreadsheet file with one or more sheets
+type Workbook struct {
+	Sheets []*Sheet
+}
+
+// Sheet is a table whose first row is the header and whose first column
+// is the row key (the workout ID). A key may span several rows, one per
+// exercise set.
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// Open reads an XLSX or ODS workbook, chosen by file extension. A missing
+// file is an empty workbook.
+func Open(path string) (*Workbook, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Workbook{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		return readXLSX(data)
+	case ".ods":
+		return readODS(data)
+	default:
+		return nil, fmt.Errorf("unsupported spreadsheet %s (use .xlsx or .ods)", path)
+	}
+}
+
+// Save writes the workbook to path, replacing the file atomically so a
+// crash never leaves a half-written log
+func (wb *Workbook) Save(path string) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		data, err = writeXLSX(wb)
+	case ".ods":
+		data, err = writeODS(wb)
+	default:
+		return fmt.Errorf("unsupported spreadsheet %s (use .xlsx or .ods)", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// Sheet returns the sheet with the given name, adding it if needed
+func (wb *Workbook) Sheet(name string) *Sheet {
+	name = SheetName(name)
+	for _, s := range wb.Sheets {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	s := &Sheet{Name: name}
+	wb.Sheets = append(wb.Sheets, s)
+	return s
+}
+
+// Merge upserts every sheet of other into the sheet of the same name
+func (wb *Workbook) Merge(other *Workbook) {
+	for _, src := range other.Sheets {
+		if len(src.Rows) == 0 {
+			continue
+		}
+		dst := wb.Sheet(src.Name)
+
+		var keys []string
+		groups := make(map[string][][]string)
+		for _, row := range src.Rows[1:] {
+			key := cell(row, 0)
+			if key == "" {
+				continue
+			}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], row)
+		}
+		for _, key := range keys {
+			dst.Upsert(key, groups[key], src.Rows[0])
+		}
+	}
+}
+
+// SetHeader makes header the sheet's header, keeping any extra columns the
+// sheet already had (e.g. added by hand) after it
+func (s *Sheet) SetHeader(header []string) {
+	if len(s.Rows) == 0 {
+		s.Rows = [][]string{append([]string(nil), header...)}
+		return
+	}
+
+	current := s.Rows[0]
+	merged := append([]string(nil), header...)
+	for _, col := range current {
+		if indexOf(merged, col) < 0 {
+			merged = append(merged, col)
+		}
+	}
+	if equal(current, merged) {
+		return
+	}
+
+	for i := 1; i < len(s.Rows); i++ {
+		s.Rows[i] = realign(s.Rows[i], current, merged)
+	}
+	s.Rows[0] = merged
+}
+
+// Upsert replaces the rows for key with rows (laid out as header), or
+// appends them when the key is new. Columns the sheet has but header
+// lacks keep their previous values where possible.
+func (s *Sheet) Upsert(key string, rows [][]string, header []string) {
+	s.SetHeader(header)
+	cols := s.Rows[0]
+
+	first, old := s.remove(key)
+	aligned := make([][]string, len(rows))
+	for i, row := range rows {
+		aligned[i] = realign(row, header, cols)
+		aligned[i][0] = key
+		// Carry over hand-entered extra columns from the old rows
+		if i < len(old) {
+			for c := len(header); c < len(cols); c++ {
+				aligned[i][c] = cell(old[i], c)
+			}
+		}
+	}
+
+	if first < 0 {
+		s.Rows = append(s.Rows, aligned...)
+		return
+	}
+	s.Rows = append(s.Rows[:first], append(aligned, s.Rows[first:]...)...)
+}
+
+// Remove deletes the rows for key and reports whether there were any
+func (s *Sheet) Remove(key string) bool {
+	first, _ := s.remove(key)
+	return first >= 0
+}
+
+// Has reports whether the sheet has rows for key
+func (s *Sheet) Has(key string) bool {
+	for i := 1; i < len(s.Rows); i++ {
+		if cell(s.Rows[i], 0) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// remove deletes the rows for key, returning the index of the first one
+// (or -1) and the removed rows
+func (s *Sheet) remove(key string) (int, [][]string) {
+	first := -1
+	var removed [][]string
+	kept := s.Rows[:0:0]
+	for i, row := range s.Rows {
+		if i > 0 && cell(row, 0) == key {
+			if first < 0 {
+				first = len(kept)
+			}
+			removed = append(removed, row)
+			continue
+		}
+		kept = append(kept, row)
+	}
+	s.Rows = kept
+	return first, removed
+}
+
+// SheetName makes name valid as a sheet name: at most 31 characters and
+// none of []:*?/\
+func SheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Workouts"
+	}
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	return name
+}
+
+// realign reorders row from the from column layout to the to layout
+func realign(row, from, to []string) []string {
+	out := make([]string, len(to))
+	for i, col := range from {
+		if j := indexOf(to, col); j >= 0 {
+			out[j] = cell(row, i)
+		}
+	}
+	return out
+}
+
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+func indexOf(cols []string, col string) int {
+	for i, c := range cols {
+		if strings.EqualFold(c, col) {
+			return i
+		}
+	}
+	return -1
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
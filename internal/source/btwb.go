@@ -0,0 +1,17 @@
+package source
+
+// Beyond the Whiteboard exports (Settings → Export Data → Workout Sessions)
+// have one row per logged workout with the columns Date, Workout, Result,
+// Prescribed, Pukie, Work Performed, Time, Description and Notes.
+func init() {
+	Register("btwb", tabular("btwb", columns{
+		Date:        []string{"date"},
+		Title:       []string{"workout", "workout name", "title"},
+		Description: []string{"description", "workout description"},
+		Score:       []string{"result", "formatted result", "score"},
+		ScoreType:   []string{"result type", "score type"},
+		RX:          []string{"prescribed", "rx"},
+		PR:          []string{"pr", "personal record"},
+		Notes:       []string{"notes"},
+	}))
+}
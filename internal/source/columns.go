@@ -0,0 +1,112 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+// columns lists, for each field, the column names an app uses for it in
+// its exports (CSV headers or JSON keys), most specific first
+type columns struct {
+	Date        []string
+	Title       []string
+	Description []string
+	Score       []string
+	ScoreType   []string
+	Lift        []string // barbell lift name, for strength components
+	RX          []string // "RX", "RX+", "Scaled" or a yes/no flag
+	RxPlus      []string
+	PR          []string
+	Notes       []string
+}
+
+// tabular returns an importer for exports with one row per logged
+// component, described by cols
+func tabular(format string, cols columns) Importer {
+	return func(records []Record, opts Options) ([]models.Workout, error) {
+		var entries []entry
+		for i, rec := range records {
+			date := rec.Get(cols.Date...)
+			title := rec.Get(cols.Title...)
+			lift := rec.Get(cols.Lift...)
+			if date == "" && title == "" {
+				continue // blank line
+			}
+
+			day, err := parseDay(date, opts.location())
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+1, err)
+			}
+
+			if title == "" {
+				title = lift
+			}
+			if title == "" {
+				title = "Workout"
+			}
+
+			score := rec.Get(cols.Score...)
+			rx, rxPlus := parseRX(rec.Get(cols.RX...))
+			if isTrue(rec.Get(cols.RxPlus...)) {
+				rx, rxPlus = true, true
+			}
+
+			e := entry{
+				Day:    day,
+				Score:  score,
+				RxPlus: rxPlus,
+				Section: models.WorkoutSection{
+					Name:        title,
+					Description: rec.Get(cols.Description...),
+					Notes:       rec.Get(cols.Notes...),
+					RX:          rx,
+				},
+			}
+			e.Section.Type = detectType(title, e.Section.Description, rec.Get(cols.ScoreType...))
+
+			var result models.WorkoutResult
+			parseScore(score, e.Section.Type, &result)
+			if result.Time != nil {
+				e.Section.Time = score
+			}
+			e.Section.RoundsCompleted = result.Rounds
+			e.Section.RepsAchieved = result.Reps
+
+			if lift != "" || e.Section.Type == models.WorkoutTypeStrength {
+				if lift == "" {
+					lift = title
+				}
+				weight, unit := parseLoad(score, "kg")
+				e.Exercises = append(e.Exercises, models.Exercise{
+					Name:       lift,
+					Weight:     weight,
+					WeightUnit: unit,
+					PR:         isPR(rec.Get(cols.PR...)),
+				})
+			}
+
+			entries = append(entries, e)
+		}
+
+		return buildWorkouts(format, entries, opts)
+	}
+}
+
+// parseRX interprets an "RX / RX+ / Scaled" column or a yes/no flag
+func parseRX(value string) (rx, rxPlus bool) {
+	switch v := strings.ToUpper(strings.TrimSpace(value)); {
+	case v == "RX+" || v == "RX PLUS":
+		return true, true
+	case v == "RX" || v == "PRESCRIBED":
+		return true, false
+	default:
+		return isTrue(v), false
+	}
+}
+
+// isPR interprets a PR column, which is either a flag or the text "PR"
+func isPR(value string) bool {
+	return strings.EqualFold(strings.TrimSpace(value), "PR") || isTrue(value)
+}
@@ -0,0 +1,154 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Record is one row of an export file, keyed by normalized column name
+type Record map[string]string
+
+// Get returns the first non-empty value among the given column names
+func (r Record) Get(names ...string) string {
+	for _, name := range names {
+		if v := strings.TrimSpace(r[normalizeKey(name)]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// normalizeKey lowercases a column name and drops spaces, dashes and
+// underscores, so "Best Result", "best_result" and "bestResult" match
+func normalizeKey(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(key)) {
+		if r == ' ' || r == '_' || r == '-' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ReadRecords reads a CSV file with a header row, or a JSON array of flat
+// objects (optionally wrapped in an object with a single array field)
+func ReadRecords(r io.Reader) ([]Record, error) {
+	br := bufio.NewReader(r)
+
+	// Skip a UTF-8 byte order mark, which spreadsheet exports often have
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.Discard(1)
+			continue
+		case '[', '{':
+			return readJSONRecords(br)
+		default:
+			return readCSVRecords(br)
+		}
+	}
+}
+
+func readCSVRecords(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = normalizeKey(header[i])
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row: %w", err)
+		}
+
+		record := make(Record, len(header))
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func readJSONRecords(r io.Reader) ([]Record, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		// Accept {"workouts": [...]} and similar wrappers
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &wrapper); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		for _, v := range wrapper {
+			if json.Unmarshal(v, &items) == nil {
+				break
+			}
+		}
+		if items == nil {
+			return nil, fmt.Errorf("JSON export has no array of workouts")
+		}
+	}
+
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		record := make(Record, len(item))
+		for k, v := range item {
+			record[normalizeKey(k)] = jsonString(v)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// jsonString converts a decoded JSON value to the string a CSV would hold
+func jsonString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
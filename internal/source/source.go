@@ -0,0 +1,144 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
+)
+
+// Aimharder is the name of the default source, the Aimharder website
+const Aimharder = "aimharder"
+
+// DefaultStartTime is the time of day given to imported workouts when
+// Options.StartTime is not set
+const DefaultStartTime = "12:00"
+
+// WorkoutSource is somewhere workouts are read from
+type WorkoutSource interface {
+	// Name returns the source name (e.g. "aimharder", "sugarwod")
+	Name() string
+
+	// Workouts returns the workouts logged between start and end (inclusive)
+	Workouts(ctx context.Context, start, end time.Time) ([]models.Workout, error)
+}
+
+// Options configures importers
+type Options struct {
+	// Location is the time zone export dates are interpreted in
+	Location *time.Location
+
+	// StartTime is the "HH:MM" time of day given to imported workouts,
+	// since exports only record the date (default: DefaultStartTime)
+	StartTime string
+
+	// Renderer renders descriptions; the built-in templates are used when nil
+	Renderer *render.Renderer
+}
+
+// renderer returns the configured renderer or the built-in one
+func (o Options) renderer() *render.Renderer {
+	if o.Renderer == nil {
+		return render.Default()
+	}
+	return o.Renderer
+}
+
+// location returns the configured time zone or the local one
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+	return o.Location
+}
+
+// Importer converts the records of an export file into workouts
+type Importer func(records []Record, opts Options) ([]models.Workout, error)
+
+var registry = map[string]Importer{}
+
+// Register makes an importer available under the given format name
+func Register(format string, importer Importer) {
+	registry[strings.ToLower(format)] = importer
+}
+
+// Formats returns the names of all registered import formats
+func Formats() []string {
+	formats := make([]string, 0, len(registry))
+	for name := range registry {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// FileSource reads workouts from an export file of another app
+type FileSource struct {
+	format   string
+	path     string
+	importer Importer
+	opts     Options
+}
+
+// Open returns a source for a "<format>:<file>" spec, e.g.
+// "sugarwod:workouts.csv"
+func Open(spec string, opts Options) (*FileSource, error) {
+	format, path, ok := strings.Cut(spec, ":")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid source %q (expected <format>:<file>, formats: %s)", spec, strings.Join(Formats(), ", "))
+	}
+	format = strings.ToLower(format)
+	importer, ok := registry[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return &FileSource{format: format, path: path, importer: importer, opts: opts}, nil
+}
+
+// Name returns the import format
+func (s *FileSource) Name() string {
+	return s.format
+}
+
+// Workouts parses the export file and returns the workouts between start
+// and end
+func (s *FileSource) Workouts(ctx context.Context, start, end time.Time) ([]models.Workout, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s export: %w", s.format, err)
+	}
+	defer f.Close()
+
+	records, err := ReadRecords(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+
+	workouts, err := s.importer(records, s.opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import %s: %w", s.path, err)
+	}
+
+	var inRange []models.Workout
+	for _, w := range workouts {
+		day := time.Date(w.Date.Year(), w.Date.Month(), w.Date.Day(), 0, 0, 0, 0, start.Location())
+		if day.Before(start) || day.After(end) {
+			continue
+		}
+		inRange = append(inRange, w)
+	}
+
+	sort.Slice(inRange, func(i, j int) bool { return inRange[i].Date.Before(inRange[j].Date) })
+	return inRange, nil
+}
+
+// WorkoutID namespaces an imported workout ID with its format, so imports
+// never collide with Aimharder IDs or with each other
+func WorkoutID(format, key string) string {
+	return format + ":" + key
+}
@@ -0,0 +1,19 @@
+package source
+
+// SugarWOD exports (Athlete → Settings → Export Workouts) have one row per
+// logged component with the columns date, title, description,
+// best_result_raw, best_result_display, score_type, barbell_lift,
+// set_details, notes, rx_or_scaled and pr. Dates are MM/DD/YYYY.
+func init() {
+	Register("sugarwod", tabular("sugarwod", columns{
+		Date:        []string{"date"},
+		Title:       []string{"title"},
+		Description: []string{"description"},
+		Score:       []string{"best_result_display", "best_result_raw"},
+		ScoreType:   []string{"score_type"},
+		Lift:        []string{"barbell_lift"},
+		RX:          []string{"rx_or_scaled"},
+		PR:          []string{"pr"},
+		Notes:       []string{"notes"},
+	}))
+}
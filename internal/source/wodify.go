@@ -0,0 +1,19 @@
+package source
+
+// Wodify exports (Performance → Export Results) have one row per logged
+// component with the columns Date, Component, Result Type, Result, Rx,
+// Rx+, Is PR and Comment. Column names vary slightly between Wodify
+// versions, so the common variants are accepted.
+func init() {
+	Register("wodify", tabular("wodify", columns{
+		Date:        []string{"date", "class date"},
+		Title:       []string{"component", "component name", "workout", "wod"},
+		Description: []string{"component description", "description"},
+		Score:       []string{"result", "performance result", "score"},
+		ScoreType:   []string{"result type", "performance result type", "component type"},
+		RX:          []string{"rx", "is rx", "rx/scaled"},
+		RxPlus:      []string{"rx+", "is rx plus", "rx plus"},
+		PR:          []string{"is pr", "pr"},
+		Notes:       []string{"comment", "comments", "notes"},
+	}))
+}
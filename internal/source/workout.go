@@ -0,0 +1,241 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+// entry is one logged component (a lift, a metcon, ...) of an export.
+// Exports have one row per component; entries logged on the same day
+// become the sections of one workout, like an Aimharder class.
+type entry struct {
+	Day       time.Time
+	Section   models.WorkoutSection
+	Exercises []models.Exercise
+	Score     string // result as displayed by the app
+	RxPlus    bool
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"2006/01/02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"Mon, Jan 2, 2006",
+}
+
+// parseDay parses an export date and returns midnight of that day in loc
+func parseDay(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// buildWorkouts groups entries by day into workouts with namespaced IDs
+func buildWorkouts(format string, entries []entry, opts Options) ([]models.Workout, error) {
+	startTime := opts.StartTime
+	if startTime == "" {
+		startTime = DefaultStartTime
+	}
+	start, err := time.Parse("15:04", startTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time %q (expected HH:MM)", startTime)
+	}
+
+	var days []string
+	byDay := make(map[string][]entry)
+	for _, e := range entries {
+		key := e.Day.Format("2006-01-02")
+		if _, ok := byDay[key]; !ok {
+			days = append(days, key)
+		}
+		byDay[key] = append(byDay[key], e)
+	}
+
+	workouts := make([]models.Workout, 0, len(days))
+	for _, key := range days {
+		dayEntries := byDay[key]
+		day := dayEntries[0].Day
+
+		workout := models.Workout{
+			ID:        WorkoutID(format, key),
+			Date:      time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, day.Location()),
+			ClassTime: startTime,
+		}
+
+		var names []string
+		for i, e := range dayEntries {
+			workout.Sections = append(workout.Sections, e.Section)
+			for _, ex := range e.Exercises {
+				ex.SectionIndex = i
+				workout.Exercises = append(workout.Exercises, ex)
+			}
+			names = append(names, e.Section.Name)
+		}
+
+		// The workout result is the last conditioning piece of the day, or
+		// the last lift when there's only strength work
+		main := len(dayEntries) - 1
+		for i := len(dayEntries) - 1; i >= 0; i-- {
+			if t := dayEntries[i].Section.Type; t != models.WorkoutTypeStrength && t != models.WorkoutTypeSkill {
+				main = i
+				break
+			}
+		}
+
+		m := dayEntries[main]
+		workout.Name = strings.Join(names, " + ")
+		workout.Type = m.Section.Type
+		workout.Result = &models.WorkoutResult{
+			Score:  m.Score,
+			Scaled: !m.Section.RX,
+			RxPlus: m.RxPlus,
+			Notes:  m.Section.Notes,
+		}
+		parseScore(m.Score, workout.Type, workout.Result)
+
+		workout.Description = opts.renderer().Description(&workout)
+		workouts = append(workouts, workout)
+	}
+
+	return workouts, nil
+}
+
+// detectType guesses the workout type of a component from its title,
+// description and the app's score type
+func detectType(title, description, scoreType string) models.WorkoutType {
+	switch st := strings.ToLower(scoreType); {
+	case strings.Contains(st, "load") || strings.Contains(st, "weight"):
+		return models.WorkoutTypeStrength
+	case strings.Contains(st, "round") || strings.Contains(st, "amrap"):
+		return models.WorkoutTypeAMRAP
+	case strings.Contains(st, "time"):
+		return models.WorkoutTypeForTime
+	}
+
+	combined := strings.ToUpper(title + " " + description)
+	switch {
+	case strings.Contains(combined, "AMRAP"):
+		return models.WorkoutTypeAMRAP
+	case strings.Contains(combined, "FOR TIME") || strings.Contains(combined, "FORTIME"):
+		return models.WorkoutTypeForTime
+	case strings.Contains(combined, "EMOM") || strings.Contains(combined, "E2MOM"):
+		return models.WorkoutTypeEMOM
+	case strings.Contains(combined, "TABATA"):
+		return models.WorkoutTypeTabata
+	case strings.Contains(combined, "STRENGTH") || strings.Contains(combined, "1RM") ||
+		strings.Contains(combined, "5X5") || strings.Contains(combined, "3X3"):
+		return models.WorkoutTypeStrength
+	case strings.Contains(combined, "SKILL"):
+		return models.WorkoutTypeSkill
+	default:
+		return models.WorkoutTypeWOD
+	}
+}
+
+var (
+	clockRegex      = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2}))?$`)
+	roundsRepsRegex = regexp.MustCompile(`(?i)^(\d+)\s*(?:rounds?|rds?)?\s*\+\s*(\d+)`)
+	roundsRegex     = regexp.MustCompile(`(?i)^(\d+)\s*(?:rounds?|rds?)$`)
+	repsRegex       = regexp.MustCompile(`(?i)^(\d+)\s*reps?$`)
+	loadRegex       = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*(kgs?|lbs?|#)?$`)
+)
+
+// parseScore fills the structured fields of result from a displayed score
+// such as "12:34", "5 + 12", "150 reps" or "100 kg". A bare number is a load
+// only for strength work; elsewhere it's a rep count.
+func parseScore(score string, workoutType models.WorkoutType, result *models.WorkoutResult) {
+	score = strings.TrimSpace(score)
+
+	if d, ok := parseClock(score); ok {
+		result.Time = &d
+		return
+	}
+	if m := roundsRepsRegex.FindStringSubmatch(score); m != nil {
+		result.Rounds, _ = strconv.Atoi(m[1])
+		result.Reps, _ = strconv.Atoi(m[2])
+		return
+	}
+	if m := roundsRegex.FindStringSubmatch(score); m != nil {
+		result.Rounds, _ = strconv.Atoi(m[1])
+		return
+	}
+	if m := repsRegex.FindStringSubmatch(score); m != nil {
+		result.Reps, _ = strconv.Atoi(m[1])
+		return
+	}
+	if m := loadRegex.FindStringSubmatch(score); m != nil {
+		unit := strings.ToLower(m[2])
+		if unit == "" && workoutType != models.WorkoutTypeStrength {
+			if reps, err := strconv.Atoi(m[1]); err == nil {
+				result.Reps = reps
+			}
+			return
+		}
+		weight, _ := strconv.ParseFloat(m[1], 64)
+		if strings.HasPrefix(unit, "lb") || unit == "#" {
+			result.WeightLbs = weight
+		} else {
+			result.Weight = weight
+		}
+	}
+}
+
+// parseClock parses "M:SS" or "H:MM:SS"
+func parseClock(s string) (time.Duration, bool) {
+	m := clockRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	a, _ := strconv.Atoi(m[1])
+	b, _ := strconv.Atoi(m[2])
+	if m[3] != "" {
+		c, _ := strconv.Atoi(m[3])
+		return time.Duration(a)*time.Hour + time.Duration(b)*time.Minute + time.Duration(c)*time.Second, true
+	}
+	return time.Duration(a)*time.Minute + time.Duration(b)*time.Second, true
+}
+
+// parseLoad parses a weight such as "100 kg" or "225 lbs", using
+// defaultUnit when the value has none
+func parseLoad(s, defaultUnit string) (float64, string) {
+	m := loadRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, ""
+	}
+	weight, _ := strconv.ParseFloat(m[1], 64)
+	unit := strings.ToLower(m[2])
+	switch {
+	case strings.HasPrefix(unit, "lb") || unit == "#":
+		unit = "lbs"
+	case strings.HasPrefix(unit, "kg"):
+		unit = "kg"
+	default:
+		unit = defaultUnit
+	}
+	return weight, unit
+}
+
+// isTrue reports whether an export flag column is set ("yes", "true",
+// "x", "1", ...)
+func isTrue(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y", "true", "t", "1", "x", "✓":
+		return true
+	}
+	return false
+}
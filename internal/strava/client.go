@@ -6,17 +6,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
 
 	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/logging"
+	"github.com/aimharder-sync/internal/metrics"
 	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/tracing"
 )
 
 const (
@@ -52,12 +57,15 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		config:      cfg,
 		oauthConfig: oauthConfig,
 		tokenFile:   cfg.Storage.TokensFile,
-		httpClient:  &http.Client{Timeout: 60 * time.Second},
+		httpClient: &http.Client{
+			Timeout:   60 * time.Second,
+			Transport: tracing.Transport("strava", metrics.Transport("strava", rateLimitTransport{http.DefaultTransport})),
+		},
 	}
 
 	if err := client.loadTokens(); err != nil {
 		if cfg.Strava.RefreshToken == "" {
-			fmt.Printf("Note: No existing Strava tokens found. Run 'auth strava' to authenticate.\n")
+			slog.Info("No Strava tokens found; run 'auth strava' to authenticate")
 		}
 	}
 
@@ -78,6 +86,39 @@ func (c *Client) NeedsRefresh() bool {
 	return time.Until(c.tokens.ExpiresAt) < 5*time.Minute
 }
 
+// Connection describes the stored Strava authorization, without the tokens
+type Connection struct {
+	Authenticated bool       `json:"authenticated"`
+	CanRefresh    bool       `json:"can_refresh"` // has a refresh token
+	AthleteID     int64      `json:"athlete_id,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+}
+
+// Connection returns the state of the stored tokens
+func (c *Client) Connection() Connection {
+	if c.tokens == nil {
+		return Connection{}
+	}
+	conn := Connection{
+		Authenticated: c.tokens.AccessToken != "" || c.tokens.RefreshToken != "",
+		CanRefresh:    c.tokens.RefreshToken != "",
+		AthleteID:     c.tokens.AthleteID,
+	}
+	if c.tokens.AccessToken != "" && !c.tokens.ExpiresAt.IsZero() {
+		expiresAt := c.tokens.ExpiresAt
+		conn.ExpiresAt = &expiresAt
+	}
+	return conn
+}
+
+// LoadConnection returns the state of the stored tokens without creating
+// a client
+func LoadConnection(cfg *config.Config) Connection {
+	c := &Client{config: cfg, tokenFile: cfg.Storage.TokensFile}
+	c.loadTokens()
+	return c.Connection()
+}
+
 // GetAuthURL returns the URL for OAuth authorization
 // Note: Strava requires comma-separated scopes, not space-separated
 func (c *Client) GetAuthURL(state string) string {
@@ -135,6 +176,7 @@ func (c *Client) RefreshTokens(ctx context.Context) error {
 	ctxWithClient := context.WithValue(ctx, oauth2.HTTPClient, c.httpClient)
 	tokenSource := c.oauthConfig.TokenSource(ctxWithClient, token)
 	newToken, err := tokenSource.Token()
+	metrics.RecordLogin("strava", err)
 	if err != nil {
 		return fmt.Errorf("failed to refresh token: %w", err)
 	}
@@ -154,11 +196,11 @@ func (c *Client) EnsureValidToken(ctx context.Context) error {
 	}
 
 	if c.tokens.RefreshToken != "" && (c.tokens.AccessToken == "" || c.NeedsRefresh()) {
-		fmt.Println("  🔄 Refreshing Strava access token...")
+		slog.Info("Refreshing access token", logging.Icon("🔄"), "destination", "strava")
 		if err := c.RefreshTokens(ctx); err != nil {
 			return fmt.Errorf("failed to refresh Strava token: %w", err)
 		}
-		fmt.Println("  ✓ Token refreshed successfully")
+		slog.Debug("Token refreshed", "destination", "strava")
 	}
 
 	if c.tokens.AccessToken == "" {
@@ -177,11 +219,11 @@ func (c *Client) UploadActivity(ctx context.Context, tcxPath string, workout *mo
 	resp, err := c.doUpload(ctx, tcxPath, workout)
 	if err != nil {
 		if isUnauthorizedError(err) {
-			fmt.Println("  ⚠️  Token expired, refreshing...")
+			slog.Warn("Access token expired, refreshing", "destination", "strava")
 			if refreshErr := c.RefreshTokens(ctx); refreshErr != nil {
 				return nil, fmt.Errorf("upload failed and token refresh failed: %w", refreshErr)
 			}
-			fmt.Println("  ✓ Token refreshed, retrying upload...")
+			slog.Debug("Token refreshed, retrying upload", "destination", "strava")
 			return c.doUpload(ctx, tcxPath, workout)
 		}
 		return nil, err
@@ -306,10 +348,19 @@ func (c *Client) CheckUploadStatus(ctx context.Context, uploadID int64) (*Upload
 }
 
 // WaitForUpload waits for an upload to complete
-func (c *Client) WaitForUpload(ctx context.Context, uploadID int64, timeout time.Duration) (*UploadStatus, error) {
+func (c *Client) WaitForUpload(ctx context.Context, uploadID int64, timeout time.Duration) (status *UploadStatus, err error) {
+	ctx, span := tracing.Start(ctx, "strava.wait_for_upload", tracing.String("upload.id", strconv.FormatInt(uploadID, 10)))
+	polls := 0
+	defer func() {
+		span.SetAttributes(tracing.Int("polls", polls))
+		span.RecordError(err)
+		span.End()
+	}()
+
 	deadline := time.Now().Add(timeout)
 
 	for time.Now().Before(deadline) {
+		polls++
 		status, err := c.CheckUploadStatus(ctx, uploadID)
 		if err != nil {
 			return nil, err
@@ -441,31 +492,6 @@ func (c *Client) GetActivitiesInRange(ctx context.Context, start, end time.Time)
 	return activities, nil
 }
 
-// ActivityExistsForWorkout checks if an activity already exists in Strava for this workout
-// It checks by external_id or by matching date (same day)
-func (c *Client) ActivityExistsForWorkout(existingActivities []Activity, workout *models.Workout) *Activity {
-	for i := range existingActivities {
-		act := &existingActivities[i]
-
-		// Check by external_id (our workout ID)
-		if act.ExternalID == workout.ID {
-			return act
-		}
-
-		// Check by matching date (same day) and similar time
-		// Strava uses UTC, but we'll compare just the date part
-		workoutDate := workout.Date.Format("2006-01-02")
-		activityDate := act.StartDateLocal.Format("2006-01-02")
-
-		if workoutDate == activityDate {
-			// Same day - likely the same workout
-			return act
-		}
-	}
-
-	return nil
-}
-
 // mapWorkoutType maps internal workout type to Strava activity type
 func (c *Client) mapWorkoutType(workoutType models.WorkoutType) string {
 	// Strava activity types: https://developers.strava.com/docs/reference/#api-models-ActivityType
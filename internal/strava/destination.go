@@ -0,0 +1,123 @@
+package strava
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Platform is the destination name used in sync history
+const Platform = "strava"
+
+func init() {
+	destination.Register(Platform, func(cfg *config.Config) (destination.Destination, error) {
+		if err := cfg.ValidateStrava(); err != nil {
+			return nil, err
+		}
+		client, err := NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Strava client: %w", err)
+		}
+		return &Destination{client: client, update: cfg.Strava.Update}, nil
+	})
+}
+
+// Destination uploads workouts to Strava
+type Destination struct {
+	client *Client
+	update bool // overwrite activities whose workout changed
+}
+
+// Name returns the platform name
+func (d *Destination) Name() string {
+	return Platform
+}
+
+// Authenticate refreshes the access token if needed
+func (d *Destination) Authenticate(ctx context.Context) error {
+	if err := d.client.EnsureValidToken(ctx); err != nil {
+		return fmt.Errorf("%w (run 'aimharder-sync auth' or set STRAVA_REFRESH_TOKEN)", err)
+	}
+	return nil
+}
+
+// ListExisting returns the athlete's activities between start and end
+func (d *Destination) ListExisting(ctx context.Context, start, end time.Time) ([]destination.Activity, error) {
+	activities, err := d.client.GetActivitiesInRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make([]destination.Activity, len(activities))
+	for i, act := range activities {
+		existing[i] = destination.Activity{
+			ID:         strconv.FormatInt(act.ID, 10),
+			ExternalID: act.ExternalID,
+			Name:       act.Name,
+			StartTime:  act.StartDateLocal,
+		}
+	}
+	return existing, nil
+}
+
+// Upload uploads the TCX file and waits for Strava to process it
+func (d *Destination) Upload(ctx context.Context, workout *models.Workout, file string) (*destination.UploadResult, error) {
+	uploadResp, err := d.client.UploadActivity(ctx, file, workout)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadID := strconv.FormatInt(uploadResp.ID, 10)
+	status, err := d.client.WaitForUpload(ctx, uploadResp.ID, 2*time.Minute)
+	if status != nil && strings.Contains(status.Error, "duplicate") {
+		return &destination.UploadResult{UploadID: uploadID, Duplicate: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &destination.UploadResult{ID: strconv.FormatInt(status.ActivityID, 10), UploadID: uploadID}, nil
+}
+
+// Update sets the name, description and type of an uploaded activity
+func (d *Destination) Update(ctx context.Context, externalID string, workout *models.Workout) error {
+	if !d.update {
+		return fmt.Errorf("strava: %w", destination.ErrUpdateDisabled)
+	}
+	id, err := strconv.ParseInt(externalID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Strava activity ID %q", externalID)
+	}
+
+	preview := d.client.PreviewActivity(workout, "")
+	return d.client.UpdateActivity(ctx, id, map[string]interface{}{
+		"name":        preview.Name,
+		"description": preview.Description,
+		"sport_type":  preview.SportType,
+	})
+}
+
+// Delete is not available: the Strava API does not allow deleting activities
+func (d *Destination) Delete(ctx context.Context, externalID string) error {
+	return fmt.Errorf("strava: %w (delete activity %s on strava.com)", destination.ErrNotSupported, externalID)
+}
+
+// Preview describes the activity Upload would create
+func (d *Destination) Preview(workout *models.Workout, file string) *destination.Preview {
+	p := d.client.PreviewActivity(workout, file)
+	return &destination.Preview{
+		Name:        p.Name,
+		Type:        p.Type,
+		StartDate:   workout.Date.Format("2006-01-02T15:04:05Z"),
+		Description: p.Description,
+		ExternalID:  p.ExternalID,
+		DataType:    p.DataType,
+		File:        file,
+	}
+}
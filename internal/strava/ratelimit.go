@@ -0,0 +1,37 @@
+package strava
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aimharder-sync/internal/metrics"
+)
+
+// rateLimitTransport records Strava's rate limit headers, e.g.
+// "X-RateLimit-Limit: 200,2000" and "X-RateLimit-Usage: 34,1122" for the
+// 15 minute and daily windows
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		recordRateLimit(metrics.StravaRateLimit, resp.Header.Get("X-RateLimit-Limit"))
+		recordRateLimit(metrics.StravaRateLimitUsage, resp.Header.Get("X-RateLimit-Usage"))
+	}
+	return resp, err
+}
+
+func recordRateLimit(g *metrics.Gauge, header string) {
+	windows := []string{"15m", "daily"}
+	for i, part := range strings.Split(header, ",") {
+		if i >= len(windows) {
+			break
+		}
+		if v, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			g.Set(float64(v), windows[i])
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/aimharder-sync/internal/models"
+	"github.com/aimharder-sync/internal/render"
 )
 
 // TCX XML structures following Garmin Training Center Database v2 schema
@@ -138,6 +139,7 @@ type TPX struct {
 type Generator struct {
 	outputDir       string
 	defaultDuration time.Duration
+	renderer        *render.Renderer
 }
 
 // NewGenerator creates a new TCX generator
@@ -148,6 +150,14 @@ func NewGenerator(outputDir string, defaultDuration time.Duration) *Generator {
 	return &Generator{
 		outputDir:       outputDir,
 		defaultDuration: defaultDuration,
+		renderer:        render.Default(),
+	}
+}
+
+// SetRenderer sets the renderer used for activity notes
+func (g *Generator) SetRenderer(r *render.Renderer) {
+	if r != nil {
+		g.renderer = r
 	}
 }
 
@@ -159,6 +169,9 @@ func (g *Generator) Generate(workout *models.Workout) (string, error) {
 	}
 
 	tcx := g.workoutToTCX(workout)
+	if err := Validate(tcx); err != nil {
+		return "", fmt.Errorf("generated TCX is invalid: %w", err)
+	}
 
 	// Generate filename
 	filename := g.generateFilename(workout)
@@ -181,20 +194,50 @@ func (g *Generator) Generate(workout *models.Workout) (string, error) {
 	return filepath, nil
 }
 
-// GenerateAll creates TCX files for multiple workouts
-func (g *Generator) GenerateAll(workouts []models.Workout) ([]string, error) {
-	var files []string
+// Failure is a workout GenerateAll couldn't create a file for
+type Failure struct {
+	Workout *models.Workout
+	Err     error
+}
 
+// GenerateAll creates TCX files for multiple workouts. Workouts whose file
+// can't be generated are returned as failures and left out of generated,
+// so files[i] is always the file of generated[i].
+func (g *Generator) GenerateAll(workouts []models.Workout) (generated []models.Workout, files []string, failures []Failure) {
 	for i := range workouts {
 		filepath, err := g.Generate(&workouts[i])
 		if err != nil {
-			fmt.Printf("Warning: failed to generate TCX for workout %s: %v\n", workouts[i].ID, err)
+			failures = append(failures, Failure{Workout: &workouts[i], Err: err})
 			continue
 		}
+		generated = append(generated, workouts[i])
 		files = append(files, filepath)
 	}
 
-	return files, nil
+	return generated, files, failures
+}
+
+// Build returns the TCX document for a workout without writing it to disk
+func (g *Generator) Build(workout *models.Workout) *TrainingCenterDatabase {
+	return g.workoutToTCX(workout)
+}
+
+// Filename returns the file name Generate uses for a workout
+func (g *Generator) Filename(workout *models.Workout) string {
+	return g.generateFilename(workout)
+}
+
+// StartTime returns the start time written to the TCX file for a workout
+func (g *Generator) StartTime(workout *models.Workout) time.Time {
+	return g.getStartTime(workout)
+}
+
+// Duration returns the activity duration written to the TCX file for a workout
+func (g *Generator) Duration(workout *models.Workout) time.Duration {
+	if workout.Duration > 0 {
+		return workout.Duration
+	}
+	return g.defaultDuration
 }
 
 // workoutToTCX converts a Workout to TCX structure
@@ -206,13 +249,10 @@ func (g *Generator) workoutToTCX(workout *models.Workout) *TrainingCenterDatabas
 	startTime := g.getStartTime(workout)
 	startTimeStr := startTime.Format(time.RFC3339)
 
-	duration := g.defaultDuration
-	if workout.Duration > 0 {
-		duration = workout.Duration
-	}
+	duration := g.Duration(workout)
 
 	// Build notes/description
-	notes := g.buildNotes(workout)
+	notes := g.renderer.Notes(workout)
 
 	// Create lap
 	lap := Lap{
@@ -242,18 +282,24 @@ func (g *Generator) workoutToTCX(workout *models.Workout) *TrainingCenterDatabas
 		lap.Calories = 400 + rand.Intn(201)
 	}
 
-	// Generate trackpoints with simulated heart rate for better Strava calorie calculation
-	// CrossFit typical HR: warm-up ~120, working ~155, peaks ~175
-	lap.Track = generateTrackpointsWithHR(startTime, duration)
-
-	// Set average/max HR on lap level too
-	if lap.AverageHeartRateBpm == nil {
+	// Set average/max HR on lap level too, derived from the real values
+	// when only one of them is known
+	switch {
+	case lap.AverageHeartRateBpm == nil && lap.MaximumHeartRateBpm == nil:
 		lap.AverageHeartRateBpm = &HeartRate{Value: 150}
-	}
-	if lap.MaximumHeartRateBpm == nil {
 		lap.MaximumHeartRateBpm = &HeartRate{Value: 175}
+	case lap.AverageHeartRateBpm == nil:
+		lap.AverageHeartRateBpm = &HeartRate{Value: min(150, lap.MaximumHeartRateBpm.Value)}
+	case lap.MaximumHeartRateBpm == nil:
+		lap.MaximumHeartRateBpm = &HeartRate{Value: max(175, lap.AverageHeartRateBpm.Value)}
+	case lap.AverageHeartRateBpm.Value > lap.MaximumHeartRateBpm.Value:
+		lap.AverageHeartRateBpm.Value = lap.MaximumHeartRateBpm.Value
 	}
 
+	// Generate trackpoints with simulated heart rate for better Strava calorie calculation
+	// CrossFit typical HR: warm-up ~120, working ~155, peaks ~175
+	lap.Track = generateTrackpointsWithHR(startTime, duration, lap.MaximumHeartRateBpm.Value)
+
 	// Build activity
 	activity := Activity{
 		Sport: sport,
@@ -345,82 +391,14 @@ func (g *Generator) getStartTime(workout *models.Workout) time.Time {
 	return startTime
 }
 
-// buildNotes creates a comprehensive notes string for the workout
-func (g *Generator) buildNotes(workout *models.Workout) string {
-	var parts []string
-
-	// Add workout name
-	if workout.Name != "" {
-		parts = append(parts, fmt.Sprintf("📋 %s", workout.Name))
-	}
-
-	// Add workout type
-	if workout.Type != "" && workout.Type != models.WorkoutTypeWOD {
-		parts = append(parts, fmt.Sprintf("🏋️ Type: %s", workout.Type))
-	}
-
-	// Add description
-	if workout.Description != "" {
-		parts = append(parts, fmt.Sprintf("\n📝 Workout:\n%s", workout.Description))
-	}
-
-	// Add result
-	if workout.Result != nil {
-		parts = append(parts, "\n🎯 Result:")
-
-		if workout.Result.Time != nil {
-			parts = append(parts, fmt.Sprintf("  ⏱️ Time: %s", formatDuration(*workout.Result.Time)))
-		}
-
-		if workout.Result.Rounds > 0 {
-			if workout.Result.Reps > 0 {
-				parts = append(parts, fmt.Sprintf("  🔄 Rounds: %d + %d reps", workout.Result.Rounds, workout.Result.Reps))
-			} else {
-				parts = append(parts, fmt.Sprintf("  🔄 Rounds: %d", workout.Result.Rounds))
-			}
-		}
-
-		if workout.Result.Weight > 0 {
-			parts = append(parts, fmt.Sprintf("  🏋️ Weight: %.1f kg", workout.Result.Weight))
-		}
-
-		if workout.Result.Score != "" && workout.Result.Time == nil && workout.Result.Rounds == 0 {
-			parts = append(parts, fmt.Sprintf("  📊 Score: %s", workout.Result.Score))
-		}
-
-		// Scaling info
-		if workout.Result.RxPlus {
-			parts = append(parts, "  ⭐ Rx+")
-		} else if workout.Result.Scaled {
-			parts = append(parts, "  📉 Scaled")
-		} else {
-			parts = append(parts, "  ✅ Rx")
-		}
-
-		// Notes
-		if workout.Result.Notes != "" {
-			parts = append(parts, fmt.Sprintf("  💬 Notes: %s", workout.Result.Notes))
-		}
-	}
-
-	// Add box info
-	if workout.BoxName != "" {
-		parts = append(parts, fmt.Sprintf("\n🏠 Box: %s", workout.BoxName))
-	}
-
-	// Add sync info
-	parts = append(parts, "\n📤 Synced via AimHarder-Sync")
-
-	return strings.Join(parts, "\n")
-}
-
 // generateFilename creates a unique filename for the TCX file
 func (g *Generator) generateFilename(workout *models.Workout) string {
-	// Format: YYYY-MM-DD_HHMM_workout-name.tcx
+	// Format: YYYY-MM-DD_HHMM_workout-name_ID.tcx
+	// The workout ID keeps two same-named workouts on one day apart
 	dateStr := workout.Date.Format("2006-01-02")
 	timeStr := strings.ReplaceAll(workout.ClassTime, ":", "")
 	if timeStr == "" {
-		timeStr = "0000"
+		timeStr = workout.Date.Format("1504")
 	}
 
 	// Sanitize workout name for filename
@@ -429,19 +407,10 @@ func (g *Generator) generateFilename(workout *models.Workout) string {
 		name = "workout"
 	}
 
-	return fmt.Sprintf("%s_%s_%s.tcx", dateStr, timeStr, name)
-}
-
-// formatDuration formats a duration as MM:SS or HH:MM:SS
-func formatDuration(d time.Duration) string {
-	h := int(d.Hours())
-	m := int(d.Minutes()) % 60
-	s := int(d.Seconds()) % 60
-
-	if h > 0 {
-		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	if id := sanitizeFilename(workout.ID); id != "" {
+		return fmt.Sprintf("%s_%s_%s_%s.tcx", dateStr, timeStr, name, id)
 	}
-	return fmt.Sprintf("%d:%02d", m, s)
+	return fmt.Sprintf("%s_%s_%s.tcx", dateStr, timeStr, name)
 }
 
 // sanitizeFilename removes/replaces invalid filename characters
@@ -475,14 +444,17 @@ func sanitizeFilename(name string) string {
 // generateTrackpointsWithHR creates trackpoints with simulated heart rate data
 // This helps Strava calculate more accurate calories for CrossFit workouts
 // Pattern: warm-up (5 min, ~120 bpm) -> working (main, ~155 bpm with peaks) -> cool-down (5 min, ~130 bpm)
-func generateTrackpointsWithHR(startTime time.Time, duration time.Duration) *Track {
+// Trackpoints stay within the duration and at or below maxHR.
+func generateTrackpointsWithHR(startTime time.Time, duration time.Duration, maxHR int) *Track {
 	trackpoints := []Trackpoint{}
 
 	// Generate a trackpoint every 30 seconds
 	totalSeconds := int(duration.Seconds())
 	numPoints := totalSeconds / 30
+	step := 30
 	if numPoints < 4 {
-		numPoints = 4 // Minimum 4 points
+		numPoints = 4 // Minimum 4 points, spread over short workouts
+		step = totalSeconds / numPoints
 	}
 	if numPoints > 120 {
 		numPoints = 120 // Max 1 hour of 30s intervals
@@ -494,7 +466,7 @@ func generateTrackpointsWithHR(startTime time.Time, duration time.Duration) *Tra
 	intensityOffset := rand.Intn(26) - 10
 
 	for i := 0; i <= numPoints; i++ {
-		elapsed := i * 30 // seconds elapsed
+		elapsed := i * step // seconds elapsed
 		pointTime := startTime.Add(time.Duration(elapsed) * time.Second)
 
 		// Calculate heart rate based on workout phase
@@ -525,6 +497,7 @@ func generateTrackpointsWithHR(startTime time.Time, duration time.Duration) *Tra
 		if hr > 185 {
 			hr = 185
 		}
+		hr = min(hr, maxHR)
 
 		trackpoints = append(trackpoints, Trackpoint{
 			Time:         pointTime.Format(time.RFC3339),
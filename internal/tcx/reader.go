@@ -0,0 +1,154 @@
+package tcx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Parse reads a TrainingCenterDatabase document from r
+func Parse(r io.Reader) (*TrainingCenterDatabase, error) {
+	var db TrainingCenterDatabase
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Garmin devices and older exports sometimes declare ISO-8859-1 or
+		// windows-1252; the element content we care about is ASCII anyway.
+		return input, nil
+	}
+	if err := decoder.Decode(&db); err != nil {
+		return nil, fmt.Errorf("failed to parse TCX: %w", err)
+	}
+	return &db, nil
+}
+
+// ParseFile reads a TrainingCenterDatabase document from a file
+func ParseFile(path string) (*TrainingCenterDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TCX file: %w", err)
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Workouts converts the activities in a TCX document back into workouts.
+// Only the data carried by the TCX format is restored: start time, duration,
+// heart rate, calories and the name, type and description written to Notes
+// by the generator.
+func (db *TrainingCenterDatabase) Workouts() ([]models.Workout, error) {
+	if db.Activities == nil {
+		return nil, nil
+	}
+
+	var workouts []models.Workout
+	for i, activity := range db.Activities.Activity {
+		workout, err := activityToWorkout(&activity)
+		if err != nil {
+			return nil, fmt.Errorf("activity %d: %w", i+1, err)
+		}
+		workouts = append(workouts, workout)
+	}
+
+	return workouts, nil
+}
+
+// activityToWorkout converts a single TCX activity into a workout
+func activityToWorkout(activity *Activity) (models.Workout, error) {
+	start, err := parseTime(activity.ID)
+	if err != nil {
+		return models.Workout{}, fmt.Errorf("invalid activity Id %q: %w", activity.ID, err)
+	}
+
+	workout := models.Workout{
+		ID:        "tcx-" + start.UTC().Format("20060102T150405"),
+		Date:      start,
+		Type:      models.WorkoutTypeWOD,
+		ClassTime: start.Format("15:04"),
+	}
+
+	var (
+		totalSeconds float64
+		calories     int
+		avgHR        int
+		maxHR        int
+	)
+	for _, lap := range activity.Lap {
+		totalSeconds += lap.TotalTimeSeconds
+		calories += lap.Calories
+		if lap.AverageHeartRateBpm != nil && avgHR == 0 {
+			avgHR = lap.AverageHeartRateBpm.Value
+		}
+		if lap.MaximumHeartRateBpm != nil && lap.MaximumHeartRateBpm.Value > maxHR {
+			maxHR = lap.MaximumHeartRateBpm.Value
+		}
+	}
+	workout.Duration = time.Duration(totalSeconds * float64(time.Second))
+
+	notes := activity.Notes
+	if notes == "" && len(activity.Lap) > 0 {
+		notes = activity.Lap[0].Notes
+	}
+	parseNotes(notes, &workout)
+
+	if workout.Name == "" {
+		workout.Name = fmt.Sprintf("CrossFit WOD - %s", start.Format("2006-01-02"))
+	}
+
+	if avgHR > 0 || maxHR > 0 || calories > 0 {
+		workout.Result = &models.WorkoutResult{
+			AvgHeartRate: avgHR,
+			MaxHeartRate: maxHR,
+			Calories:     calories,
+		}
+	}
+
+	return workout, nil
+}
+
+// parseNotes restores the fields that buildNotes writes into the TCX notes
+func parseNotes(notes string, workout *models.Workout) {
+	if notes == "" {
+		return
+	}
+
+	var (
+		description []string
+		inWorkout   bool
+	)
+	for _, line := range strings.Split(notes, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "📋 ") && workout.Name == "":
+			workout.Name = strings.TrimPrefix(trimmed, "📋 ")
+		case strings.HasPrefix(trimmed, "🏋️ Type: ") && !inWorkout:
+			workout.Type = models.WorkoutType(strings.TrimPrefix(trimmed, "🏋️ Type: "))
+		case strings.HasPrefix(trimmed, "📝 Workout:"):
+			inWorkout = true
+		case strings.HasPrefix(trimmed, "🎯 Result:"), strings.HasPrefix(trimmed, "🏠 Box: "), strings.HasPrefix(trimmed, "📤 "):
+			inWorkout = false
+			if strings.HasPrefix(trimmed, "🏠 Box: ") {
+				workout.BoxName = strings.TrimPrefix(trimmed, "🏠 Box: ")
+			}
+		case inWorkout:
+			description = append(description, line)
+		}
+	}
+
+	workout.Description = strings.TrimSpace(strings.Join(description, "\n"))
+}
+
+// parseTime parses an xs:dateTime value as used in TCX files
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	// xs:dateTime allows omitting the timezone
+	return time.Parse("2006-01-02T15:04:05", value)
+}
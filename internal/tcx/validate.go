@@ -0,0 +1,225 @@
+package tcx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Allowed enumeration values from the TrainingCenterDatabase v2 schema
+var (
+	validSports         = []string{"Running", "Biking", "Other"}
+	validIntensities    = []string{"Active", "Resting"}
+	validTriggerMethods = []string{"Manual", "Distance", "Location", "Time", "HeartRate"}
+)
+
+const (
+	// Calories_t is an xs:unsignedShort
+	maxCalories = 65535
+	// HeartRateInBeatsPerMinute_t is an xs:unsignedByte with minInclusive 1
+	minHeartRate = 1
+	maxHeartRate = 255
+)
+
+// ValidationError describes a single rule violation in a TCX document
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors is the list of violations found in a TCX document
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d TCX validation error(s): %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Validate checks a TCX document against the TrainingCenterDatabase v2 rules
+// that matter for uploads: required elements, enumerations, timestamp
+// ordering and heart rate ranges. It returns ValidationErrors or nil.
+func Validate(db *TrainingCenterDatabase) error {
+	v := &validator{}
+
+	if db.Activities == nil || len(db.Activities.Activity) == 0 {
+		v.add("TrainingCenterDatabase", "missing Activities/Activity element")
+	} else {
+		var prevStart time.Time
+		for i := range db.Activities.Activity {
+			path := fmt.Sprintf("Activity[%d]", i+1)
+			start := v.activity(path, &db.Activities.Activity[i])
+			if !start.IsZero() && !prevStart.IsZero() && start.Before(prevStart) {
+				v.add(path+"/Id", "activities are not in chronological order")
+			}
+			if !start.IsZero() {
+				prevStart = start
+			}
+		}
+	}
+
+	if db.Author != nil && strings.TrimSpace(db.Author.Name) == "" {
+		v.add("Author", "missing Name element")
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// ValidateFile parses and validates a TCX file
+func ValidateFile(path string) error {
+	db, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+	return Validate(db)
+}
+
+// validator accumulates validation errors
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// activity validates an Activity and returns its parsed start time
+func (v *validator) activity(path string, activity *Activity) time.Time {
+	if !contains(validSports, activity.Sport) {
+		v.add(path, "invalid Sport %q (expected one of %s)", activity.Sport, strings.Join(validSports, ", "))
+	}
+
+	var start time.Time
+	if activity.ID == "" {
+		v.add(path, "missing Id element")
+	} else if t, err := parseTime(activity.ID); err != nil {
+		v.add(path+"/Id", "invalid dateTime %q", activity.ID)
+	} else {
+		start = t
+	}
+
+	if len(activity.Lap) == 0 {
+		v.add(path, "at least one Lap is required")
+	}
+
+	var prevEnd time.Time
+	for i := range activity.Lap {
+		lapPath := fmt.Sprintf("%s/Lap[%d]", path, i+1)
+		lapStart, lapEnd := v.lap(lapPath, &activity.Lap[i])
+		if lapStart.IsZero() {
+			continue
+		}
+		if i == 0 && !start.IsZero() && lapStart.Before(start) {
+			v.add(lapPath, "StartTime %s is before activity Id %s", activity.Lap[i].StartTime, activity.ID)
+		}
+		if !prevEnd.IsZero() && lapStart.Before(prevEnd) {
+			v.add(lapPath, "StartTime overlaps the previous lap")
+		}
+		prevEnd = lapEnd
+	}
+
+	if activity.Creator != nil && strings.TrimSpace(activity.Creator.Name) == "" {
+		v.add(path+"/Creator", "missing Name element")
+	}
+
+	return start
+}
+
+// lap validates a Lap and returns its start and end time
+func (v *validator) lap(path string, lap *Lap) (time.Time, time.Time) {
+	var start, end time.Time
+	if lap.StartTime == "" {
+		v.add(path, "missing StartTime attribute")
+	} else if t, err := parseTime(lap.StartTime); err != nil {
+		v.add(path, "invalid StartTime %q", lap.StartTime)
+	} else {
+		start = t
+		end = t.Add(time.Duration(lap.TotalTimeSeconds * float64(time.Second)))
+	}
+
+	if lap.TotalTimeSeconds <= 0 {
+		v.add(path+"/TotalTimeSeconds", "must be positive, got %g", lap.TotalTimeSeconds)
+	}
+	if lap.DistanceMeters < 0 {
+		v.add(path+"/DistanceMeters", "must not be negative, got %g", lap.DistanceMeters)
+	}
+	if lap.Calories < 0 || lap.Calories > maxCalories {
+		v.add(path+"/Calories", "out of range (0-%d), got %d", maxCalories, lap.Calories)
+	}
+	if !contains(validIntensities, lap.Intensity) {
+		v.add(path+"/Intensity", "invalid value %q (expected one of %s)", lap.Intensity, strings.Join(validIntensities, ", "))
+	}
+	if !contains(validTriggerMethods, lap.TriggerMethod) {
+		v.add(path+"/TriggerMethod", "invalid value %q (expected one of %s)", lap.TriggerMethod, strings.Join(validTriggerMethods, ", "))
+	}
+
+	v.heartRate(path+"/AverageHeartRateBpm", lap.AverageHeartRateBpm)
+	v.heartRate(path+"/MaximumHeartRateBpm", lap.MaximumHeartRateBpm)
+	if lap.AverageHeartRateBpm != nil && lap.MaximumHeartRateBpm != nil &&
+		lap.AverageHeartRateBpm.Value > lap.MaximumHeartRateBpm.Value {
+		v.add(path, "AverageHeartRateBpm %d exceeds MaximumHeartRateBpm %d",
+			lap.AverageHeartRateBpm.Value, lap.MaximumHeartRateBpm.Value)
+	}
+
+	if lap.Track != nil {
+		v.track(path+"/Track", lap.Track, start, end)
+	}
+
+	return start, end
+}
+
+// track validates trackpoint timestamps and heart rate values
+func (v *validator) track(path string, track *Track, lapStart, lapEnd time.Time) {
+	var prev time.Time
+	for i, tp := range track.Trackpoint {
+		tpPath := fmt.Sprintf("%s/Trackpoint[%d]", path, i+1)
+
+		if tp.Time == "" {
+			v.add(tpPath, "missing Time element")
+		} else if t, err := parseTime(tp.Time); err != nil {
+			v.add(tpPath+"/Time", "invalid dateTime %q", tp.Time)
+		} else {
+			if !prev.IsZero() && t.Before(prev) {
+				v.add(tpPath+"/Time", "%s is earlier than the previous trackpoint", tp.Time)
+			}
+			if !lapStart.IsZero() && (t.Before(lapStart) || t.After(lapEnd)) {
+				v.add(tpPath+"/Time", "%s is outside the lap (%s - %s)", tp.Time,
+					lapStart.Format(time.RFC3339), lapEnd.Format(time.RFC3339))
+			}
+			prev = t
+		}
+
+		v.heartRate(tpPath+"/HeartRateBpm", tp.HeartRateBpm)
+		if tp.Cadence < 0 || tp.Cadence > 254 {
+			v.add(tpPath+"/Cadence", "out of range (0-254), got %d", tp.Cadence)
+		}
+	}
+}
+
+// heartRate validates an optional heart rate value
+func (v *validator) heartRate(path string, hr *HeartRate) {
+	if hr == nil {
+		return
+	}
+	if hr.Value < minHeartRate || hr.Value > maxHeartRate {
+		v.add(path, "out of range (%d-%d), got %d", minHeartRate, maxHeartRate, hr.Value)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
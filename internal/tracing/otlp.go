@@ -0,0 +1,301 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aimharder-sync/internal/logging"
+)
+
+// Export batching
+const (
+	batchSize     = 512
+	queueSize     = 2048
+	flushInterval = 5 * time.Second
+	exportTimeout = 10 * time.Second
+)
+
+// scopeName identifies the spans' instrumentation in OTLP
+const scopeName = "github.com/aimharder-sync/internal/tracing"
+
+// Options configures Setup
+type Options struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, e.g.
+	// http://localhost:4318; spans are posted to <Endpoint>/v1/traces
+	Endpoint string
+
+	// ServiceName and ServiceVersion describe this program in the traces
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Setup starts recording spans and exporting them to a collector. Call
+// Shutdown before exiting to export the last spans.
+func Setup(opts Options) error {
+	endpoint := strings.TrimRight(opts.Endpoint, "/")
+	if endpoint == "" {
+		return fmt.Errorf("tracing endpoint is required")
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return fmt.Errorf("invalid tracing endpoint %q (expected http:// or https://)", opts.Endpoint)
+	}
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+
+	resource := []Attr{String("service.name", opts.ServiceName)}
+	if opts.ServiceVersion != "" {
+		resource = append(resource, String("service.version", opts.ServiceVersion))
+	}
+
+	exp := &exporter{
+		url:      endpoint,
+		client:   &http.Client{Timeout: exportTimeout},
+		resource: resource,
+		queue:    make(chan *Span, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		log:      logging.Component("tracing"),
+	}
+	go exp.run()
+	if old := current.Swap(exp); old != nil {
+		old.shutdown(context.Background())
+	}
+	return nil
+}
+
+// Shutdown stops recording spans and exports the queued ones, waiting until
+// ctx is done at most
+func Shutdown(ctx context.Context) error {
+	exp := current.Swap(nil)
+	if exp == nil {
+		return nil
+	}
+	return exp.shutdown(ctx)
+}
+
+// exporter batches ended spans and posts them to the collector
+type exporter struct {
+	url      string
+	client   *http.Client
+	resource []Attr
+	queue    chan *Span
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	log      *slog.Logger
+
+	failing bool // the last export failed; warn again only after a success
+}
+
+// enqueue queues an ended span, dropping it when the queue is full rather
+// than slowing down the sync
+func (e *exporter) enqueue(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+		e.log.Debug("Span queue full, dropping span", "span", s.name)
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stop:
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *exporter) shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to export traces: %w", ctx.Err())
+	}
+}
+
+// export posts spans to the collector. Failures are logged; tracing never
+// fails a sync.
+func (e *exporter) export(spans []*Span) {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		e.log.Warn("Failed to encode spans", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		e.log.Warn("Failed to export spans", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			err = fmt.Errorf("collector returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		}
+	}
+	if err != nil {
+		if !e.failing {
+			e.log.Warn("Failed to export spans", "url", e.url, "spans", len(spans), "error", err)
+		}
+		e.failing = true
+		return
+	}
+	e.failing = false
+	e.log.Debug("Exported spans", "spans", len(spans))
+}
+
+// OTLP/JSON request body, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func (e *exporter) request(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: unixNano(s.start),
+			EndTimeUnixNano:   unixNano(s.end),
+			Attributes:        keyValues(s.attrs),
+			Status:            otlpStatus{Code: s.status, Message: s.message},
+		}
+		if s.parentID != ([8]byte{}) {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, ev := range s.events {
+			span.Events = append(span.Events, otlpEvent{TimeUnixNano: unixNano(ev.time), Name: ev.name, Attributes: keyValues(ev.attrs)})
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: keyValues(e.resource)},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: encoded}},
+	}}}
+}
+
+// unixNano formats t as OTLP/JSON does 64-bit integers, as a string
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func keyValues(attrs []Attr) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]any
+		switch v := a.Value.(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: value})
+	}
+	return kvs
+}
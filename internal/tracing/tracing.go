@@ -0,0 +1,187 @@
+// Package tracing records OpenTelemetry-compatible spans of syncs and their
+// outbound HTTP requests, and exports them over OTLP/HTTP (JSON) to a
+// collector such as the OpenTelemetry Collector, Jaeger or Tempo. Until
+// Setup is called, Start returns nil spans, whose methods do nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Span kinds, as numbered by OTLP
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+// Status codes, as numbered by OTLP
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Attr is a span attribute
+type Attr struct {
+	Key   string
+	Value any // string, bool, int64 or float64
+}
+
+// String returns a string attribute
+func String(key, value string) Attr { return Attr{key, value} }
+
+// Int returns an integer attribute
+func Int(key string, value int) Attr { return Attr{key, int64(value)} }
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attr { return Attr{key, value} }
+
+// Float returns a floating point attribute
+func Float(key string, value float64) Attr { return Attr{key, value} }
+
+// event is something that happened during a span, e.g. an exception
+type event struct {
+	name  string
+	time  time.Time
+	attrs []Attr
+}
+
+// Span is a timed operation within a trace. A nil *Span is valid and
+// records nothing.
+type Span struct {
+	mu       sync.Mutex
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []Attr
+	events   []event
+	status   int
+	message  string
+	ended    bool
+	exporter *exporter
+}
+
+// current is the active exporter, nil while tracing is disabled
+var current atomic.Pointer[exporter]
+
+// Enabled reports whether spans are recorded
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+type spanKey struct{}
+
+// FromContext returns the span in ctx, or nil
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start starts a span, a child of the span in ctx if there is one, and
+// returns a context carrying it. End must be called on the span.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal, attrs...)
+}
+
+// StartKind is Start for a span of a given kind, e.g. KindClient for an
+// outbound request
+func StartKind(ctx context.Context, name string, kind int, attrs ...Attr) (context.Context, *Span) {
+	exp := current.Load()
+	if exp == nil {
+		return ctx, nil
+	}
+
+	span := &Span{name: name, kind: kind, start: time.Now(), attrs: attrs, exporter: exp}
+	if parent := FromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// TraceID returns the span's trace ID in hex, or "" for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SetAttributes adds attributes to the span, replacing any with the same key
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		replaced := false
+		for i := range s.attrs {
+			if s.attrs[i].Key == a.Key {
+				s.attrs[i] = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.attrs = append(s.attrs, a)
+		}
+	}
+}
+
+// RecordError marks the span as failed with err, recording it as an
+// exception event. A nil err is ignored. Cancellations are recorded but
+// don't fail the span.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event{name: "exception", time: time.Now(), attrs: []Attr{
+		String("exception.message", err.Error()),
+	}})
+	if !errors.Is(err, context.Canceled) {
+		s.status, s.message = StatusError, err.Error()
+	}
+}
+
+// SetStatus sets the span's status, e.g. StatusError with a message for a
+// failure that isn't an error value
+func (s *Span) SetStatus(code int, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.message = code, message
+}
+
+// End finishes the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.exporter.enqueue(s)
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aimharder-sync/internal/logging"
+)
+
+// Transport records a client span for each request, a child of the span in
+// the request's context, ending when the response headers arrive. base
+// defaults to http.DefaultTransport. The trace context isn't sent along;
+// AimHarder and Strava don't take part in traces.
+func Transport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{service: service, base: base}
+}
+
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Enabled() {
+		return t.base.RoundTrip(req)
+	}
+
+	// Query strings carry tokens and OAuth codes; they're redacted like logs
+	u := *req.URL
+	u.User = nil
+	_, span := StartKind(req.Context(), req.Method+" "+req.URL.Host, KindClient,
+		String("peer.service", t.service),
+		String("http.request.method", req.Method),
+		String("server.address", req.URL.Hostname()),
+		String("url.full", logging.Redact(u.String())),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return resp, err
+	}
+	span.SetAttributes(Int("http.response.status_code", resp.StatusCode))
+	if resp.ContentLength >= 0 {
+		span.SetAttributes(Int("http.response.body.size", int(resp.ContentLength)))
+	}
+	if resp.StatusCode >= 400 {
+		span.SetAttributes(String("error.type", fmt.Sprint(resp.StatusCode)))
+		span.SetStatus(StatusError, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,249 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimharder-sync/internal/config"
+	"github.com/aimharder-sync/internal/destination"
+	"github.com/aimharder-sync/internal/models"
+)
+
+// Kind is the destination kind; configured webhooks are selected as
+// "webhook:<name>"
+const Kind = "webhook"
+
+// Events sent to webhooks
+const (
+	EventCreated = "workout.created"
+	EventUpdated = "workout.updated"
+	EventDeleted = "workout.deleted"
+)
+
+// Request headers
+const (
+	HeaderEvent     = "X-AimHarder-Event"
+	HeaderDelivery  = "X-AimHarder-Delivery"
+	HeaderTimestamp = "X-AimHarder-Timestamp"
+	HeaderSignature = "X-AimHarder-Signature"
+)
+
+func init() {
+	destination.RegisterKind(Kind, func(cfg *config.Config, instance string) (destination.Destination, error) {
+		hook, err := cfg.Webhook(instance)
+		if err != nil {
+			return nil, err
+		}
+		return New(*hook, cfg.Sync.RetryAttempts, cfg.Sync.RetryDelay)
+	})
+}
+
+// Payload is the JSON body POSTed to webhooks
+type Payload struct {
+	Event       string          `json:"event"`
+	DeliveryID  string          `json:"delivery_id"`
+	SentAt      time.Time       `json:"sent_at"`
+	WorkoutID   string          `json:"workout_id,omitempty"`
+	ExternalID  string          `json:"external_id,omitempty"` // delivery ID of the original workout.created event
+	Workout     *models.Workout `json:"workout,omitempty"`
+	Description string          `json:"description,omitempty"`
+}
+
+// Destination POSTs workouts to a user-configured URL
+type Destination struct {
+	hook       config.WebhookConfig
+	httpClient *http.Client
+	attempts   int
+	retryDelay time.Duration
+}
+
+// New creates a webhook destination. Failed deliveries are attempted up
+// to attempts times, doubling retryDelay between attempts.
+func New(hook config.WebhookConfig, attempts int, retryDelay time.Duration) (*Destination, error) {
+	if hook.URL == "" {
+		return nil, fmt.Errorf("webhook %q has no url", hook.Name)
+	}
+	if attempts < 1 {
+		attempts = 1
+	}
+	if retryDelay <= 0 {
+		retryDelay = time.Second
+	}
+	return &Destination{
+		hook:       hook,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		attempts:   attempts,
+		retryDelay: retryDelay,
+	}, nil
+}
+
+// Name returns the platform name used in sync history
+func (d *Destination) Name() string {
+	return Kind + ":" + strings.ToLower(d.hook.Name)
+}
+
+// Accepts reports whether the workout type passes the configured filter
+func (d *Destination) Accepts(workout *models.Workout) bool {
+	if len(d.hook.Types) == 0 {
+		return true
+	}
+	for _, t := range d.hook.Types {
+		if strings.EqualFold(t, string(workout.Type)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate is a no-op: requests are signed, not authenticated
+func (d *Destination) Authenticate(ctx context.Context) error {
+	return nil
+}
+
+// ListExisting returns nothing; deduplication relies on sync history
+func (d *Destination) ListExisting(ctx context.Context, start, end time.Time) ([]destination.Activity, error) {
+	return nil, nil
+}
+
+// Upload sends a workout.created event
+func (d *Destination) Upload(ctx context.Context, workout *models.Workout, file string) (*destination.UploadResult, error) {
+	id, err := d.send(ctx, Payload{Event: EventCreated, Workout: workout})
+	if err != nil {
+		return nil, err
+	}
+	return &destination.UploadResult{ID: id}, nil
+}
+
+// Update sends a workout.updated event
+func (d *Destination) Update(ctx context.Context, externalID string, workout *models.Workout) error {
+	_, err := d.send(ctx, Payload{Event: EventUpdated, ExternalID: externalID, Workout: workout})
+	return err
+}
+
+// Delete sends a workout.deleted event for the workout delivered as externalID
+func (d *Destination) Delete(ctx context.Context, externalID string) error {
+	_, err := d.send(ctx, Payload{Event: EventDeleted, ExternalID: externalID})
+	return err
+}
+
+// Preview describes the event Upload would send
+func (d *Destination) Preview(workout *models.Workout, file string) *destination.Preview {
+	return &destination.Preview{
+		Name:        workout.Name,
+		Type:        string(workout.Type),
+		StartDate:   workout.Date.Format(time.RFC3339),
+		Description: workout.Description,
+		ExternalID:  workout.ID,
+		DataType:    "json (" + EventCreated + ")",
+		File:        d.hook.URL,
+	}
+}
+
+// send delivers an event, retrying on network errors, 429 and 5xx
+// responses, and returns the delivery ID
+func (d *Destination) send(ctx context.Context, payload Payload) (string, error) {
+	payload.DeliveryID = newDeliveryID()
+	payload.SentAt = time.Now().UTC()
+	if payload.Workout != nil {
+		payload.WorkoutID = payload.Workout.ID
+		payload.Description = payload.Workout.Description
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	delay := d.retryDelay
+	var lastErr error
+	for attempt := 1; attempt <= d.attempts; attempt++ {
+		retry, err := d.post(ctx, payload, body)
+		if err == nil {
+			return payload.DeliveryID, nil
+		}
+		lastErr = err
+		if !retry || attempt == d.attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	return "", fmt.Errorf("webhook %s failed after %d attempt(s): %w", d.hook.Name, d.attempts, lastErr)
+}
+
+// post sends one delivery attempt and reports whether a failure is retryable
+func (d *Destination) post(ctx context.Context, payload Payload, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(payload.SentAt.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aimharder-sync")
+	req.Header.Set(HeaderEvent, payload.Event)
+	req.Header.Set(HeaderDelivery, payload.DeliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if d.hook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(d.hook.Secret, timestamp, body))
+	}
+	for k, v := range d.hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, err
+}
+
+// Sign returns the signature header value for a request body: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers should recompute it and reject stale timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header produced by Sign
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}